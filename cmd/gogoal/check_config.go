@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"go-goal/pkg/config"
+)
+
+func runCheckConfig(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("check-config", flag.ExitOnError)
+	ping := flags.Bool("ping", false, "also connect to the database")
+	flags.Parse(args)
+
+	fmt.Printf("PORT                %s\n", cfg.Port)
+	fmt.Printf("DATABASE_URL        %s\n", cfg.RedactedDatabaseURL())
+	fmt.Printf("AUTO_MIGRATE        %t\n", cfg.AutoMigrate)
+	fmt.Printf("READ_TIMEOUT        %s\n", cfg.ReadTimeout)
+	fmt.Printf("WRITE_TIMEOUT       %s\n", cfg.WriteTimeout)
+	fmt.Printf("IDLE_TIMEOUT        %s\n", cfg.IdleTimeout)
+	fmt.Printf("SHUTDOWN_TIMEOUT    %s\n", cfg.ShutdownTimeout)
+	fmt.Printf("DB_MAX_CONNECTIONS  %d\n", cfg.DBMaxOpenConns)
+	fmt.Printf("DB_STATEMENT_TIMEOUT %s\n", cfg.DBStatementTimeout)
+	fmt.Printf("METRICS_ENABLED     %t\n", cfg.MetricsEnabled)
+	fmt.Printf("METRICS_GRAPHQL_OPERATIONS %s\n", strings.Join(cfg.MetricsOperations, ","))
+	fmt.Printf("TRACING_EXPORTER    %s\n", cfg.TracingExporter)
+	fmt.Printf("DEFAULT_LANGUAGE    %s\n", cfg.DefaultLanguage)
+	fmt.Printf("SUPPORTED_LANGUAGES %s\n", strings.Join(cfg.SupportedLanguages, ","))
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	if *ping {
+		database, err := connect(cfg)
+		if err != nil {
+			return err
+		}
+		database.Close()
+		fmt.Println("database connection OK")
+	}
+
+	fmt.Println("configuration OK")
+	return nil
+}
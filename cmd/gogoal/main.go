@@ -0,0 +1,100 @@
+// Command gogoal runs the Go Goal server and its maintenance tasks.
+//
+// Usage:
+//
+//	gogoal serve [-migrate=true]
+//	gogoal migrate up|down|status|redo [-steps N]
+//	gogoal seed [-force]
+//	gogoal check-config [-ping]
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"go-goal/internal/db"
+	"go-goal/internal/logging"
+	"go-goal/pkg/config"
+)
+
+// connectTimeout bounds the initial database ping.
+const connectTimeout = 10 * time.Second
+
+type command struct {
+	name    string
+	summary string
+	run     func(cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{"serve", "start the HTTP server", runServe},
+	{"migrate", "apply, roll back or list database migrations (up, down, status, redo)", runMigrate},
+	{"seed", "insert example projects, goals, tasks and tags", runSeed},
+	{"check-config", "validate the configuration and optionally the database connection", runCheckConfig},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+
+	cfg := config.Load()
+	// Invalid logging settings keep the default logger; Validate reports them.
+	if logger, err := logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat); err == nil {
+		slog.SetDefault(logger)
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("%s: %v", name, err)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "gogoal: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: gogoal <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-13s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'gogoal <command> -h' for the flags of a command.")
+}
+
+// connect opens the database pool described by cfg.
+func connect(cfg *config.Config) (*sql.DB, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	database, err := db.Connect(ctx, cfg.DatabaseURL, db.Pool{
+		MaxOpenConns:     cfg.DBMaxOpenConns,
+		MaxIdleConns:     cfg.DBMaxIdleConns,
+		ConnMaxLifetime:  cfg.DBConnMaxLifetime,
+		ConnMaxIdleTime:  cfg.DBConnMaxIdleTime,
+		StatementTimeout: cfg.DBStatementTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return database, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"go-goal/internal/db"
+	"go-goal/migrations"
+	"go-goal/pkg/config"
+)
+
+const migrateUsage = "usage: gogoal migrate up|down|status|redo [-steps N]"
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	action := args[0]
+
+	flags := flag.NewFlagSet("migrate "+action, flag.ExitOnError)
+	steps := flags.Int("steps", 1, "number of migrations to roll back (down only)")
+	flags.Parse(args[1:])
+
+	database, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	migrator, err := db.NewMigrator(database, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch action {
+	case "up":
+		return migrateUp(ctx, migrator)
+	case "down":
+		if *steps < 1 {
+			return errors.New("-steps must be at least 1")
+		}
+		return migrateDown(ctx, migrator, *steps)
+	case "redo":
+		if err := migrateDown(ctx, migrator, 1); err != nil {
+			return err
+		}
+		return migrateUp(ctx, migrator)
+	case "status":
+		return migrateStatus(ctx, migrator)
+	}
+	return fmt.Errorf("unknown migrate action %q\n%s", action, migrateUsage)
+}
+
+func migrateUp(ctx context.Context, migrator *db.Migrator) error {
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		fmt.Printf("applied  %03d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Println("database is up to date")
+	}
+	return nil
+}
+
+func migrateDown(ctx context.Context, migrator *db.Migrator, steps int) error {
+	reverted, err := migrator.Down(ctx, steps)
+	for _, m := range reverted {
+		fmt.Printf("reverted %03d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(reverted) == 0 {
+		fmt.Println("no applied migrations to roll back")
+	}
+	return nil
+}
+
+func migrateStatus(ctx context.Context, migrator *db.Migrator) error {
+	if err := migrator.Verify(ctx); err != nil {
+		return err
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT\tDOWN")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		down := "yes"
+		if s.Down == "" {
+			down = "no"
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", s.Version, s.Name, appliedAt, down)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/pkg/config"
+)
+
+func runSeed(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	force := flags.Bool("force", false, "seed even if projects already exist")
+	flags.Parse(args)
+
+	database, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	s := store.New(database)
+
+	existing, err := s.Projects.List(ctx, store.ProjectFilter{})
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 && !*force {
+		fmt.Printf("database already has %d project(s); use -force to seed anyway\n", len(existing))
+		return nil
+	}
+
+	if err := seed(ctx, s); err != nil {
+		return err
+	}
+	fmt.Println("seed data inserted")
+	return nil
+}
+
+// seed inserts a small example workspace: one project per example flow with
+// a goal, a few tasks and tags.
+func seed(ctx context.Context, s *store.Store) error {
+	workspaceID, err := seedWorkspace(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	flows, err := s.Flows.List(ctx, store.FlowFilter{WorkspaceID: &workspaceID})
+	if err != nil {
+		return err
+	}
+	flowIDs := map[string]*int{}
+	for i := range flows {
+		flowIDs[flows[i].Title] = &flows[i].ID
+	}
+
+	tags := map[string]*models.Tag{
+		"work":     {Name: "work", Color: "#3B82F6"},
+		"personal": {Name: "personal", Color: "#10B981"},
+		"urgent":   {Name: "urgent", Color: "#EF4444"},
+	}
+	existingTags, err := s.Tags.List(ctx, store.TagFilter{})
+	if err != nil {
+		return err
+	}
+	for i := range existingTags {
+		if _, ok := tags[existingTags[i].Name]; ok {
+			tags[existingTags[i].Name] = &existingTags[i]
+		}
+	}
+	for _, name := range []string{"work", "personal", "urgent"} {
+		if tags[name].ID != 0 {
+			continue
+		}
+		if err := s.Tags.Create(ctx, tags[name]); err != nil {
+			return fmt.Errorf("failed to create tag %q: %w", name, err)
+		}
+	}
+
+	in := func(days int) *time.Time {
+		t := time.Now().AddDate(0, 0, days)
+		return &t
+	}
+
+	examples := []struct {
+		flow    string
+		project models.Project
+		goal    models.Goal
+		tasks   []models.Task
+		tag     string
+	}{
+		{
+			flow:    "Career Development",
+			project: models.Project{Title: "Job Search", Description: "Find a new remote position", Status: "active"},
+			goal:    models.Goal{Title: "Send 20 applications", Status: "active", Priority: 3, DueDate: in(30)},
+			tasks: []models.Task{
+				{Title: "Update resume", Status: "in_progress", Priority: 3, DueDate: in(0)},
+				{Title: "Polish portfolio site", Status: "pending", Priority: 2, DueDate: in(7)},
+				{Title: "Reach out to former colleagues", Status: "pending", Priority: 1},
+			},
+			tag: "work",
+		},
+		{
+			flow:    "Health & Fitness",
+			project: models.Project{Title: "Sleep Enough", Description: "Get 8 hours of quality sleep", Status: "active"},
+			goal:    models.Goal{Title: "Lights out by 23:00 for a month", Status: "active", Priority: 2, DueDate: in(30)},
+			tasks: []models.Task{
+				{Title: "Set a wind-down alarm", Status: "completed", Priority: 2},
+				{Title: "Keep the phone out of the bedroom", Status: "pending", Priority: 1},
+			},
+			tag: "personal",
+		},
+	}
+
+	for _, ex := range examples {
+		project := ex.project
+		project.WorkspaceID = &workspaceID
+		project.FlowID = flowIDs[ex.flow]
+		if err := s.Projects.Create(ctx, &project); err != nil {
+			return fmt.Errorf("failed to create project %q: %w", project.Title, err)
+		}
+
+		goal := ex.goal
+		goal.ProjectID = &project.ID
+		goal.FlowID = project.FlowID
+		if err := s.Goals.Create(ctx, &goal); err != nil {
+			return fmt.Errorf("failed to create goal %q: %w", goal.Title, err)
+		}
+
+		for _, task := range ex.tasks {
+			task.ProjectID = &project.ID
+			task.GoalID = &goal.ID
+			task.FlowID = project.FlowID
+			if err := s.Tasks.Create(ctx, &task); err != nil {
+				return fmt.Errorf("failed to create task %q: %w", task.Title, err)
+			}
+			if task.Priority >= 3 {
+				if err := s.Tags.Assign(ctx, "task", task.ID, tags["urgent"].ID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := s.Tags.Assign(ctx, "project", project.ID, tags[ex.tag].ID); err != nil {
+			return err
+		}
+		if err := s.Tags.Assign(ctx, "goal", goal.ID, tags[ex.tag].ID); err != nil {
+			return err
+		}
+
+		note := models.Note{
+			Title:      "Why this matters",
+			Content:    "Part of the " + ex.flow + " flow.",
+			EntityType: "project",
+			EntityID:   &project.ID,
+		}
+		if err := s.Notes.Create(ctx, &note); err != nil {
+			return fmt.Errorf("failed to create note: %w", err)
+		}
+	}
+	return nil
+}
+
+// seedWorkspace returns the oldest workspace, creating one if none exist.
+func seedWorkspace(ctx context.Context, s *store.Store) (int, error) {
+	workspaces, err := s.Workspaces.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(workspaces) > 0 {
+		return workspaces[len(workspaces)-1].ID, nil
+	}
+
+	ws := models.Workspace{Name: "Default Workspace", Description: "Your primary workspace for goals and projects"}
+	if err := s.Workspaces.Create(ctx, &ws); err != nil {
+		return 0, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return ws.ID, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go-goal/internal/api"
+	"go-goal/internal/db"
+	"go-goal/internal/tracing"
+	"go-goal/migrations"
+	"go-goal/pkg/config"
+)
+
+func runServe(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	migrate := flags.Bool("migrate", cfg.AutoMigrate, "apply pending migrations before serving (AUTO_MIGRATE)")
+	flags.Parse(args)
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:       cfg.TracingExporter,
+		Endpoint:       cfg.TracingEndpoint,
+		Insecure:       cfg.TracingInsecure,
+		SampleRatio:    cfg.TracingSampleRatio,
+		ServiceName:    "gogoal",
+		ServiceVersion: cfg.AppVersion,
+		Stdout:         os.Stdout,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	database, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	migrator, err := db.NewMigrator(database, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if *migrate {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+		for _, m := range applied {
+			slog.Info("migration applied", "version", m.Version, "name", m.Name)
+		}
+	} else {
+		if err := migrator.Verify(ctx); err != nil {
+			return err
+		}
+		if pending, err := migrator.Pending(ctx); err != nil {
+			return err
+		} else if pending > 0 {
+			slog.Warn("migrations pending; run 'gogoal migrate up'", "pending", pending)
+		}
+	}
+
+	ready := api.NewReadiness(
+		api.DatabaseCheck(database),
+		api.PoolCheck(database),
+		api.MigrationCheck(migrator),
+	)
+	srv := api.NewServer(cfg, api.NewRouter(database, cfg, ready))
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("server starting", "port", cfg.Port)
+	if err := api.Serve(ctx, srv, ln, ready, cfg.ShutdownDelay, cfg.ShutdownTimeout); err != nil {
+		return fmt.Errorf("server stopped: %w", err)
+	}
+
+	// Close the pool only after every in-flight request has finished.
+	if err := database.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+	slog.Info("shutdown complete")
+	return nil
+}
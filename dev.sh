@@ -219,7 +219,7 @@ start_backend() {
     fi
     
     print_success "Backend server starting on http://localhost:${PORT}"
-    go run cmd/server/main.go &
+    go run ./cmd/gogoal serve &
     BACKEND_PID=$!
     
     # Wait a moment for backend to start
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"go-goal/internal/store"
+)
+
+type AuditHandler struct {
+	Store store.AuditStore
+}
+
+// GetAudit lists the audit events of the workspaces the user is a member
+// of, newest first.
+func (h *AuditHandler) GetAudit(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r, "entity_type", "entity_id", "actor_id", "workspace_id", "action")
+	if err != nil {
+		invalidQuery(w, r, err)
+		return
+	}
+
+	filter := store.AuditFilter{
+		EntityType:  q.EntityType,
+		EntityID:    q.EntityID,
+		ActorID:     q.ActorID,
+		WorkspaceID: q.WorkspaceID,
+		Action:      q.Action,
+	}
+	result, err := h.Store.ListPage(r.Context(), filter, q.Page)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch audit events")
+		return
+	}
+
+	total, err := h.Store.Count(r.Context(), filter)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch audit events")
+		return
+	}
+
+	writeList(w, r, result, total)
+}
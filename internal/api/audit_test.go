@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditHandler(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	h := &AuditHandler{Store: store.New(db).Audit}
+	ctx := auth.WithUser(context.Background(), &models.User{ID: 1})
+	get := func(target string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", target, nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		h.GetAudit(w, r)
+		return w
+	}
+
+	t.Run("should list the events of an entity", func(t *testing.T) {
+		mock.ExpectQuery(`FROM audit_events WHERE entity_type = \$1 AND entity_id = \$2 AND`).
+			WithArgs("goal", 5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "actor_id", "actor_email", "workspace_id", "previous_workspace_id", "entity_type", "entity_id",
+				"action", "before", "after", "request_id", "created_at",
+			}).AddRow(1, 1, "ada@example.com", 2, 1, "goal", 5, "update", []byte(`{"project_id":1}`), []byte(`{"project_id":2}`), "req-1", time.Now()))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM audit_events`).
+			WithArgs("goal", 5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		w := get("/api/v1/audit?entity_type=goal&entity_id=5")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+		assert.Contains(t, w.Body.String(), `"before":{"project_id":1},"after":{"project_id":2}`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject filters of other lists", func(t *testing.T) {
+		w := get("/api/v1/audit?status=active")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/logging"
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
+)
+
+// sessionCookie holds the session token of a signed-in browser.
+const sessionCookie = "gogoal_session"
+
+type AuthHandler struct {
+	Users    store.UserStore
+	Sessions store.SessionStore
+	Rules    validation.Rules[models.Credentials]
+	// SessionTTL is how long a session stays valid after sign-in.
+	SessionTTL time.Duration
+	// SecureCookie restricts the session cookie to HTTPS.
+	SecureCookie bool
+}
+
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var creds models.Credentials
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		invalidJSON(w, r, err)
+		return
+	}
+
+	creds.Email = normalizeEmail(creds.Email)
+	creds.Name = strings.TrimSpace(creds.Name)
+	if err := h.Rules.Validate(r.Context(), &creds); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	hash, err := auth.HashPassword(creds.Password)
+	if err != nil {
+		storeError(w, r, err, "Failed to register")
+		return
+	}
+	u := models.User{Email: creds.Email, Name: creds.Name, PasswordHash: hash}
+	if err := h.Users.Create(r.Context(), &u); err != nil {
+		storeError(w, r, err, "Failed to register")
+		return
+	}
+	if err := h.startSession(w, r, &u); err != nil {
+		storeError(w, r, err, "Failed to sign in")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(u)
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var creds models.Credentials
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		invalidJSON(w, r, err)
+		return
+	}
+
+	u, err := h.Users.GetByEmail(r.Context(), normalizeEmail(creds.Email))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		storeError(w, r, err, "Failed to sign in")
+		return
+	}
+	if !auth.CheckPassword(u, creds.Password) {
+		writeProblem(w, r, http.StatusUnauthorized, codeInvalidCredentials, "Email or password is incorrect")
+		return
+	}
+	if err := h.startSession(w, r, u); err != nil {
+		storeError(w, r, err, "Failed to sign in")
+		return
+	}
+	if _, err := h.Sessions.DeleteExpired(r.Context()); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to delete expired sessions", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(u)
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		if err := h.Sessions.Delete(r.Context(), auth.HashToken(c.Value)); err != nil {
+			storeError(w, r, err, "Failed to sign out")
+			return
+		}
+	}
+	http.SetCookie(w, h.cookie("", -1))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	u := auth.UserFrom(r.Context())
+	if u == nil {
+		unauthenticated(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(u)
+}
+
+// startSession stores a new session for u and sets its cookie.
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, u *models.User) error {
+	token, hash := auth.NewToken()
+	sess := models.Session{TokenHash: hash, UserID: u.ID, ExpiresAt: time.Now().Add(h.SessionTTL)}
+	if err := h.Sessions.Create(r.Context(), &sess); err != nil {
+		return err
+	}
+	http.SetCookie(w, h.cookie(token, int(h.SessionTTL.Seconds())))
+	return nil
+}
+
+// cookie returns the session cookie. It is hidden from scripts and not sent
+// on cross-site subrequests or form posts, which blocks CSRF.
+func (h *AuthHandler) cookie(token string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.SecureCookie,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// Authenticate identifies the caller and stores the user in the request
+// context. A bearer API token takes precedence and also restricts the
+// request to the token's scopes; an invalid one is rejected outright.
+// Otherwise the session cookie is resolved. Requests with neither continue
+// anonymously; RequireUser rejects them where sign-in is needed.
+func Authenticate(sessions store.SessionStore, tokens store.TokenStore, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if bearer, ok := bearerToken(r); ok {
+			t, u, err := tokens.Use(r.Context(), auth.HashToken(bearer))
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				invalidToken(w, r)
+			case err != nil:
+				storeError(w, r, err, "Failed to check API token")
+			default:
+				ctx := auth.WithScopes(auth.WithUser(r.Context(), u), t.Scopes)
+				next.ServeHTTP(w, r.WithContext(ctx))
+			}
+			return
+		}
+
+		c, err := r.Cookie(sessionCookie)
+		if err != nil || c.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		u, err := sessions.User(r.Context(), auth.HashToken(c.Value))
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			next.ServeHTTP(w, r)
+		case err != nil:
+			storeError(w, r, err, "Failed to load session")
+		default:
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), u)))
+		}
+	})
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// RequireUser answers 401 to requests without a signed-in user.
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.UserFrom(r.Context()) == nil {
+			unauthenticated(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope answers 403 to API token requests lacking scope.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !auth.HasScope(r.Context(), scope) {
+				insufficientScope(w, r, scope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireMethodScope requires the read scope for safe methods and the write
+// scope for everything else.
+func RequireMethodScope(next http.Handler) http.Handler {
+	read, write := RequireScope(auth.ScopeRead)(next), RequireScope(auth.ScopeWrite)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			read.ServeHTTP(w, r)
+		default:
+			write.ServeHTTP(w, r)
+		}
+	})
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
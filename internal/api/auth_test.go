@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var userColumns = []string{"id", "email", "name", "password_hash", "is_admin", "created_at", "updated_at"}
+
+func TestAuthHandler(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	stores := store.New(db)
+	h := &AuthHandler{
+		Users:        stores.Users,
+		Sessions:     stores.Sessions,
+		Rules:        validation.New(stores).Registration,
+		SessionTTL:   time.Hour,
+		SecureCookie: true,
+	}
+	post := func(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("POST", "/api/v1/auth", strings.NewReader(body)))
+		return w
+	}
+	hash, err := auth.HashPassword("correct horse")
+	require.NoError(t, err)
+
+	t.Run("should register a user and sign them in", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO users`).
+			WithArgs("ada@example.com", "Ada", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "is_admin", "created_at", "updated_at"}).AddRow(1, true, time.Now(), time.Now()))
+		mock.ExpectQuery(`INSERT INTO sessions`).
+			WithArgs(sqlmock.AnyArg(), 1, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+
+		w := post(h.Register, `{"email":" Ada@Example.com ","name":"Ada","password":"correct horse"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"email":"ada@example.com"`)
+		assert.NotContains(t, w.Body.String(), "password")
+		cookie := w.Result().Cookies()[0]
+		assert.Equal(t, sessionCookie, cookie.Name)
+		assert.True(t, cookie.HttpOnly)
+		assert.True(t, cookie.Secure)
+		assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+		assert.Equal(t, 3600, cookie.MaxAge)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject weak registrations", func(t *testing.T) {
+		w := post(h.Register, `{"email":"ada","password":"short"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		p := decodeProblem(t, w)
+		require.Len(t, p.Errors, 2)
+		assert.Equal(t, "email", p.Errors[0].Field)
+		assert.Equal(t, FieldError{Field: "password", Code: "too_short", Message: "must be at least 8 characters"}, p.Errors[1])
+	})
+
+	t.Run("should report a taken email as a conflict", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO users`).WillReturnError(&pq.Error{
+			Code:       "23505",
+			Constraint: "users_email_key",
+			Detail:     "Key (email)=(ada@example.com) already exists.",
+		})
+
+		w := post(h.Register, `{"email":"ada@example.com","password":"correct horse"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "email", decodeProblem(t, w).Errors[0].Field)
+	})
+
+	t.Run("should sign in with the right password", func(t *testing.T) {
+		mock.ExpectQuery(`FROM users WHERE email = \$1`).
+			WithArgs("ada@example.com").
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "ada@example.com", "Ada", hash, false, time.Now(), time.Now()))
+		mock.ExpectQuery(`INSERT INTO sessions`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+		mock.ExpectExec(`DELETE FROM sessions WHERE expires_at`).WillReturnResult(sqlmock.NewResult(0, 3))
+
+		w := post(h.Login, `{"email":"ADA@example.com","password":"correct horse"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, w.Result().Cookies(), 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not reveal whether the email exists", func(t *testing.T) {
+		mock.ExpectQuery(`FROM users WHERE email = \$1`).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "ada@example.com", "Ada", hash, false, time.Now(), time.Now()))
+		wrongPassword := post(h.Login, `{"email":"ada@example.com","password":"battery staple"}`)
+
+		mock.ExpectQuery(`FROM users WHERE email = \$1`).WillReturnRows(sqlmock.NewRows(userColumns))
+		unknownEmail := post(h.Login, `{"email":"bob@example.com","password":"correct horse"}`)
+
+		for _, w := range []*httptest.ResponseRecorder{wrongPassword, unknownEmail} {
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, codeInvalidCredentials, decodeProblem(t, w).Code)
+			assert.Empty(t, w.Result().Cookies())
+		}
+	})
+
+	t.Run("should delete the session and clear the cookie on logout", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM sessions WHERE token_hash = \$1`).
+			WithArgs(auth.HashToken("token")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		r := httptest.NewRequest("POST", "/api/v1/auth/logout", nil)
+		r.AddCookie(&http.Cookie{Name: sessionCookie, Value: "token"})
+		w := httptest.NewRecorder()
+
+		h.Logout(w, r)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	stores := store.New(db)
+	var seen *models.User
+	h := Authenticate(stores.Sessions, stores.Tokens, RequireUser(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = auth.UserFrom(r.Context())
+	})))
+	serve := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/api/v1/goals", nil)
+		if token != "" {
+			r.AddCookie(&http.Cookie{Name: sessionCookie, Value: token})
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("should reject requests without a session", func(t *testing.T) {
+		w := serve("")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, codeUnauthenticated, decodeProblem(t, w).Code)
+	})
+
+	t.Run("should reject expired or unknown sessions", func(t *testing.T) {
+		mock.ExpectQuery(`FROM sessions s`).WithArgs(auth.HashToken("stale")).WillReturnRows(sqlmock.NewRows(userColumns))
+
+		assert.Equal(t, http.StatusUnauthorized, serve("stale").Code)
+	})
+
+	t.Run("should put the session user into the context", func(t *testing.T) {
+		mock.ExpectQuery(`FROM sessions s`).
+			WithArgs(auth.HashToken("fresh")).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(7, "ada@example.com", "Ada", "hash", false, time.Now(), time.Now()))
+
+		w := serve("fresh")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, seen)
+		assert.Equal(t, 7, seen.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	bearer := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/api/v1/goals", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("should reject unknown or expired API tokens", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE api_tokens SET last_used_at`).WithArgs(auth.HashToken("gg_stale")).WillReturnRows(sqlmock.NewRows(nil))
+
+		w := bearer("gg_stale")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, codeInvalidToken, decodeProblem(t, w).Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should put the token owner into the context", func(t *testing.T) {
+		seen = nil
+		mock.ExpectQuery(`UPDATE api_tokens SET last_used_at`).
+			WithArgs(auth.HashToken("gg_fresh")).
+			WillReturnRows(sqlmock.NewRows(append([]string{"id", "user_id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at"}, userColumns...)).
+				AddRow(2, 9, "CI", "gg_fresh", "{read}", nil, time.Now(), time.Now(), 9, "ci@example.com", "CI", "hash", false, time.Now(), time.Now()))
+
+		w := bearer("gg_fresh")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, seen)
+		assert.Equal(t, 9, seen.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRequireMethodScope(t *testing.T) {
+	h := RequireMethodScope(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(method string, scopes ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/v1/goals", nil)
+		if scopes != nil {
+			r = r.WithContext(auth.WithScopes(r.Context(), scopes))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("should leave session requests unrestricted", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("DELETE").Code)
+	})
+
+	t.Run("should let read tokens read", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("GET", auth.ScopeRead).Code)
+	})
+
+	t.Run("should keep read tokens from writing", func(t *testing.T) {
+		w := serve("POST", auth.ScopeRead)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, codeInsufficientScope, decodeProblem(t, w).Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), `scope="write"`)
+	})
+
+	t.Run("should let admin tokens do anything", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("PATCH", auth.ScopeAdmin).Code)
+	})
+}
+
+func TestMe(t *testing.T) {
+	h := &AuthHandler{}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/v1/auth/me", nil)
+
+	h.Me(w, r.WithContext(auth.WithUser(context.Background(), &models.User{ID: 3, Email: "ada@example.com"})))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":3`)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go-goal/internal/conflicts"
+	"go-goal/internal/logging"
+	"go-goal/internal/models"
+)
+
+// The responses of task, goal and project writes carry the flow rules the
+// written entity breaks, if any, under "warnings". Rules never reject a
+// write.
+
+type taskResponse struct {
+	*models.Task
+	Warnings []models.FlowWarning `json:"warnings,omitempty"`
+}
+
+type goalResponse struct {
+	*models.Goal
+	Warnings []models.FlowWarning `json:"warnings,omitempty"`
+}
+
+type projectResponse struct {
+	*models.Project
+	Warnings []models.FlowWarning `json:"warnings,omitempty"`
+}
+
+// flowWarnings evaluates the flow rules against s. A failure to evaluate
+// them is logged rather than failing the write that already succeeded.
+func flowWarnings(r *http.Request, engine *conflicts.Engine, s conflicts.Subject) []models.FlowWarning {
+	if engine == nil {
+		return nil
+	}
+	warnings, err := engine.Check(r.Context(), s)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("flow rules failed", "entity_type", s.EntityType, "entity_id", s.ID, "error", err)
+		return nil
+	}
+	return warnings
+}
+
+type ConflictHandler struct {
+	Engine *conflicts.Engine
+}
+
+// GetConflicts reports the declared conflicts between active flows and the
+// constraints of active flows that tasks, goals and projects break.
+func (h *ConflictHandler) GetConflicts(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r, "workspace_id")
+	if err != nil {
+		invalidQuery(w, r, err)
+		return
+	}
+
+	warnings, err := h.Engine.Report(r.Context(), q.WorkspaceID)
+	if err != nil {
+		storeError(w, r, err, "Failed to evaluate flow conflicts")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(warnings)
+}
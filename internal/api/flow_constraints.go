@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+// GetFlowConstraints returns the constraints of a flow, empty if none were
+// set.
+func (h *FlowHandler) GetFlowConstraints(w http.ResponseWriter, r *http.Request) {
+	flowID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid flow ID")
+		return
+	}
+
+	c, err := h.Store.GetConstraints(r.Context(), flowID)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch flow constraints")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// SetFlowConstraints replaces the constraints of a flow.
+func (h *FlowHandler) SetFlowConstraints(w http.ResponseWriter, r *http.Request) {
+	flowID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid flow ID")
+		return
+	}
+
+	var c models.FlowConstraints
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		invalidJSON(w, r, err)
+		return
+	}
+	c.FlowID = flowID
+	if err := h.ConstraintRules.Validate(r.Context(), &c); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	err = h.Store.SetConstraints(r.Context(), &c)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to update flow constraints")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
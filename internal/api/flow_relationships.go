@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+// GetFlowRelationships lists the relationships of a flow in either
+// direction.
+func (h *FlowHandler) GetFlowRelationships(w http.ResponseWriter, r *http.Request) {
+	flowID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid flow ID")
+		return
+	}
+
+	_, err = h.Store.Get(r.Context(), flowID)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch flow")
+		return
+	}
+
+	relationships, err := h.Store.ListRelationships(r.Context(), []int{flowID})
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch flow relationships")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(relationships)
+}
+
+// CreateFlowRelationship links the flow to the flow given as
+// related_flow_id.
+func (h *FlowHandler) CreateFlowRelationship(w http.ResponseWriter, r *http.Request) {
+	flowID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid flow ID")
+		return
+	}
+
+	var rel models.FlowRelationship
+	if err := json.NewDecoder(r.Body).Decode(&rel); err != nil {
+		invalidJSON(w, r, err)
+		return
+	}
+	rel.FlowID = flowID
+	if err := h.RelationshipRules.Validate(r.Context(), &rel); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	err = h.Store.CreateRelationship(r.Context(), &rel)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to create flow relationship")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rel)
+}
+
+func (h *FlowHandler) DeleteFlowRelationship(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	flowID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid flow ID")
+		return
+	}
+	id, err := strconv.Atoi(vars["relationship_id"])
+	if err != nil {
+		badRequest(w, r, "Invalid relationship ID")
+		return
+	}
+
+	err = h.Store.DeleteRelationship(r.Context(), flowID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow relationship not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to delete flow relationship")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+// PauseFlow, ResumeFlow, ArchiveFlow and CelebrateFlow move a flow through
+// its lifecycle. The body may carry a {"reason"} for the transition
+// history.
+
+func (h *FlowHandler) PauseFlow(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, "paused")
+}
+
+func (h *FlowHandler) ResumeFlow(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, "active")
+}
+
+func (h *FlowHandler) ArchiveFlow(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, "archived")
+}
+
+func (h *FlowHandler) CelebrateFlow(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, "celebrated")
+}
+
+func (h *FlowHandler) transition(w http.ResponseWriter, r *http.Request, to string) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid flow ID")
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		invalidJSON(w, r, err)
+		return
+	}
+	t := models.FlowTransition{FlowID: id, ToStatus: to, Reason: body.Reason}
+	if err := h.TransitionRules.Validate(r.Context(), &t); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	f, err := h.Store.Transition(r.Context(), id, t.ToStatus, t.Reason)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to update flow status")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(f)
+}
+
+// GetFlowTransitions lists the status changes of a flow, oldest first.
+func (h *FlowHandler) GetFlowTransitions(w http.ResponseWriter, r *http.Request) {
+	flowID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid flow ID")
+		return
+	}
+
+	_, err = h.Store.Get(r.Context(), flowID)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch flow")
+		return
+	}
+
+	transitions, err := h.Store.ListTransitions(r.Context(), flowID)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch flow transitions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transitions)
+}
@@ -1,84 +1,69 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
 
 	"github.com/gorilla/mux"
 )
 
 type FlowHandler struct {
-	DB *sql.DB
+	Store             store.FlowStore
+	Rules             validation.Rules[models.Flow]
+	RelationshipRules validation.Rules[models.FlowRelationship]
+	ConstraintRules   validation.Rules[models.FlowConstraints]
+	TransitionRules   validation.Rules[models.FlowTransition]
+	TimelineRules     validation.Rules[models.TimelineRange]
 }
 
 func (h *FlowHandler) GetFlows(w http.ResponseWriter, r *http.Request) {
-	workspaceID := r.URL.Query().Get("workspace_id")
-	
-	var query string
-	var args []interface{}
-	
-	if workspaceID != "" {
-		query = `
-			SELECT id, title, description, color, status, start_date, end_date, parent_id, workspace_id, created_at, updated_at 
-			FROM flows 
-			WHERE workspace_id = $1
-			ORDER BY created_at DESC
-		`
-		args = append(args, workspaceID)
-	} else {
-		query = `
-			SELECT id, title, description, color, status, start_date, end_date, parent_id, workspace_id, created_at, updated_at 
-			FROM flows 
-			ORDER BY created_at DESC
-		`
-	}
-
-	rows, err := h.DB.Query(query, args...)
-	if err != nil {
-		http.Error(w, "Failed to fetch flows", http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var flows []models.Flow
-	for rows.Next() {
-		var f models.Flow
-		err := rows.Scan(&f.ID, &f.Title, &f.Description, &f.Color, &f.Status, &f.StartDate, &f.EndDate, &f.ParentID, &f.WorkspaceID, &f.CreatedAt, &f.UpdatedAt)
-		if err != nil {
-			http.Error(w, "Failed to scan flow", http.StatusInternalServerError)
-			return
-		}
-		flows = append(flows, f)
+	q, err := parseListQuery(r, "status", "workspace_id", "q")
+	if err != nil {
+		invalidQuery(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(flows)
+	filter := store.FlowFilter{
+		WorkspaceID: q.WorkspaceID,
+		Status:      q.Status,
+		Search:      q.Search,
+	}
+	result, err := h.Store.ListPage(r.Context(), filter, q.Page)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch flows")
+		return
+	}
+
+	total, err := h.Store.Count(r.Context(), filter)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch flows")
+		return
+	}
+
+	writeList(w, r, result, total)
 }
 
 func (h *FlowHandler) GetFlow(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid flow ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid flow ID")
 		return
 	}
 
-	var f models.Flow
-	err = h.DB.QueryRow(`
-		SELECT id, title, description, color, status, start_date, end_date, parent_id, workspace_id, created_at, updated_at 
-		FROM flows WHERE id = $1
-	`, id).Scan(&f.ID, &f.Title, &f.Description, &f.Color, &f.Status, &f.StartDate, &f.EndDate, &f.ParentID, &f.WorkspaceID, &f.CreatedAt, &f.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		http.Error(w, "Flow not found", http.StatusNotFound)
+	f, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to fetch flow", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch flow")
 		return
 	}
 
@@ -89,18 +74,18 @@ func (h *FlowHandler) GetFlow(w http.ResponseWriter, r *http.Request) {
 func (h *FlowHandler) CreateFlow(w http.ResponseWriter, r *http.Request) {
 	var f models.Flow
 	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
-	err := h.DB.QueryRow(`
-		INSERT INTO flows (title, description, color, status, start_date, end_date, parent_id, workspace_id) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
-		RETURNING id, created_at, updated_at
-	`, f.Title, f.Description, f.Color, f.Status, f.StartDate, f.EndDate, f.ParentID, f.WorkspaceID).Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt)
+	validation.Defaults(&f)
+	if err := h.Rules.Validate(r.Context(), &f); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err != nil {
-		http.Error(w, "Failed to create flow", http.StatusInternalServerError)
+	if err := h.Store.Create(r.Context(), &f); err != nil {
+		storeError(w, r, err, "Failed to create flow")
 		return
 	}
 
@@ -113,30 +98,29 @@ func (h *FlowHandler) UpdateFlow(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid flow ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid flow ID")
 		return
 	}
 
 	var f models.Flow
 	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
 	f.ID = id
-	err = h.DB.QueryRow(`
-		UPDATE flows 
-		SET title = $2, description = $3, color = $4, status = $5, start_date = $6, end_date = $7, parent_id = $8, workspace_id = $9
-		WHERE id = $1 
-		RETURNING updated_at
-	`, f.ID, f.Title, f.Description, f.Color, f.Status, f.StartDate, f.EndDate, f.ParentID, f.WorkspaceID).Scan(&f.UpdatedAt)
+	if err := h.Rules.Validate(r.Context(), &f); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err == sql.ErrNoRows {
-		http.Error(w, "Flow not found", http.StatusNotFound)
+	err = h.Store.Update(r.Context(), &f)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to update flow", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to update flow")
 		return
 	}
 
@@ -144,23 +128,64 @@ func (h *FlowHandler) UpdateFlow(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(f)
 }
 
-func (h *FlowHandler) DeleteFlow(w http.ResponseWriter, r *http.Request) {
+func (h *FlowHandler) PatchFlow(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid flow ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid flow ID")
 		return
 	}
 
-	result, err := h.DB.Exec("DELETE FROM flows WHERE id = $1", id)
+	f, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch flow")
+		return
+	}
+
+	if err := decodePatch(r, f); err != nil {
+		patchError(w, r, err)
+		return
+	}
+
+	f.ID = id
+	if err := h.Rules.Validate(r.Context(), f); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	err = h.Store.Update(r.Context(), f)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to update flow")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(f)
+}
+
+func (h *FlowHandler) DeleteFlow(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Failed to delete flow", http.StatusInternalServerError)
+		badRequest(w, r, "Invalid flow ID")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Flow not found", http.StatusNotFound)
+	err = h.Store.Delete(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to delete flow")
 		return
 	}
 
@@ -171,53 +196,29 @@ func (h *FlowHandler) GetFlowStats(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	flowID, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid flow ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid flow ID")
 		return
 	}
 
-	// Get flow details
-	var flow models.Flow
-	err = h.DB.QueryRow(`
-		SELECT id, title, description, color, status, start_date, end_date, parent_id, workspace_id, created_at, updated_at 
-		FROM flows WHERE id = $1
-	`, flowID).Scan(&flow.ID, &flow.Title, &flow.Description, &flow.Color, &flow.Status, &flow.StartDate, &flow.EndDate, &flow.ParentID, &flow.WorkspaceID, &flow.CreatedAt, &flow.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		http.Error(w, "Flow not found", http.StatusNotFound)
+	flow, err := h.Store.Get(r.Context(), flowID)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Flow not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to fetch flow", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch flow")
 		return
 	}
 
-	// Get stats
-	var stats struct {
-		TotalProjects    int `json:"total_projects"`
-		TotalGoals       int `json:"total_goals"`
-		TotalTasks       int `json:"total_tasks"`
-		CompletedTasks   int `json:"completed_tasks"`
-		PendingTasks     int `json:"pending_tasks"`
-		ActiveProjects   int `json:"active_projects"`
-		CompletedGoals   int `json:"completed_goals"`
+	stats, err := h.Store.Stats(r.Context(), flowID)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch flow stats")
+		return
 	}
 
-	// Count projects
-	h.DB.QueryRow("SELECT COUNT(*) FROM projects WHERE flow_id = $1", flowID).Scan(&stats.TotalProjects)
-	h.DB.QueryRow("SELECT COUNT(*) FROM projects WHERE flow_id = $1 AND status = 'active'", flowID).Scan(&stats.ActiveProjects)
-
-	// Count goals
-	h.DB.QueryRow("SELECT COUNT(*) FROM goals WHERE flow_id = $1", flowID).Scan(&stats.TotalGoals)
-	h.DB.QueryRow("SELECT COUNT(*) FROM goals WHERE flow_id = $1 AND status = 'completed'", flowID).Scan(&stats.CompletedGoals)
-
-	// Count tasks
-	h.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE flow_id = $1", flowID).Scan(&stats.TotalTasks)
-	h.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE flow_id = $1 AND status = 'completed'", flowID).Scan(&stats.CompletedTasks)
-	h.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE flow_id = $1 AND status = 'pending'", flowID).Scan(&stats.PendingTasks)
-
 	response := struct {
-		Flow  models.Flow `json:"flow"`
-		Stats interface{} `json:"stats"`
+		Flow  *models.Flow      `json:"flow"`
+		Stats *models.FlowStats `json:"stats"`
 	}{
 		Flow:  flow,
 		Stats: stats,
@@ -226,3 +227,55 @@ func (h *FlowHandler) GetFlowStats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// GetFlowTree returns the flows nested under their parents, each with the
+// stats of its whole subtree.
+func (h *FlowHandler) GetFlowTree(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r, "workspace_id")
+	if err != nil {
+		invalidQuery(w, r, err)
+		return
+	}
+
+	tree, err := h.Store.Tree(r.Context(), q.WorkspaceID)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch flow tree")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+// GetFlowTimeline lays out the dated flows active between the from and to
+// dates, both required, for drawing as a timeline.
+func (h *FlowHandler) GetFlowTimeline(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	var tr models.TimelineRange
+	var err error
+	if tr.From, err = dateParam(values, "from"); err != nil {
+		invalidQuery(w, r, err)
+		return
+	}
+	if tr.To, err = dateParam(values, "to"); err != nil {
+		invalidQuery(w, r, err)
+		return
+	}
+	if tr.WorkspaceID, err = intParam(values, "workspace_id"); err != nil {
+		invalidQuery(w, r, err)
+		return
+	}
+	if err := h.TimelineRules.Validate(r.Context(), &tr); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	timeline, err := h.Store.Timeline(r.Context(), tr)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch flow timeline")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timeline)
+}
@@ -1,67 +1,74 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"go-goal/internal/conflicts"
 	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
 
 	"github.com/gorilla/mux"
 )
 
 type GoalHandler struct {
-	DB *sql.DB
+	Store store.GoalStore
+	Rules validation.Rules[models.Goal]
+	// Conflicts, if set, evaluates flow rules after writes.
+	Conflicts *conflicts.Engine
 }
 
 func (h *GoalHandler) GetGoals(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.DB.Query(`
-		SELECT id, title, description, project_id, status, priority, due_date, created_at, updated_at 
-		FROM goals 
-		ORDER BY priority DESC, created_at DESC
-	`)
+	q, err := parseListQuery(r, "status", "priority", "project_id", "flow_id", "tag", "due_before", "due_after", "q")
 	if err != nil {
-		http.Error(w, "Failed to fetch goals", http.StatusInternalServerError)
+		invalidQuery(w, r, err)
 		return
 	}
-	defer rows.Close()
 
-	var goals []models.Goal
-	for rows.Next() {
-		var g models.Goal
-		err := rows.Scan(&g.ID, &g.Title, &g.Description, &g.ProjectID, &g.Status, &g.Priority, &g.DueDate, &g.CreatedAt, &g.UpdatedAt)
-		if err != nil {
-			http.Error(w, "Failed to scan goal", http.StatusInternalServerError)
-			return
-		}
-		goals = append(goals, g)
+	filter := store.GoalFilter{
+		ProjectID: q.ProjectID,
+		FlowID:    q.FlowID,
+		Status:    q.Status,
+		Priority:  q.Priority,
+		TagID:     q.TagID,
+		TagName:   q.TagName,
+		DueBefore: q.DueBefore,
+		DueAfter:  q.DueAfter,
+		Search:    q.Search,
+	}
+	result, err := h.Store.ListPage(r.Context(), filter, q.Page)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch goals")
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(goals)
+	total, err := h.Store.Count(r.Context(), filter)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch goals")
+		return
+	}
+
+	writeList(w, r, result, total)
 }
 
 func (h *GoalHandler) GetGoal(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid goal ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid goal ID")
 		return
 	}
 
-	var g models.Goal
-	err = h.DB.QueryRow(`
-		SELECT id, title, description, project_id, status, priority, due_date, created_at, updated_at 
-		FROM goals WHERE id = $1
-	`, id).Scan(&g.ID, &g.Title, &g.Description, &g.ProjectID, &g.Status, &g.Priority, &g.DueDate, &g.CreatedAt, &g.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		http.Error(w, "Goal not found", http.StatusNotFound)
+	g, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Goal not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to fetch goal", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch goal")
 		return
 	}
 
@@ -72,80 +79,141 @@ func (h *GoalHandler) GetGoal(w http.ResponseWriter, r *http.Request) {
 func (h *GoalHandler) CreateGoal(w http.ResponseWriter, r *http.Request) {
 	var g models.Goal
 	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
-	err := h.DB.QueryRow(`
-		INSERT INTO goals (title, description, project_id, status, priority, due_date) 
-		VALUES ($1, $2, $3, $4, $5, $6) 
-		RETURNING id, created_at, updated_at
-	`, g.Title, g.Description, g.ProjectID, g.Status, g.Priority, g.DueDate).Scan(&g.ID, &g.CreatedAt, &g.UpdatedAt)
+	validation.Defaults(&g)
+	if err := h.Rules.Validate(r.Context(), &g); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err != nil {
-		http.Error(w, "Failed to create goal", http.StatusInternalServerError)
+	if err := h.Store.Create(r.Context(), &g); err != nil {
+		storeError(w, r, err, "Failed to create goal")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(g)
+	json.NewEncoder(w).Encode(goalResponse{&g, flowWarnings(r, h.Conflicts, conflicts.GoalSubject(&g))})
 }
 
 func (h *GoalHandler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid goal ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid goal ID")
+		return
+	}
+
+	before, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Goal not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch goal")
 		return
 	}
 
 	var g models.Goal
 	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
 	g.ID = id
-	err = h.DB.QueryRow(`
-		UPDATE goals 
-		SET title = $2, description = $3, project_id = $4, status = $5, priority = $6, due_date = $7
-		WHERE id = $1 
-		RETURNING updated_at
-	`, g.ID, g.Title, g.Description, g.ProjectID, g.Status, g.Priority, g.DueDate).Scan(&g.UpdatedAt)
+	if err := h.Rules.Validate(r.Context(), &g); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err == sql.ErrNoRows {
-		http.Error(w, "Goal not found", http.StatusNotFound)
+	err = h.Store.Update(r.Context(), &g)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Goal not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to update goal", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to update goal")
 		return
 	}
 
+	var warnings []models.FlowWarning
+	if conflicts.GoalMoved(before, &g) {
+		warnings = flowWarnings(r, h.Conflicts, conflicts.GoalSubject(&g))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(g)
+	json.NewEncoder(w).Encode(goalResponse{&g, warnings})
 }
 
-func (h *GoalHandler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+func (h *GoalHandler) PatchGoal(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid goal ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid goal ID")
 		return
 	}
 
-	result, err := h.DB.Exec("DELETE FROM goals WHERE id = $1", id)
+	g, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Goal not found")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to delete goal", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch goal")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Goal not found", http.StatusNotFound)
+	before := *g
+	if err := decodePatch(r, g); err != nil {
+		patchError(w, r, err)
+		return
+	}
+
+	g.ID = id
+	if err := h.Rules.Validate(r.Context(), g); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	err = h.Store.Update(r.Context(), g)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Goal not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to update goal")
+		return
+	}
+
+	var warnings []models.FlowWarning
+	if conflicts.GoalMoved(&before, g) {
+		warnings = flowWarnings(r, h.Conflicts, conflicts.GoalSubject(g))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(goalResponse{g, warnings})
+}
+
+func (h *GoalHandler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid goal ID")
+		return
+	}
+
+	err = h.Store.Delete(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Goal not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to delete goal")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
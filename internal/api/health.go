@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-goal/internal/db"
+)
+
+// Health check statuses, from best to worst. A warning is reported but does
+// not take the instance out of rotation.
+const (
+	statusPass = "pass"
+	statusWarn = "warn"
+	statusFail = "fail"
+)
+
+const (
+	// checkTimeout bounds each readiness check.
+	checkTimeout = 2 * time.Second
+	// slowPing is the database round trip above which the check warns.
+	slowPing = 500 * time.Millisecond
+	// poolWarnRatio is the share of open connections in use above which the
+	// pool check warns.
+	poolWarnRatio = 0.8
+)
+
+// CheckResult is the outcome of one readiness check. Metrics carries the
+// measurements behind the status, such as latency or connection counts.
+type CheckResult struct {
+	Status  string         `json:"status"`
+	Detail  string         `json:"detail,omitempty"`
+	Metrics map[string]any `json:"metrics,omitempty"`
+}
+
+// HealthCheck is a named readiness check.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) CheckResult
+}
+
+// Readiness answers /readyz. It runs its checks on every probe and also
+// fails once Drain has been called, so load balancers stop routing to the
+// instance while in-flight requests complete. The zero value has no checks
+// and is ready.
+type Readiness struct {
+	checks   []HealthCheck
+	draining atomic.Bool
+}
+
+// NewReadiness returns a Readiness running checks.
+func NewReadiness(checks ...HealthCheck) *Readiness {
+	return &Readiness{checks: checks}
+}
+
+// Ready reports whether shutdown has not begun.
+func (rd *Readiness) Ready() bool {
+	return !rd.draining.Load()
+}
+
+// Drain marks the server as shutting down.
+func (rd *Readiness) Drain() {
+	rd.draining.Store(true)
+}
+
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// ServeHTTP runs every check concurrently and responds with the breakdown:
+// 200 when no check failed, 503 otherwise.
+func (rd *Readiness) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	results := make([]CheckResult, len(rd.checks))
+	var wg sync.WaitGroup
+	for i, check := range rd.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+			defer cancel()
+			results[i] = check.Check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	resp := healthResponse{Status: statusPass, Checks: map[string]CheckResult{}}
+	shutdown := CheckResult{Status: statusPass}
+	if !rd.Ready() {
+		shutdown = CheckResult{Status: statusFail, Detail: "server is shutting down"}
+	}
+	resp.Checks["shutdown"] = shutdown
+	for i, check := range rd.checks {
+		resp.Checks[check.Name] = results[i]
+	}
+	for _, result := range resp.Checks {
+		resp.Status = worse(resp.Status, result.Status)
+	}
+
+	code := http.StatusOK
+	if resp.Status == statusFail {
+		code = http.StatusServiceUnavailable
+	}
+	writeHealth(w, code, resp)
+}
+
+// Liveness answers /healthz. It only shows the process is serving requests;
+// dependencies are covered by readiness so an unreachable database does not
+// get the instance restarted.
+func Liveness(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, healthResponse{Status: statusPass})
+}
+
+func writeHealth(w http.ResponseWriter, code int, resp healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// worse returns the more severe of two statuses.
+func worse(a, b string) string {
+	rank := map[string]int{statusPass: 0, statusWarn: 1, statusFail: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// DatabaseCheck pings the database and reports the round trip. Slow pings
+// warn; failed ones fail.
+func DatabaseCheck(database *sql.DB) HealthCheck {
+	return HealthCheck{Name: "database", Check: func(ctx context.Context) CheckResult {
+		start := time.Now()
+		err := database.PingContext(ctx)
+		latency := time.Since(start)
+
+		result := CheckResult{
+			Status:  statusPass,
+			Metrics: map[string]any{"latency_ms": float64(latency.Microseconds()) / 1000},
+		}
+		switch {
+		case err != nil:
+			result.Status, result.Detail = statusFail, err.Error()
+		case latency > slowPing:
+			result.Status, result.Detail = statusWarn, fmt.Sprintf("ping took longer than %s", slowPing)
+		}
+		return result
+	}}
+}
+
+// PoolCheck reports connection pool usage. It warns when most connections
+// are in use and fails when the pool is exhausted and requests have had to
+// wait for a connection since the previous probe.
+func PoolCheck(database *sql.DB) HealthCheck {
+	var lastWaits atomic.Int64
+	return HealthCheck{Name: "pool", Check: func(ctx context.Context) CheckResult {
+		stats := database.Stats()
+		waits := stats.WaitCount - lastWaits.Swap(stats.WaitCount)
+
+		result := CheckResult{
+			Status: statusPass,
+			Metrics: map[string]any{
+				"open":       stats.OpenConnections,
+				"in_use":     stats.InUse,
+				"idle":       stats.Idle,
+				"max_open":   stats.MaxOpenConnections,
+				"wait_count": stats.WaitCount,
+			},
+		}
+		if stats.MaxOpenConnections <= 0 {
+			return result
+		}
+		usage := float64(stats.InUse) / float64(stats.MaxOpenConnections)
+		switch {
+		case stats.InUse >= stats.MaxOpenConnections && waits > 0:
+			result.Status = statusFail
+			result.Detail = fmt.Sprintf("all %d connections in use; %d request(s) waited", stats.MaxOpenConnections, waits)
+		case usage >= poolWarnRatio:
+			result.Status = statusWarn
+			result.Detail = fmt.Sprintf("%d of %d connections in use", stats.InUse, stats.MaxOpenConnections)
+		}
+		return result
+	}}
+}
+
+// MigrationCheck compares the applied schema version with the embedded
+// migrations. Pending or modified migrations fail the check; a database
+// ahead of this binary, as during a rolling deploy, only warns. It only
+// reads the migrations table, so probes never take schema locks.
+func MigrationCheck(migrator *db.Migrator) HealthCheck {
+	return HealthCheck{Name: "migrations", Check: func(ctx context.Context) CheckResult {
+		state, err := migrator.Inspect(ctx)
+		if err != nil {
+			return CheckResult{Status: statusFail, Detail: err.Error()}
+		}
+
+		result := CheckResult{
+			Status:  statusPass,
+			Metrics: map[string]any{"version": state.Applied, "latest": state.Latest, "pending": state.Pending},
+		}
+		switch {
+		case state.Pending > 0:
+			result.Status = statusFail
+			result.Detail = fmt.Sprintf("%d migration(s) pending", state.Pending)
+		case state.Modified > 0:
+			result.Status = statusFail
+			result.Detail = fmt.Sprintf("%d applied migration(s) modified", state.Modified)
+		case state.Applied > state.Latest:
+			result.Status = statusWarn
+			result.Detail = fmt.Sprintf("database is at version %d, newer than this build's %d", state.Applied, state.Latest)
+		}
+		return result
+	}}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"go-goal/internal/db"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, h http.Handler) (int, healthResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	var resp healthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestReadiness(t *testing.T) {
+	pass := HealthCheck{Name: "ok", Check: func(ctx context.Context) CheckResult {
+		return CheckResult{Status: statusPass}
+	}}
+	warn := HealthCheck{Name: "slow", Check: func(ctx context.Context) CheckResult {
+		return CheckResult{Status: statusWarn, Detail: "slow"}
+	}}
+	fail := HealthCheck{Name: "broken", Check: func(ctx context.Context) CheckResult {
+		return CheckResult{Status: statusFail, Detail: "broken"}
+	}}
+
+	t.Run("should stay ready when checks only warn", func(t *testing.T) {
+		code, resp := probe(t, NewReadiness(pass, warn))
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, statusWarn, resp.Status)
+		assert.Equal(t, statusPass, resp.Checks["ok"].Status)
+		assert.Equal(t, "slow", resp.Checks["slow"].Detail)
+		assert.Equal(t, statusPass, resp.Checks["shutdown"].Status)
+	})
+
+	t.Run("should fail when any check fails", func(t *testing.T) {
+		code, resp := probe(t, NewReadiness(pass, warn, fail))
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, statusFail, resp.Status)
+		assert.Equal(t, "broken", resp.Checks["broken"].Detail)
+	})
+
+	t.Run("should fail once draining", func(t *testing.T) {
+		ready := NewReadiness(pass)
+		ready.Drain()
+
+		code, resp := probe(t, ready)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "server is shutting down", resp.Checks["shutdown"].Detail)
+	})
+}
+
+func TestLiveness(t *testing.T) {
+	code, resp := probe(t, http.HandlerFunc(Liveness))
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, healthResponse{Status: statusPass}, resp)
+}
+
+func TestDatabaseCheck(t *testing.T) {
+	database, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer database.Close()
+	check := DatabaseCheck(database)
+
+	mock.ExpectPing()
+	result := check.Check(context.Background())
+	assert.Equal(t, statusPass, result.Status)
+	assert.Contains(t, result.Metrics, "latency_ms")
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	result = check.Check(context.Background())
+	assert.Equal(t, statusFail, result.Status)
+	assert.Equal(t, "connection refused", result.Detail)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPoolCheck(t *testing.T) {
+	database, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer database.Close()
+
+	t.Run("should pass with an unlimited pool", func(t *testing.T) {
+		result := PoolCheck(database).Check(context.Background())
+
+		assert.Equal(t, statusPass, result.Status)
+		assert.Equal(t, 0, result.Metrics["max_open"])
+	})
+
+	t.Run("should warn when most connections are in use", func(t *testing.T) {
+		database.SetMaxOpenConns(1)
+		conn, err := database.Conn(context.Background())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		result := PoolCheck(database).Check(context.Background())
+
+		assert.Equal(t, statusWarn, result.Status)
+		assert.Equal(t, "1 of 1 connections in use", result.Detail)
+	})
+}
+
+func TestMigrationCheck(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_first.sql":  {Data: []byte("CREATE TABLE a ();")},
+		"002_second.sql": {Data: []byte("CREATE TABLE b ();")},
+	}
+	records := func(versions ...int) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"version", "checksum"})
+		for _, v := range versions {
+			rows.AddRow(v, nil)
+		}
+		return rows
+	}
+	check := func(t *testing.T, expect func(mock sqlmock.Sqlmock)) CheckResult {
+		database, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer database.Close()
+		migrator, err := db.NewMigrator(database, fsys)
+		require.NoError(t, err)
+
+		expect(mock)
+
+		result := MigrationCheck(migrator).Check(context.Background())
+		assert.NoError(t, mock.ExpectationsWereMet())
+		return result
+	}
+	run := func(t *testing.T, applied ...int) CheckResult {
+		return check(t, func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery(`SELECT version, checksum FROM migrations`).WillReturnRows(records(applied...))
+		})
+	}
+
+	t.Run("should pass when every migration is applied", func(t *testing.T) {
+		result := run(t, 1, 2)
+
+		assert.Equal(t, statusPass, result.Status)
+		assert.Equal(t, map[string]any{"version": 2, "latest": 2, "pending": 0}, result.Metrics)
+	})
+
+	t.Run("should fail when migrations are pending", func(t *testing.T) {
+		result := run(t, 1)
+
+		assert.Equal(t, statusFail, result.Status)
+		assert.Equal(t, "1 migration(s) pending", result.Detail)
+	})
+
+	t.Run("should warn when the database is ahead of this build", func(t *testing.T) {
+		result := run(t, 1, 2, 3)
+
+		assert.Equal(t, statusWarn, result.Status)
+	})
+
+	t.Run("should fail when an applied migration was modified", func(t *testing.T) {
+		result := check(t, func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery(`SELECT version, checksum FROM migrations`).
+				WillReturnRows(sqlmock.NewRows([]string{"version", "checksum"}).AddRow(1, "stale").AddRow(2, nil))
+		})
+
+		assert.Equal(t, statusFail, result.Status)
+		assert.Equal(t, "1 applied migration(s) modified", result.Detail)
+	})
+
+	t.Run("should report every migration pending without a migrations table", func(t *testing.T) {
+		result := check(t, func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery(`SELECT version, checksum FROM migrations`).
+				WillReturnError(&pq.Error{Code: "42P01"})
+		})
+
+		assert.Equal(t, statusFail, result.Status)
+		assert.Equal(t, map[string]any{"version": 0, "latest": 2, "pending": 2}, result.Metrics)
+	})
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"go-goal/internal/store"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// listQuery holds the query parameters shared by the list endpoints:
+//
+//	limit      page size, default 50, at most 200
+//	cursor     opaque position returned in the previous page's Link header
+//	sort       field to order by, prefixed with "-" for descending order
+//	status, priority, project_id, goal_id, flow_id, workspace_id
+//	           exact-match filters
+//	tag        tag ID or tag name
+//	due_before, due_after
+//	           due date bounds as YYYY-MM-DD or RFC 3339
+//	q          case-insensitive text search
+//	entity_type, entity_id, actor_id, action
+//	           audit event filters
+//
+// Each endpoint lists the filters it supports; any other filter is rejected
+// rather than silently ignored.
+type listQuery struct {
+	Page        store.Page
+	Status      *string
+	Priority    *int
+	ProjectID   *int
+	GoalID      *int
+	FlowID      *int
+	WorkspaceID *int
+	TagID       *int
+	TagName     *string
+	DueBefore   *time.Time
+	DueAfter    *time.Time
+	Search      *string
+	EntityType  *string
+	EntityID    *int
+	ActorID     *int
+	Action      *string
+}
+
+var listFilters = []string{
+	"status", "priority", "project_id", "goal_id", "flow_id", "workspace_id",
+	"tag", "due_before", "due_after", "q",
+	"entity_type", "entity_id", "actor_id", "action",
+}
+
+func parseListQuery(r *http.Request, supported ...string) (listQuery, error) {
+	values := r.URL.Query()
+	q := listQuery{Page: store.Page{Limit: defaultListLimit, Sort: values.Get("sort")}}
+
+	for _, name := range listFilters {
+		if values.Has(name) && !slices.Contains(supported, name) {
+			return q, FieldError{Field: name, Code: "unsupported", Message: "is not supported by this endpoint"}
+		}
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return q, FieldError{Field: "limit", Code: "invalid", Message: "must be a positive integer"}
+		}
+		q.Page.Limit = min(limit, maxListLimit)
+	}
+	if v := values.Get("cursor"); v != "" {
+		cursor, err := store.ParseCursor(v)
+		if err != nil {
+			return q, FieldError{Field: "cursor", Code: "invalid", Message: "is not a cursor returned by this API"}
+		}
+		q.Page.After = cursor
+	}
+
+	var err error
+	if v := values.Get("status"); v != "" {
+		q.Status = &v
+	}
+	if q.Priority, err = intParam(values, "priority"); err != nil {
+		return q, err
+	}
+	if q.ProjectID, err = intParam(values, "project_id"); err != nil {
+		return q, err
+	}
+	if q.GoalID, err = intParam(values, "goal_id"); err != nil {
+		return q, err
+	}
+	if q.FlowID, err = intParam(values, "flow_id"); err != nil {
+		return q, err
+	}
+	if q.WorkspaceID, err = intParam(values, "workspace_id"); err != nil {
+		return q, err
+	}
+	if v := values.Get("tag"); v != "" {
+		if id, err := strconv.Atoi(v); err == nil {
+			q.TagID = &id
+		} else {
+			q.TagName = &v
+		}
+	}
+	if q.DueBefore, err = dateParam(values, "due_before"); err != nil {
+		return q, err
+	}
+	if q.DueAfter, err = dateParam(values, "due_after"); err != nil {
+		return q, err
+	}
+	if v := values.Get("q"); v != "" {
+		q.Search = &v
+	}
+	if v := values.Get("entity_type"); v != "" {
+		q.EntityType = &v
+	}
+	if q.EntityID, err = intParam(values, "entity_id"); err != nil {
+		return q, err
+	}
+	if q.ActorID, err = intParam(values, "actor_id"); err != nil {
+		return q, err
+	}
+	if v := values.Get("action"); v != "" {
+		q.Action = &v
+	}
+	return q, nil
+}
+
+func intParam(values url.Values, name string) (*int, error) {
+	v := values.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, FieldError{Field: name, Code: "invalid", Message: "must be an integer"}
+	}
+	return &n, nil
+}
+
+func dateParam(values url.Values, name string) (*time.Time, error) {
+	v := values.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, FieldError{Field: name, Code: "invalid", Message: "must be a date (YYYY-MM-DD) or RFC 3339 timestamp"}
+}
+
+// writeList encodes one page of a list endpoint. The total number of
+// matching rows is sent in X-Total-Count and the first and next pages are
+// advertised in an RFC 8288 Link header.
+func writeList[T any](w http.ResponseWriter, r *http.Request, result store.PageResult[T], total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	first := pageURL(r, "")
+	links := fmt.Sprintf(`<%s>; rel="first"`, first)
+	if result.More {
+		next := pageURL(r, result.Cursors[len(result.Cursors)-1].String())
+		links += fmt.Sprintf(`, <%s>; rel="next"`, next)
+	}
+	w.Header().Set("Link", links)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result.Items)
+}
+
+// pageURL returns the request URL with its cursor parameter replaced.
+func pageURL(r *http.Request, cursor string) string {
+	values := r.URL.Query()
+	values.Del("cursor")
+	if cursor != "" {
+		values.Set("cursor", cursor)
+	}
+
+	u := url.URL{Path: r.URL.Path, RawQuery: values.Encode()}
+	return u.String()
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-goal/internal/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListQuery(t *testing.T) {
+	t.Run("should parse filters, sort and paging", func(t *testing.T) {
+		cursor := store.Cursor{Sort: "-due_date", Key: "3", ID: 9}.String()
+		r := httptest.NewRequest("GET", "/api/v1/tasks?limit=500&cursor="+cursor+"&sort=-due_date&status=pending&tag=urgent&due_before=2025-06-01&q=report", nil)
+
+		q, err := parseListQuery(r, "status", "tag", "due_before", "q")
+
+		require.NoError(t, err)
+		assert.Equal(t, maxListLimit, q.Page.Limit)
+		assert.Equal(t, &store.Cursor{Sort: "-due_date", Key: "3", ID: 9}, q.Page.After)
+		assert.Equal(t, "-due_date", q.Page.Sort)
+		assert.Equal(t, "pending", *q.Status)
+		assert.Nil(t, q.TagID)
+		assert.Equal(t, "urgent", *q.TagName)
+		assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), *q.DueBefore)
+		assert.Equal(t, "report", *q.Search)
+	})
+
+	t.Run("should treat a numeric tag as an ID", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/api/v1/notes?tag=4", nil)
+
+		q, err := parseListQuery(r, "tag")
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, *q.TagID)
+		assert.Nil(t, q.TagName)
+	})
+
+	t.Run("should reject filters the endpoint does not support", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/api/v1/notes?priority=1", nil)
+
+		_, err := parseListQuery(r, "tag", "q")
+
+		assert.Equal(t, FieldError{Field: "priority", Code: "unsupported", Message: "is not supported by this endpoint"}, err)
+	})
+
+	t.Run("should reject malformed values", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "cursor=!!", "project_id=x", "due_after=tomorrow"} {
+			r := httptest.NewRequest("GET", "/api/v1/tasks?"+query, nil)
+
+			_, err := parseListQuery(r, "project_id", "due_after")
+
+			assert.Error(t, err, query)
+		}
+	})
+}
+
+func TestGetTasks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	h := &TaskHandler{Store: store.New(db).Tasks}
+	columns := []string{
+		"id", "title", "description", "goal_id", "project_id", "flow_id",
+		"status", "priority", "due_date", "created_at", "updated_at",
+	}
+
+	t.Run("should page results and advertise the next page", func(t *testing.T) {
+		mock.ExpectQuery(`FROM tasks WHERE project_id = \$1 ORDER BY priority DESC, id DESC LIMIT 2`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(5, "Five", "", nil, 2, nil, "pending", 3, nil, time.Now(), time.Now()).
+				AddRow(4, "Four", "", nil, 2, nil, "pending", 1, nil, time.Now(), time.Now()))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM tasks WHERE project_id = \$1`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+		r := httptest.NewRequest("GET", "/api/v1/tasks?project_id=2&limit=1", nil)
+		w := httptest.NewRecorder()
+		h.GetTasks(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "12", w.Header().Get("X-Total-Count"))
+		next := store.Cursor{Sort: "-priority", Key: "3", ID: 5}.String()
+		assert.Equal(t,
+			`</api/v1/tasks?limit=1&project_id=2>; rel="first", </api/v1/tasks?cursor=`+next+`&limit=1&project_id=2>; rel="next"`,
+			w.Header().Get("Link"))
+		assert.Contains(t, w.Body.String(), `"title":"Five"`)
+		assert.NotContains(t, w.Body.String(), `"title":"Four"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject cursors of another sort order", func(t *testing.T) {
+		cursor := store.Cursor{Sort: "-priority", Key: "3", ID: 5}.String()
+		r := httptest.NewRequest("GET", "/api/v1/tasks?sort=due_date&cursor="+cursor, nil)
+		w := httptest.NewRecorder()
+		h.GetTasks(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"cursor"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject unknown sort fields", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/api/v1/tasks?sort=color", nil)
+		w := httptest.NewRecorder()
+		h.GetTasks(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go-goal/internal/logging"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIDHeader carries the request ID in both directions. An ID set by a
+// proxy or client is kept so logs can be correlated across services.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds incoming IDs so they cannot bloat every log line.
+const maxRequestIDLen = 128
+
+// responseRecorder captures the status and size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// RequestLogger assigns every request an ID, stores a logger carrying it
+// (and the trace ID of traced requests) in the request context and logs one
+// line per request once it completes: method, route template, status,
+// latency and response size. Server errors are logged at error level.
+func RequestLogger(logger *slog.Logger, router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		requestLogger := logger
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			requestLogger = logger.With("trace_id", sc.TraceID().String())
+		}
+		ctx := logging.WithRequest(r.Context(), requestLogger, id)
+		rec := &responseRecorder{ResponseWriter: w}
+		router.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		logging.FromContext(ctx).LogAttrs(ctx, level, "request",
+			slog.String("method", r.Method),
+			slog.String("route", routeTemplate(router, r)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", rec.bytes),
+		)
+	})
+}
+
+// routeTemplate returns the template of the route matching r, such as
+// /api/v1/goals/{id:[0-9]+}, so requests for different IDs group together.
+// Unmatched requests report "unmatched".
+func routeTemplate(router *mux.Router, r *http.Request) string {
+	var match mux.RouteMatch
+	if router.Match(r, &match) && match.Route != nil {
+		if tmpl, err := match.Route.GetPathTemplate(); err == nil {
+			return tmpl
+		}
+	}
+	return "unmatched"
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-goal/internal/logging"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info", "json")
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/goals/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}).Methods("GET")
+	router.HandleFunc("/api/v1/boom", func(w http.ResponseWriter, r *http.Request) {
+		storeError(w, r, errors.New("connection reset"), "Failed to fetch goals")
+	})
+	h := RequestLogger(logger, router)
+
+	serve := func(req *http.Request) (*httptest.ResponseRecorder, []map[string]any) {
+		buf.Reset()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		var lines []map[string]any
+		for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var line map[string]any
+			require.NoError(t, json.Unmarshal([]byte(raw), &line))
+			lines = append(lines, line)
+		}
+		return w, lines
+	}
+
+	t.Run("should log the route template, status and size", func(t *testing.T) {
+		w, lines := serve(httptest.NewRequest("GET", "/api/v1/goals/42", nil))
+
+		require.Len(t, lines, 1)
+		assert.Equal(t, "GET", lines[0]["method"])
+		assert.Equal(t, "/api/v1/goals/{id:[0-9]+}", lines[0]["route"])
+		assert.Equal(t, "/api/v1/goals/42", lines[0]["path"])
+		assert.Equal(t, float64(200), lines[0]["status"])
+		assert.Equal(t, float64(2), lines[0]["bytes"])
+		assert.Contains(t, lines[0], "duration_ms")
+		assert.Len(t, w.Header().Get(requestIDHeader), 32)
+		assert.Equal(t, w.Header().Get(requestIDHeader), lines[0]["request_id"])
+	})
+
+	t.Run("should keep an incoming request ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/goals/1", nil)
+		req.Header.Set(requestIDHeader, "edge-7f3a")
+
+		w, lines := serve(req)
+
+		assert.Equal(t, "edge-7f3a", w.Header().Get(requestIDHeader))
+		assert.Equal(t, "edge-7f3a", lines[0]["request_id"])
+	})
+
+	t.Run("should replace a malformed request ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/goals/1", nil)
+		req.Header.Set(requestIDHeader, "has spaces")
+
+		w, _ := serve(req)
+
+		assert.NotEqual(t, "has spaces", w.Header().Get(requestIDHeader))
+	})
+
+	t.Run("should tag handler errors with the request ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/boom", nil)
+		req.Header.Set(requestIDHeader, "req-9")
+
+		_, lines := serve(req)
+
+		require.Len(t, lines, 2)
+		assert.Equal(t, "request failed", lines[0]["msg"])
+		assert.Equal(t, "connection reset", lines[0]["error"])
+		assert.Equal(t, "req-9", lines[0]["request_id"])
+		assert.Equal(t, "ERROR", lines[1]["level"])
+		assert.Equal(t, float64(500), lines[1]["status"])
+	})
+
+	t.Run("should report unmatched routes", func(t *testing.T) {
+		_, lines := serve(httptest.NewRequest("GET", "/nowhere", nil))
+
+		assert.Equal(t, "unmatched", lines[0]["route"])
+		assert.Equal(t, float64(404), lines[0]["status"])
+	})
+}
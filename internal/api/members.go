@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+type MemberHandler struct {
+	Store store.MemberStore
+	Users store.UserStore
+	Rules validation.Rules[models.WorkspaceMember]
+}
+
+func (h *MemberHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid workspace ID")
+		return
+	}
+
+	members, err := h.Store.List(r.Context(), workspaceID)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Workspace not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch members")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+// AddMember adds the user with the given email to the workspace.
+func (h *MemberHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid workspace ID")
+		return
+	}
+
+	var m models.WorkspaceMember
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		invalidJSON(w, r, err)
+		return
+	}
+	m.WorkspaceID = workspaceID
+	m.Email = normalizeEmail(m.Email)
+	if err := h.Rules.Validate(r.Context(), &m); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	u, err := h.Users.GetByEmail(r.Context(), m.Email)
+	if errors.Is(err, store.ErrNotFound) {
+		writeProblem(w, r, http.StatusUnprocessableEntity, codeInvalidReference, "No user has this email",
+			FieldError{Field: "email", Code: "invalid_reference", Message: "must be the email of a registered user"})
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to add member")
+		return
+	}
+	m.UserID, m.Name = u.ID, u.Name
+
+	err = h.Store.Add(r.Context(), &m)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Workspace not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to add member")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(m)
+}
+
+// UpdateMember changes the role of a member.
+func (h *MemberHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	workspaceID, userID, ok := memberIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var m models.WorkspaceMember
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		invalidJSON(w, r, err)
+		return
+	}
+	m.WorkspaceID, m.UserID = workspaceID, userID
+	if err := h.Rules.Validate(r.Context(), &m); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	err := h.Store.Update(r.Context(), &m)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Member not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to update member")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+// RemoveMember removes a member from the workspace. Members may remove
+// themselves to leave it.
+func (h *MemberHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	workspaceID, userID, ok := memberIDs(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.Store.Remove(r.Context(), workspaceID, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Member not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to remove member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// memberIDs parses the workspace and user IDs of a member route, reporting
+// malformed ones.
+func memberIDs(w http.ResponseWriter, r *http.Request) (workspaceID, userID int, ok bool) {
+	vars := mux.Vars(r)
+	workspaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid workspace ID")
+		return 0, 0, false
+	}
+	userID, err = strconv.Atoi(vars["user_id"])
+	if err != nil {
+		badRequest(w, r, "Invalid user ID")
+		return 0, 0, false
+	}
+	return workspaceID, userID, true
+}
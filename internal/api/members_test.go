@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberHandler(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	stores := store.New(db)
+	h := &MemberHandler{Store: stores.Members, Users: stores.Users, Rules: validation.New(stores).Member}
+	ctx := auth.WithUser(context.Background(), &models.User{ID: 1})
+	add := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/api/v1/workspaces/2/members", strings.NewReader(body))
+		r = mux.SetURLVars(r.WithContext(ctx), map[string]string{"id": "2"})
+		w := httptest.NewRecorder()
+		h.AddMember(w, r)
+		return w
+	}
+	role := func(role string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "role"}).AddRow(2, role)
+	}
+
+	t.Run("should add a registered user by email", func(t *testing.T) {
+		mock.ExpectQuery(`FROM users WHERE email = \$1`).
+			WithArgs("grace@example.com").
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(5, "grace@example.com", "Grace", "hash", false, time.Now(), time.Now()))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).WithArgs(1, 2).WillReturnRows(role("owner"))
+		mock.ExpectQuery(`INSERT INTO workspace_members`).
+			WithArgs(2, 5, "editor").
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+		mock.ExpectExec(`INSERT INTO audit_events`).
+			WithArgs(1, 2, nil, "member", 5, "create", nil, `{"role":"editor","user_id":5,"workspace_id":2}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		w := add(`{"email":"Grace@example.com","role":"editor"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"user_id":5`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject unknown roles", func(t *testing.T) {
+		w := add(`{"email":"grace@example.com","role":"admin"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "role", decodeProblem(t, w).Errors[0].Field)
+	})
+
+	t.Run("should forbid non-owners", func(t *testing.T) {
+		mock.ExpectQuery(`FROM users WHERE email = \$1`).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(5, "grace@example.com", "Grace", "hash", false, time.Now(), time.Now()))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).WithArgs(1, 2).WillReturnRows(role("editor"))
+		mock.ExpectRollback()
+
+		w := add(`{"email":"grace@example.com","role":"viewer"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, codeForbidden, decodeProblem(t, w).Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should refuse to remove the last owner", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM workspace_members\s+WHERE workspace_id = \$1 AND role = 'owner'\s+FOR UPDATE`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+		mock.ExpectRollback()
+
+		r := httptest.NewRequest("DELETE", "/api/v1/workspaces/2/members/1", nil)
+		r = mux.SetURLVars(r.WithContext(ctx), map[string]string{"id": "2", "user_id": "1"})
+		w := httptest.NewRecorder()
+		h.RemoveMember(w, r)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, codeConflict, decodeProblem(t, w).Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	database, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer database.Close()
+
+	m := NewMetrics(database)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/goals/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	router.Handle("/metrics", m.Handler())
+	h := m.Instrument(router, router)
+
+	t.Run("should count requests per route template", func(t *testing.T) {
+		for _, path := range []string{"/api/v1/goals/1", "/api/v1/goals/2", "/missing"} {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		}
+
+		assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "/api/v1/goals/{id:[0-9]+}", "200")))
+		assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "unmatched", "404")))
+	})
+
+	t.Run("should expose HTTP and pool metrics", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+		body := w.Body.String()
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.Contains(body, `gogoal_http_request_duration_seconds_count{method="GET",route="/api/v1/goals/{id:[0-9]+}"} 2`), body)
+		assert.Contains(t, body, `go_sql_max_open_connections{db_name="postgres"}`)
+		assert.Contains(t, body, `go_sql_wait_count_total{db_name="postgres"}`)
+	})
+}
@@ -1,67 +1,65 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
 
 	"github.com/gorilla/mux"
 )
 
 type NoteHandler struct {
-	DB *sql.DB
+	Store store.NoteStore
+	Rules validation.Rules[models.Note]
 }
 
 func (h *NoteHandler) GetNotes(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.DB.Query(`
-		SELECT id, title, content, entity_id, entity_type, created_at, updated_at 
-		FROM notes 
-		ORDER BY created_at DESC
-	`)
+	q, err := parseListQuery(r, "tag", "q")
 	if err != nil {
-		http.Error(w, "Failed to fetch notes", http.StatusInternalServerError)
+		invalidQuery(w, r, err)
 		return
 	}
-	defer rows.Close()
 
-	var notes []models.Note
-	for rows.Next() {
-		var n models.Note
-		err := rows.Scan(&n.ID, &n.Title, &n.Content, &n.EntityID, &n.EntityType, &n.CreatedAt, &n.UpdatedAt)
-		if err != nil {
-			http.Error(w, "Failed to scan note", http.StatusInternalServerError)
-			return
-		}
-		notes = append(notes, n)
+	filter := store.NoteFilter{
+		TagID:   q.TagID,
+		TagName: q.TagName,
+		Search:  q.Search,
+	}
+	result, err := h.Store.ListPage(r.Context(), filter, q.Page)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch notes")
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(notes)
+	total, err := h.Store.Count(r.Context(), filter)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch notes")
+		return
+	}
+
+	writeList(w, r, result, total)
 }
 
 func (h *NoteHandler) GetNote(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid note ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid note ID")
 		return
 	}
 
-	var n models.Note
-	err = h.DB.QueryRow(`
-		SELECT id, title, content, entity_id, entity_type, created_at, updated_at 
-		FROM notes WHERE id = $1
-	`, id).Scan(&n.ID, &n.Title, &n.Content, &n.EntityID, &n.EntityType, &n.CreatedAt, &n.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		http.Error(w, "Note not found", http.StatusNotFound)
+	n, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Note not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to fetch note", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch note")
 		return
 	}
 
@@ -72,18 +70,18 @@ func (h *NoteHandler) GetNote(w http.ResponseWriter, r *http.Request) {
 func (h *NoteHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
 	var n models.Note
 	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
-	err := h.DB.QueryRow(`
-		INSERT INTO notes (title, content, entity_id, entity_type) 
-		VALUES ($1, $2, $3, $4) 
-		RETURNING id, created_at, updated_at
-	`, n.Title, n.Content, n.EntityID, n.EntityType).Scan(&n.ID, &n.CreatedAt, &n.UpdatedAt)
+	validation.Defaults(&n)
+	if err := h.Rules.Validate(r.Context(), &n); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err != nil {
-		http.Error(w, "Failed to create note", http.StatusInternalServerError)
+	if err := h.Store.Create(r.Context(), &n); err != nil {
+		storeError(w, r, err, "Failed to create note")
 		return
 	}
 
@@ -96,30 +94,29 @@ func (h *NoteHandler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid note ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid note ID")
 		return
 	}
 
 	var n models.Note
 	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
 	n.ID = id
-	err = h.DB.QueryRow(`
-		UPDATE notes 
-		SET title = $2, content = $3, entity_id = $4, entity_type = $5
-		WHERE id = $1 
-		RETURNING updated_at
-	`, n.ID, n.Title, n.Content, n.EntityID, n.EntityType).Scan(&n.UpdatedAt)
+	if err := h.Rules.Validate(r.Context(), &n); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err == sql.ErrNoRows {
-		http.Error(w, "Note not found", http.StatusNotFound)
+	err = h.Store.Update(r.Context(), &n)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Note not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to update note", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to update note")
 		return
 	}
 
@@ -127,25 +124,66 @@ func (h *NoteHandler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(n)
 }
 
-func (h *NoteHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
+func (h *NoteHandler) PatchNote(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid note ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid note ID")
+		return
+	}
+
+	n, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Note not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch note")
+		return
+	}
+
+	if err := decodePatch(r, n); err != nil {
+		patchError(w, r, err)
+		return
+	}
+
+	n.ID = id
+	if err := h.Rules.Validate(r.Context(), n); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	err = h.Store.Update(r.Context(), n)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Note not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to update note")
 		return
 	}
 
-	result, err := h.DB.Exec("DELETE FROM notes WHERE id = $1", id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(n)
+}
+
+func (h *NoteHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Failed to delete note", http.StatusInternalServerError)
+		badRequest(w, r, "Invalid note ID")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Note not found", http.StatusNotFound)
+	err = h.Store.Delete(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Note not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to delete note")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
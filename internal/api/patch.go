@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// mergePatchType is the media type of RFC 7396 JSON merge patches.
+const mergePatchType = "application/merge-patch+json"
+
+var (
+	errPatchMediaType = errors.New("patch must be sent as " + mergePatchType)
+	errPatchNotObject = errors.New("patch must be a JSON object")
+)
+
+// decodePatch applies the JSON merge patch (RFC 7396) in the request body to
+// v. Members present in the patch replace those of v, members set to null
+// are cleared and absent members are left untouched. Plain application/json
+// bodies are accepted as merge patches too.
+func decodePatch[T any](r *http.Request, v *T) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != mergePatchType && mediaType != "application/json" {
+		return errPatchMediaType
+	}
+
+	var patch any
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, ok := patch.(map[string]any); !ok {
+		return errPatchNotObject
+	}
+
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return err
+	}
+	if doc, err = json.Marshal(mergePatch(target, patch)); err != nil {
+		return err
+	}
+
+	var patched T
+	if err := json.Unmarshal(doc, &patched); err != nil {
+		return fmt.Errorf("invalid patch: %w", err)
+	}
+	*v = patched
+	return nil
+}
+
+// mergePatch implements the MergePatch algorithm of RFC 7396 section 2 on
+// decoded JSON values.
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+		} else {
+			t[name] = mergePatch(t[name], value)
+		}
+	}
+	return t
+}
+
+// patchError reports a decodePatch failure.
+func patchError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errPatchMediaType):
+		w.Header().Set("Accept-Patch", mergePatchType)
+		writeProblem(w, r, http.StatusUnsupportedMediaType, codeUnsupportedMediaType, err.Error())
+	case errors.Is(err, errPatchNotObject):
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidJSON, err.Error())
+	default:
+		invalidJSON(w, r, err)
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-goal/internal/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396 appendix A.
+	cases := []struct{ target, patch, result string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, c := range cases {
+		var target, patch any
+		require.NoError(t, json.Unmarshal([]byte(c.target), &target))
+		require.NoError(t, json.Unmarshal([]byte(c.patch), &patch))
+
+		result, err := json.Marshal(mergePatch(target, patch))
+
+		require.NoError(t, err)
+		assert.JSONEq(t, c.result, string(result), "%s + %s", c.target, c.patch)
+	}
+}
+
+func TestPatchTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	h := &TaskHandler{Store: store.New(db).Tasks}
+	columns := []string{
+		"id", "title", "description", "goal_id", "project_id", "flow_id",
+		"status", "priority", "due_date", "created_at", "updated_at",
+	}
+	due := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	patch := func(body, contentType string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("PATCH", "/api/v1/tasks/7", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		r = mux.SetURLVars(r, map[string]string{"id": "7"})
+		w := httptest.NewRecorder()
+		h.PatchTask(w, r)
+		return w
+	}
+
+	t.Run("should keep absent fields and clear null ones", func(t *testing.T) {
+		mock.ExpectQuery(`FROM tasks WHERE id = \$1`).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(7, "Write report", "Quarterly numbers", nil, 2, nil, "pending", 3, due, now, now))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE tasks`).
+			WithArgs(7, "Write report", "Quarterly numbers", nil, 2, nil, "completed", 3, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(now, now))
+		mock.ExpectCommit()
+
+		w := patch(`{"status":"completed","due_date":null}`, mergePatchType)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, "Write report", got["title"])
+		assert.Equal(t, "completed", got["status"])
+		assert.Nil(t, got["due_date"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject other media types", func(t *testing.T) {
+		mock.ExpectQuery(`FROM tasks WHERE id = \$1`).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(7, "Write report", "", nil, nil, nil, "pending", 3, nil, now, now))
+
+		w := patch(`status=completed`, "application/x-www-form-urlencoded")
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Equal(t, mergePatchType, w.Header().Get("Accept-Patch"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject patches of the wrong type", func(t *testing.T) {
+		mock.ExpectQuery(`FROM tasks WHERE id = \$1`).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(7, "Write report", "", nil, nil, nil, "pending", 3, nil, now, now))
+
+		w := patch(`{"priority":"high"}`, mergePatchType)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return 404 for unknown tasks", func(t *testing.T) {
+		mock.ExpectQuery(`FROM tasks WHERE id = \$1`).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		w := patch(`{"status":"completed"}`, mergePatchType)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"time"
+
+	"go-goal/internal/logging"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
+)
+
+// problemType is the media type of RFC 7807 problem details.
+const problemType = "application/problem+json"
+
+// Problem codes are stable identifiers clients can branch on; unlike titles
+// and details they never change wording.
+const (
+	codeInvalidRequest       = "invalid_request"
+	codeInvalidJSON          = "invalid_json"
+	codeValidation           = "validation_failed"
+	codeUnauthenticated      = "unauthenticated"
+	codeInvalidCredentials   = "invalid_credentials"
+	codeInvalidToken         = "invalid_token"
+	codeInsufficientScope    = "insufficient_scope"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeConflict             = "conflict"
+	codeInvalidReference     = "invalid_reference"
+	codeConstraint           = "constraint_violation"
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeInternal             = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details object. Code and Errors are
+// extension members.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError points at a single invalid request field or query parameter.
+type FieldError = validation.FieldError
+
+// writeProblem sends a problem details response. The problem type is left as
+// about:blank; clients should branch on code.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string, errs ...FieldError) {
+	w.Header().Set("Content-Type", problemType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+		Errors:   errs,
+	})
+}
+
+// badRequest reports a malformed request, such as an unparsable path ID.
+func badRequest(w http.ResponseWriter, r *http.Request, detail string, errs ...FieldError) {
+	writeProblem(w, r, http.StatusBadRequest, codeInvalidRequest, detail, errs...)
+}
+
+// invalidQuery reports an error returned by parseListQuery.
+func invalidQuery(w http.ResponseWriter, r *http.Request, err error) {
+	var fe FieldError
+	if errors.As(err, &fe) {
+		badRequest(w, r, "Invalid query parameters", fe)
+		return
+	}
+	badRequest(w, r, err.Error())
+}
+
+// invalidJSON reports a request body that could not be decoded. Type
+// mismatches are attributed to the offending field.
+func invalidJSON(w http.ResponseWriter, r *http.Request, err error) {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON", FieldError{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: "must be of type " + jsonType(typeErr.Type),
+		})
+		return
+	}
+	writeProblem(w, r, http.StatusBadRequest, codeInvalidJSON, "Invalid JSON")
+}
+
+// jsonType names the JSON type a Go type is decoded from.
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeFor[time.Time]():
+		return "string"
+	case t.Kind() == reflect.Bool:
+		return "boolean"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Float64:
+		return "number"
+	case t.Kind() == reflect.String:
+		return "string"
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return "array"
+	}
+	return "object"
+}
+
+// invalid reports an error returned by validation.Rules.Validate. Rule
+// failures are listed per field in a 422 response.
+func invalid(w http.ResponseWriter, r *http.Request, err error) {
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		writeProblem(w, r, http.StatusUnprocessableEntity, codeValidation, "Validation failed", errs...)
+		return
+	}
+	storeError(w, r, err, "Failed to validate request")
+}
+
+// unauthenticated reports a request that needs a signed-in user or an API
+// token.
+func unauthenticated(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="gogoal"`)
+	writeProblem(w, r, http.StatusUnauthorized, codeUnauthenticated, "Sign in or send an API token to continue")
+}
+
+// invalidToken reports an unknown, revoked or expired bearer token.
+func invalidToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="gogoal", error="invalid_token"`)
+	writeProblem(w, r, http.StatusUnauthorized, codeInvalidToken, "The API token is invalid, revoked or expired")
+}
+
+// insufficientScope reports an API token lacking scope.
+func insufficientScope(w http.ResponseWriter, r *http.Request, scope string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="gogoal", error="insufficient_scope", scope="`+scope+`"`)
+	writeProblem(w, r, http.StatusForbidden, codeInsufficientScope, "The API token needs the "+scope+" scope")
+}
+
+// notFound reports a missing resource.
+func notFound(w http.ResponseWriter, r *http.Request, detail string) {
+	writeProblem(w, r, http.StatusNotFound, codeNotFound, detail)
+}
+
+// storeError reports an error returned by the store. Constraint violations
+// become 409 or 422 responses naming the offending column and missing
+// workspace roles 403 responses; anything
+// unexpected is logged and answered with a 500 carrying only detail, so
+// database internals do not leak to clients.
+func storeError(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	var ce *store.ConstraintError
+	switch {
+	case errors.As(err, &ce):
+		status, code, fieldCode := http.StatusUnprocessableEntity, codeConstraint, "invalid"
+		switch {
+		case errors.Is(ce, store.ErrConflict):
+			status, code, fieldCode = http.StatusConflict, codeConflict, "duplicate"
+		case errors.Is(ce, store.ErrInvalidReference):
+			code, fieldCode = codeInvalidReference, "invalid_reference"
+		case errors.Is(ce, store.ErrCycle):
+			fieldCode = "cycle"
+		}
+		var errs []FieldError
+		if ce.Column != "" {
+			errs = append(errs, FieldError{Field: ce.Column, Code: fieldCode, Message: ce.Detail})
+		}
+		writeProblem(w, r, status, code, ce.Detail, errs...)
+	case errors.Is(err, store.ErrNotFound):
+		notFound(w, r, err.Error())
+	case errors.Is(err, store.ErrForbidden):
+		writeProblem(w, r, http.StatusForbidden, codeForbidden, "Your role in the workspace does not allow this")
+	case errors.Is(err, store.ErrLastOwner):
+		writeProblem(w, r, http.StatusConflict, codeConflict, err.Error())
+	case errors.Is(err, store.ErrInvalidTransition):
+		writeProblem(w, r, http.StatusConflict, codeConflict, err.Error(), FieldError{Field: "status", Code: "invalid_transition", Message: err.Error()})
+	case errors.Is(err, store.ErrInvalidSort):
+		badRequest(w, r, err.Error(), FieldError{Field: "sort", Code: "invalid", Message: err.Error()})
+	case errors.Is(err, store.ErrInvalidCursor):
+		badRequest(w, r, err.Error(), FieldError{Field: "cursor", Code: "invalid", Message: "was returned for another sort order"})
+	case errors.Is(err, store.ErrInvalidEntityType):
+		badRequest(w, r, "Invalid entity type", FieldError{Field: "entity_type", Code: "invalid", Message: "must be one of project, goal, task or note"})
+	default:
+		logging.FromContext(r.Context()).Error("request failed", "error", err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, detail)
+	}
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	assert.Equal(t, problemType, w.Header().Get("Content-Type"))
+	var p Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	return p
+}
+
+func TestCreateGoalProblems(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	h := &GoalHandler{Store: store.New(db).Goals}
+	create := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/api/v1/goals", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.CreateGoal(w, r)
+		return w
+	}
+
+	t.Run("should report a missing referenced project as 422", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO goals`).WillReturnError(&pq.Error{
+			Code:       "23503",
+			Constraint: "goals_project_id_fkey",
+			Detail:     `Key (project_id)=(99) is not present in table "projects".`,
+		})
+		mock.ExpectRollback()
+
+		w := create(`{"title":"Ship it","project_id":99}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, codeInvalidReference, p.Code)
+		assert.Equal(t, "/api/v1/goals", p.Instance)
+		assert.Equal(t, []FieldError{{
+			Field:   "project_id",
+			Code:    "invalid_reference",
+			Message: `Key (project_id)=(99) is not present in table "projects".`,
+		}}, p.Errors)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should report a check violation as 422", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO goals`).WillReturnError(&pq.Error{
+			Code:       "23514",
+			Constraint: "goals_status_check",
+			Message:    `new row for relation "goals" violates check constraint "goals_status_check"`,
+		})
+		mock.ExpectRollback()
+
+		w := create(`{"title":"Ship it","status":"someday"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, codeConstraint, p.Code)
+		assert.Empty(t, p.Errors)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should hide unexpected errors behind a 500", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO goals`).WillReturnError(errors.New("connection reset by peer"))
+		mock.ExpectRollback()
+
+		w := create(`{"title":"Ship it"}`)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, codeInternal, p.Code)
+		assert.Equal(t, "Failed to create goal", p.Detail)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should point type errors at the offending field", func(t *testing.T) {
+		w := create(`{"title":"Ship it","priority":"high"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, codeInvalidJSON, p.Code)
+		assert.Equal(t, []FieldError{{Field: "priority", Code: "invalid_type", Message: "must be of type number"}}, p.Errors)
+	})
+}
+
+func TestTagProblems(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	h := &TagHandler{Store: store.New(db).Tags}
+
+	t.Run("should report duplicate names as 409", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE tags`).WillReturnError(&pq.Error{
+			Code:       "23505",
+			Constraint: "tags_name_key",
+			Detail:     `Key (name)=(urgent) already exists.`,
+		})
+		mock.ExpectRollback()
+
+		r := httptest.NewRequest("PUT", "/api/v1/tags/3", strings.NewReader(`{"name":"urgent"}`))
+		r = mux.SetURLVars(r, map[string]string{"id": "3"})
+		w := httptest.NewRecorder()
+		h.UpdateTag(w, r)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, codeConflict, p.Code)
+		assert.Equal(t, "Conflict", p.Title)
+		assert.Equal(t, "name", p.Errors[0].Field)
+		assert.Equal(t, "duplicate", p.Errors[0].Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should report unknown tags as 404", func(t *testing.T) {
+		mock.ExpectQuery(`FROM tags`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "color", "parent_id", "created_at"}))
+
+		r := httptest.NewRequest("GET", "/api/v1/tags/3", nil)
+		r = mux.SetURLVars(r, map[string]string{"id": "3"})
+		w := httptest.NewRecorder()
+		h.GetTag(w, r)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, codeNotFound, decodeProblem(t, w).Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestValidationProblems(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	stores := store.New(db)
+	h := &FlowHandler{Store: stores.Flows, Rules: validation.New(stores).Flow}
+
+	t.Run("should list every invalid field in a 422", func(t *testing.T) {
+		body := `{"title":"","color":"blue","start_date":"2025-03-01T00:00:00Z","end_date":"2025-02-01T00:00:00Z","workspace_id":1}`
+		r := httptest.NewRequest("POST", "/api/v1/flows", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.CreateFlow(w, r)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, codeValidation, p.Code)
+		assert.Equal(t, []FieldError{
+			{Field: "title", Code: "required", Message: "is required"},
+			{Field: "color", Code: "invalid_format", Message: "must be a hex color such as #3B82F6"},
+			{Field: "end_date", Code: "before_start", Message: "must not be before start_date"},
+		}, p.Errors)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should fill defaults on create", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO flows`).
+			WithArgs("Health", "", "#3B82F6", "active", nil, nil, nil, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, time.Now(), time.Now()))
+		mock.ExpectCommit()
+
+		r := httptest.NewRequest("POST", "/api/v1/flows", strings.NewReader(`{"title":"Health","workspace_id":1}`))
+		w := httptest.NewRecorder()
+		h.CreateFlow(w, r)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFlowTransitionProblems(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	stores := store.New(db)
+	h := &FlowHandler{Store: stores.Flows, TransitionRules: validation.New(stores).FlowTransition}
+
+	t.Run("should answer transitions the lifecycle forbids with 409", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM flows WHERE id = \$1 FOR UPDATE`).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("archived"))
+		mock.ExpectRollback()
+
+		r := httptest.NewRequest("POST", "/api/v1/flows/4/pause", strings.NewReader(`{"reason":"Holiday"}`))
+		r = mux.SetURLVars(r, map[string]string{"id": "4"})
+		w := httptest.NewRecorder()
+		h.PauseFlow(w, r)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, codeConflict, p.Code)
+		require.Len(t, p.Errors, 1)
+		assert.Equal(t, "invalid_transition", p.Errors[0].Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
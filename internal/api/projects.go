@@ -1,67 +1,71 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"go-goal/internal/conflicts"
 	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
 
 	"github.com/gorilla/mux"
 )
 
 type ProjectHandler struct {
-	DB *sql.DB
+	Store store.ProjectStore
+	Rules validation.Rules[models.Project]
+	// Conflicts, if set, evaluates flow rules after writes.
+	Conflicts *conflicts.Engine
 }
 
 func (h *ProjectHandler) GetProjects(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.DB.Query(`
-		SELECT id, title, description, status, workspace_id, created_at, updated_at 
-		FROM projects 
-		ORDER BY created_at DESC
-	`)
+	q, err := parseListQuery(r, "status", "flow_id", "workspace_id", "tag", "q")
 	if err != nil {
-		http.Error(w, "Failed to fetch projects", http.StatusInternalServerError)
+		invalidQuery(w, r, err)
 		return
 	}
-	defer rows.Close()
 
-	var projects []models.Project
-	for rows.Next() {
-		var p models.Project
-		err := rows.Scan(&p.ID, &p.Title, &p.Description, &p.Status, &p.WorkspaceID, &p.CreatedAt, &p.UpdatedAt)
-		if err != nil {
-			http.Error(w, "Failed to scan project", http.StatusInternalServerError)
-			return
-		}
-		projects = append(projects, p)
+	filter := store.ProjectFilter{
+		WorkspaceID: q.WorkspaceID,
+		FlowID:      q.FlowID,
+		Status:      q.Status,
+		TagID:       q.TagID,
+		TagName:     q.TagName,
+		Search:      q.Search,
+	}
+	result, err := h.Store.ListPage(r.Context(), filter, q.Page)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch projects")
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(projects)
+	total, err := h.Store.Count(r.Context(), filter)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch projects")
+		return
+	}
+
+	writeList(w, r, result, total)
 }
 
 func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid project ID")
 		return
 	}
 
-	var p models.Project
-	err = h.DB.QueryRow(`
-		SELECT id, title, description, status, workspace_id, created_at, updated_at 
-		FROM projects WHERE id = $1
-	`, id).Scan(&p.ID, &p.Title, &p.Description, &p.Status, &p.WorkspaceID, &p.CreatedAt, &p.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		http.Error(w, "Project not found", http.StatusNotFound)
+	p, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Project not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to fetch project", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch project")
 		return
 	}
 
@@ -72,80 +76,141 @@ func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
 func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 	var p models.Project
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
-	err := h.DB.QueryRow(`
-		INSERT INTO projects (title, description, status, workspace_id) 
-		VALUES ($1, $2, $3, $4) 
-		RETURNING id, created_at, updated_at
-	`, p.Title, p.Description, p.Status, p.WorkspaceID).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	validation.Defaults(&p)
+	if err := h.Rules.Validate(r.Context(), &p); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err != nil {
-		http.Error(w, "Failed to create project", http.StatusInternalServerError)
+	if err := h.Store.Create(r.Context(), &p); err != nil {
+		storeError(w, r, err, "Failed to create project")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(p)
+	json.NewEncoder(w).Encode(projectResponse{&p, flowWarnings(r, h.Conflicts, conflicts.ProjectSubject(&p))})
 }
 
 func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid project ID")
+		return
+	}
+
+	before, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Project not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch project")
 		return
 	}
 
 	var p models.Project
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
 	p.ID = id
-	err = h.DB.QueryRow(`
-		UPDATE projects 
-		SET title = $2, description = $3, status = $4, workspace_id = $5
-		WHERE id = $1 
-		RETURNING updated_at
-	`, p.ID, p.Title, p.Description, p.Status, p.WorkspaceID).Scan(&p.UpdatedAt)
+	if err := h.Rules.Validate(r.Context(), &p); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err == sql.ErrNoRows {
-		http.Error(w, "Project not found", http.StatusNotFound)
+	err = h.Store.Update(r.Context(), &p)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Project not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to update project", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to update project")
 		return
 	}
 
+	var warnings []models.FlowWarning
+	if conflicts.ProjectMoved(before, &p) {
+		warnings = flowWarnings(r, h.Conflicts, conflicts.ProjectSubject(&p))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(p)
+	json.NewEncoder(w).Encode(projectResponse{&p, warnings})
 }
 
-func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+func (h *ProjectHandler) PatchProject(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid project ID")
 		return
 	}
 
-	result, err := h.DB.Exec("DELETE FROM projects WHERE id = $1", id)
+	p, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Project not found")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to delete project", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch project")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Project not found", http.StatusNotFound)
+	before := *p
+	if err := decodePatch(r, p); err != nil {
+		patchError(w, r, err)
+		return
+	}
+
+	p.ID = id
+	if err := h.Rules.Validate(r.Context(), p); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	err = h.Store.Update(r.Context(), p)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Project not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to update project")
+		return
+	}
+
+	var warnings []models.FlowWarning
+	if conflicts.ProjectMoved(&before, p) {
+		warnings = flowWarnings(r, h.Conflicts, conflicts.ProjectSubject(p))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(projectResponse{p, warnings})
+}
+
+func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid project ID")
+		return
+	}
+
+	err = h.Store.Delete(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Project not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to delete project")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
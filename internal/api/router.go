@@ -2,9 +2,15 @@ package api
 
 import (
 	"database/sql"
+	"log/slog"
 	"net/http"
 
+	"go-goal/internal/auth"
+	"go-goal/internal/conflicts"
 	"go-goal/internal/graphql"
+	"go-goal/internal/loader"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
 	"go-goal/pkg/config"
 
 	"github.com/99designs/gqlgen/graphql/handler"
@@ -12,33 +18,66 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func NewRouter(db *sql.DB, cfg *config.Config) http.Handler {
+func NewRouter(db *sql.DB, cfg *config.Config, ready *Readiness) http.Handler {
 	r := mux.NewRouter()
 	
 	// Serve static files
 	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("web/static/"))))
 	
 	// Initialize handlers
-	projectHandler := &ProjectHandler{DB: db}
-	goalHandler := &GoalHandler{DB: db}
-	taskHandler := &TaskHandler{DB: db}
-	tagHandler := &TagHandler{DB: db}
-	noteHandler := &NoteHandler{DB: db}
-	workspaceHandler := &WorkspaceHandler{DB: db}
-	taggingHandler := &TaggingHandler{DB: db}
-	flowHandler := &FlowHandler{DB: db}
+	stores := store.New(db)
+	validator := validation.New(stores)
+	engine := conflicts.New(stores)
+	projectHandler := &ProjectHandler{Store: stores.Projects, Rules: validator.Project, Conflicts: engine}
+	goalHandler := &GoalHandler{Store: stores.Goals, Rules: validator.Goal, Conflicts: engine}
+	taskHandler := &TaskHandler{Store: stores.Tasks, Rules: validator.Task, Conflicts: engine}
+	tagHandler := &TagHandler{Store: stores.Tags, Rules: validator.Tag}
+	noteHandler := &NoteHandler{Store: stores.Notes, Rules: validator.Note}
+	workspaceHandler := &WorkspaceHandler{Store: stores.Workspaces, Rules: validator.Workspace}
+	memberHandler := &MemberHandler{Store: stores.Members, Users: stores.Users, Rules: validator.Member}
+	taggingHandler := &TaggingHandler{Store: stores.Tags}
+	flowHandler := &FlowHandler{Store: stores.Flows, Rules: validator.Flow, RelationshipRules: validator.FlowRelationship, ConstraintRules: validator.FlowConstraints, TransitionRules: validator.FlowTransition, TimelineRules: validator.TimelineRange}
+	conflictHandler := &ConflictHandler{Engine: engine}
+	tokenHandler := &TokenHandler{Store: stores.Tokens, Rules: validator.APIToken}
+	auditHandler := &AuditHandler{Store: stores.Audit}
+	authHandler := &AuthHandler{
+		Users:        stores.Users,
+		Sessions:     stores.Sessions,
+		Rules:        validator.Registration,
+		SessionTTL:   cfg.SessionTTL,
+		SecureCookie: cfg.SessionCookieSecure,
+	}
 	webHandler := NewWebHandler(cfg)
 	
-	// Health check endpoint
-	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	}).Methods("GET")
+	// Resolve the signed-in user or API token of every matched route
+	r.Use(func(next http.Handler) http.Handler {
+		return Authenticate(stores.Sessions, stores.Tokens, next)
+	})
+	
+	// Health check endpoints
+	r.HandleFunc("/healthz", Liveness).Methods("GET")
+	r.HandleFunc("/health", Liveness).Methods("GET")
+	r.Handle("/readyz", ready).Methods("GET")
+	
+	// Metrics endpoint
+	var metrics *Metrics
+	if cfg.MetricsEnabled {
+		metrics = NewMetrics(db)
+		r.Handle("/metrics", metrics.Handler()).Methods("GET")
+	}
 	
 	// GraphQL endpoint
-	resolver := &graphql.Resolver{DB: db}
-	srv := handler.NewDefaultServer(graphql.NewExecutableSchema(graphql.Config{Resolvers: resolver}))
-	r.Handle("/graphql", srv)
+	resolver := &graphql.Resolver{Store: stores, Validator: validator, Conflicts: engine}
+	srv := handler.NewDefaultServer(graphql.NewExecutableSchema(graphql.Config{Resolvers: resolver, Directives: resolver.Directives()}))
+	srv.SetRecoverFunc(graphql.Recover)
+	srv.SetErrorPresenter(graphql.PresentError)
+	srv.Use(graphql.OperationLogger{})
+	srv.Use(graphql.ScopeCheck{})
+	srv.Use(graphql.Tracer{})
+	if metrics != nil {
+		srv.Use(graphql.NewMetrics(metrics.Registerer(), cfg.MetricsOperations...))
+	}
+	r.Handle("/graphql", RequireUser(loader.Middleware(stores, srv)))
 	r.Handle("/playground", playground.Handler("GraphQL playground", "/graphql"))
 	
 	// Web routes
@@ -52,14 +91,31 @@ func NewRouter(db *sql.DB, cfg *config.Config) http.Handler {
 	r.HandleFunc("/workspaces", webHandler.Workspaces).Methods("GET")
 	r.HandleFunc("/flows", webHandler.Flows).Methods("GET")
 	
-	// API routes
+	// Authentication routes
+	authRoutes := r.PathPrefix("/api/v1/auth").Subrouter()
+	authRoutes.HandleFunc("/register", authHandler.Register).Methods("POST")
+	authRoutes.HandleFunc("/login", authHandler.Login).Methods("POST")
+	authRoutes.HandleFunc("/logout", authHandler.Logout).Methods("POST")
+	authRoutes.HandleFunc("/me", authHandler.Me).Methods("GET")
+	
+	// API token routes, which a token can only reach with the admin scope
+	tokenRoutes := r.PathPrefix("/api/v1/tokens").Subrouter()
+	tokenRoutes.Use(RequireUser, RequireScope(auth.ScopeAdmin))
+	tokenRoutes.HandleFunc("", tokenHandler.GetTokens).Methods("GET")
+	tokenRoutes.HandleFunc("", tokenHandler.CreateToken).Methods("POST")
+	tokenRoutes.HandleFunc("/{id:[0-9]+}", tokenHandler.DeleteToken).Methods("DELETE")
+	
+	// API routes, all of which need a signed-in user; tokens need the read
+	// scope to read and the write scope to change anything
 	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Use(RequireUser, RequireMethodScope)
 	
 	// Project routes
 	api.HandleFunc("/projects", projectHandler.GetProjects).Methods("GET")
 	api.HandleFunc("/projects", projectHandler.CreateProject).Methods("POST")
 	api.HandleFunc("/projects/{id:[0-9]+}", projectHandler.GetProject).Methods("GET")
 	api.HandleFunc("/projects/{id:[0-9]+}", projectHandler.UpdateProject).Methods("PUT")
+	api.HandleFunc("/projects/{id:[0-9]+}", projectHandler.PatchProject).Methods("PATCH")
 	api.HandleFunc("/projects/{id:[0-9]+}", projectHandler.DeleteProject).Methods("DELETE")
 	
 	// Goal routes
@@ -67,6 +123,7 @@ func NewRouter(db *sql.DB, cfg *config.Config) http.Handler {
 	api.HandleFunc("/goals", goalHandler.CreateGoal).Methods("POST")
 	api.HandleFunc("/goals/{id:[0-9]+}", goalHandler.GetGoal).Methods("GET")
 	api.HandleFunc("/goals/{id:[0-9]+}", goalHandler.UpdateGoal).Methods("PUT")
+	api.HandleFunc("/goals/{id:[0-9]+}", goalHandler.PatchGoal).Methods("PATCH")
 	api.HandleFunc("/goals/{id:[0-9]+}", goalHandler.DeleteGoal).Methods("DELETE")
 	
 	// Task routes
@@ -74,6 +131,7 @@ func NewRouter(db *sql.DB, cfg *config.Config) http.Handler {
 	api.HandleFunc("/tasks", taskHandler.CreateTask).Methods("POST")
 	api.HandleFunc("/tasks/{id:[0-9]+}", taskHandler.GetTask).Methods("GET")
 	api.HandleFunc("/tasks/{id:[0-9]+}", taskHandler.UpdateTask).Methods("PUT")
+	api.HandleFunc("/tasks/{id:[0-9]+}", taskHandler.PatchTask).Methods("PATCH")
 	api.HandleFunc("/tasks/{id:[0-9]+}", taskHandler.DeleteTask).Methods("DELETE")
 	
 	// Tag routes
@@ -81,6 +139,7 @@ func NewRouter(db *sql.DB, cfg *config.Config) http.Handler {
 	api.HandleFunc("/tags", tagHandler.CreateTag).Methods("POST")
 	api.HandleFunc("/tags/{id:[0-9]+}", tagHandler.GetTag).Methods("GET")
 	api.HandleFunc("/tags/{id:[0-9]+}", tagHandler.UpdateTag).Methods("PUT")
+	api.HandleFunc("/tags/{id:[0-9]+}", tagHandler.PatchTag).Methods("PATCH")
 	api.HandleFunc("/tags/{id:[0-9]+}", tagHandler.DeleteTag).Methods("DELETE")
 	
 	// Note routes
@@ -88,6 +147,7 @@ func NewRouter(db *sql.DB, cfg *config.Config) http.Handler {
 	api.HandleFunc("/notes", noteHandler.CreateNote).Methods("POST")
 	api.HandleFunc("/notes/{id:[0-9]+}", noteHandler.GetNote).Methods("GET")
 	api.HandleFunc("/notes/{id:[0-9]+}", noteHandler.UpdateNote).Methods("PUT")
+	api.HandleFunc("/notes/{id:[0-9]+}", noteHandler.PatchNote).Methods("PATCH")
 	api.HandleFunc("/notes/{id:[0-9]+}", noteHandler.DeleteNote).Methods("DELETE")
 	
 	// Workspace routes
@@ -95,8 +155,15 @@ func NewRouter(db *sql.DB, cfg *config.Config) http.Handler {
 	api.HandleFunc("/workspaces", workspaceHandler.CreateWorkspace).Methods("POST")
 	api.HandleFunc("/workspaces/{id:[0-9]+}", workspaceHandler.GetWorkspace).Methods("GET")
 	api.HandleFunc("/workspaces/{id:[0-9]+}", workspaceHandler.UpdateWorkspace).Methods("PUT")
+	api.HandleFunc("/workspaces/{id:[0-9]+}", workspaceHandler.PatchWorkspace).Methods("PATCH")
 	api.HandleFunc("/workspaces/{id:[0-9]+}", workspaceHandler.DeleteWorkspace).Methods("DELETE")
 	
+	// Workspace member routes
+	api.HandleFunc("/workspaces/{id:[0-9]+}/members", memberHandler.GetMembers).Methods("GET")
+	api.HandleFunc("/workspaces/{id:[0-9]+}/members", memberHandler.AddMember).Methods("POST")
+	api.HandleFunc("/workspaces/{id:[0-9]+}/members/{user_id:[0-9]+}", memberHandler.UpdateMember).Methods("PUT")
+	api.HandleFunc("/workspaces/{id:[0-9]+}/members/{user_id:[0-9]+}", memberHandler.RemoveMember).Methods("DELETE")
+	
 	// Tagging routes
 	api.HandleFunc("/tags/assign", taggingHandler.AssignTag).Methods("POST")
 	api.HandleFunc("/tags/remove/{entity_type}/{entity_id:[0-9]+}/{tag_id:[0-9]+}", taggingHandler.RemoveTag).Methods("DELETE")
@@ -105,10 +172,31 @@ func NewRouter(db *sql.DB, cfg *config.Config) http.Handler {
 	// Flow routes
 	api.HandleFunc("/flows", flowHandler.GetFlows).Methods("GET")
 	api.HandleFunc("/flows", flowHandler.CreateFlow).Methods("POST")
+	api.HandleFunc("/flows/tree", flowHandler.GetFlowTree).Methods("GET")
+	api.HandleFunc("/flows/timeline", flowHandler.GetFlowTimeline).Methods("GET")
+	api.HandleFunc("/flows/conflicts", conflictHandler.GetConflicts).Methods("GET")
 	api.HandleFunc("/flows/{id:[0-9]+}", flowHandler.GetFlow).Methods("GET")
 	api.HandleFunc("/flows/{id:[0-9]+}", flowHandler.UpdateFlow).Methods("PUT")
+	api.HandleFunc("/flows/{id:[0-9]+}", flowHandler.PatchFlow).Methods("PATCH")
 	api.HandleFunc("/flows/{id:[0-9]+}", flowHandler.DeleteFlow).Methods("DELETE")
 	api.HandleFunc("/flows/{id:[0-9]+}/stats", flowHandler.GetFlowStats).Methods("GET")
+	api.HandleFunc("/flows/{id:[0-9]+}/relationships", flowHandler.GetFlowRelationships).Methods("GET")
+	api.HandleFunc("/flows/{id:[0-9]+}/relationships", flowHandler.CreateFlowRelationship).Methods("POST")
+	api.HandleFunc("/flows/{id:[0-9]+}/relationships/{relationship_id:[0-9]+}", flowHandler.DeleteFlowRelationship).Methods("DELETE")
+	api.HandleFunc("/flows/{id:[0-9]+}/constraints", flowHandler.GetFlowConstraints).Methods("GET")
+	api.HandleFunc("/flows/{id:[0-9]+}/constraints", flowHandler.SetFlowConstraints).Methods("PUT")
+	api.HandleFunc("/flows/{id:[0-9]+}/transitions", flowHandler.GetFlowTransitions).Methods("GET")
+	api.HandleFunc("/flows/{id:[0-9]+}/pause", flowHandler.PauseFlow).Methods("POST")
+	api.HandleFunc("/flows/{id:[0-9]+}/resume", flowHandler.ResumeFlow).Methods("POST")
+	api.HandleFunc("/flows/{id:[0-9]+}/archive", flowHandler.ArchiveFlow).Methods("POST")
+	api.HandleFunc("/flows/{id:[0-9]+}/celebrate", flowHandler.CelebrateFlow).Methods("POST")
+	
+	// Audit routes
+	api.HandleFunc("/audit", auditHandler.GetAudit).Methods("GET")
 	
-	return r
+	root := RequestLogger(slog.Default(), r)
+	if metrics != nil {
+		root = metrics.Instrument(r, root)
+	}
+	return Trace(r, root)
 }
\ No newline at end of file
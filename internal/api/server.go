@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"go-goal/pkg/config"
+)
+
+// NewServer returns an http.Server for handler with the timeouts from cfg.
+// Request contexts expire with the write timeout, so database work for a
+// response that can no longer be sent is canceled.
+func NewServer(cfg *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           withDeadline(cfg.WriteTimeout, handler),
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// withDeadline bounds every request context by timeout; zero disables it.
+func withDeadline(timeout time.Duration, next http.Handler) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Serve accepts connections on ln until ctx is canceled, then shuts srv down
+// gracefully: ready is switched to draining, Serve waits drainDelay so probes
+// notice, and in-flight requests get up to timeout to finish (zero waits
+// indefinitely). It returns once every connection is closed, or with the
+// error that stopped the listener.
+func Serve(ctx context.Context, srv *http.Server, ln net.Listener, ready *Readiness, drainDelay, timeout time.Duration) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down: draining connections")
+	ready.Drain()
+	time.Sleep(drainDelay)
+
+	shutdownCtx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, timeout)
+		defer cancel()
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
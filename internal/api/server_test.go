@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	t.Run("should finish in-flight requests before returning", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.Write([]byte("done"))
+		})}
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ready := &Readiness{}
+		served := make(chan error, 1)
+		go func() {
+			served <- Serve(ctx, srv, ln, ready, 0, 5*time.Second)
+		}()
+
+		responses := make(chan *http.Response, 1)
+		go func() {
+			resp, err := http.Get("http://" + ln.Addr().String())
+			assert.NoError(t, err)
+			responses <- resp
+		}()
+		<-started
+
+		cancel()
+		assert.Eventually(t, func() bool { return !ready.Ready() }, time.Second, 5*time.Millisecond)
+		select {
+		case err := <-served:
+			t.Fatalf("Serve returned before the request finished: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		assert.NoError(t, <-served)
+		resp := <-responses
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp.Body.Close()
+	})
+
+	t.Run("should give up after the shutdown timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		started := make(chan struct{})
+		srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		})}
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		served := make(chan error, 1)
+		go func() {
+			served <- Serve(ctx, srv, ln, &Readiness{}, 0, 20*time.Millisecond)
+		}()
+		go http.Get("http://" + ln.Addr().String())
+		<-started
+
+		cancel()
+
+		assert.ErrorIs(t, <-served, context.DeadlineExceeded)
+	})
+}
+
+func TestWithDeadline(t *testing.T) {
+	var deadline time.Time
+	var ok bool
+	h := withDeadline(time.Minute, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok = r.Context().Deadline()
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+}
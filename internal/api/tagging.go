@@ -1,16 +1,18 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"go-goal/internal/store"
+
 	"github.com/gorilla/mux"
 )
 
 type TaggingHandler struct {
-	DB *sql.DB
+	Store store.TagStore
 }
 
 type TagAssignment struct {
@@ -22,36 +24,13 @@ type TagAssignment struct {
 func (h *TaggingHandler) AssignTag(w http.ResponseWriter, r *http.Request) {
 	var assignment TagAssignment
 	if err := json.NewDecoder(r.Body).Decode(&assignment); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
-	var tableName string
-	var columnName string
-	
-	switch assignment.EntityType {
-	case "project":
-		tableName = "project_tags"
-		columnName = "project_id"
-	case "goal":
-		tableName = "goal_tags"
-		columnName = "goal_id"
-	case "task":
-		tableName = "task_tags"
-		columnName = "task_id"
-	default:
-		http.Error(w, "Invalid entity type", http.StatusBadRequest)
-		return
-	}
-
-	_, err := h.DB.Exec(`
-		INSERT INTO `+tableName+` (`+columnName+`, tag_id) 
-		VALUES ($1, $2) 
-		ON CONFLICT DO NOTHING
-	`, assignment.EntityID, assignment.TagID)
-
+	err := h.Store.Assign(r.Context(), assignment.EntityType, assignment.EntityID, assignment.TagID)
 	if err != nil {
-		http.Error(w, "Failed to assign tag", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to assign tag")
 		return
 	}
 
@@ -64,47 +43,23 @@ func (h *TaggingHandler) RemoveTag(w http.ResponseWriter, r *http.Request) {
 	entityType := vars["entity_type"]
 	entityID, err := strconv.Atoi(vars["entity_id"])
 	if err != nil {
-		http.Error(w, "Invalid entity ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid entity ID")
 		return
 	}
-	
+
 	tagID, err := strconv.Atoi(vars["tag_id"])
 	if err != nil {
-		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid tag ID")
 		return
 	}
 
-	var tableName string
-	var columnName string
-	
-	switch entityType {
-	case "project":
-		tableName = "project_tags"
-		columnName = "project_id"
-	case "goal":
-		tableName = "goal_tags"
-		columnName = "goal_id"
-	case "task":
-		tableName = "task_tags"
-		columnName = "task_id"
-	default:
-		http.Error(w, "Invalid entity type", http.StatusBadRequest)
+	err = h.Store.Remove(r.Context(), entityType, entityID, tagID)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Tag assignment not found")
 		return
 	}
-
-	result, err := h.DB.Exec(`
-		DELETE FROM `+tableName+` 
-		WHERE `+columnName+` = $1 AND tag_id = $2
-	`, entityID, tagID)
-
 	if err != nil {
-		http.Error(w, "Failed to remove tag", http.StatusInternalServerError)
-		return
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Tag assignment not found", http.StatusNotFound)
+		storeError(w, r, err, "Failed to remove tag")
 		return
 	}
 
@@ -116,67 +71,16 @@ func (h *TaggingHandler) GetEntityTags(w http.ResponseWriter, r *http.Request) {
 	entityType := vars["entity_type"]
 	entityID, err := strconv.Atoi(vars["entity_id"])
 	if err != nil {
-		http.Error(w, "Invalid entity ID", http.StatusBadRequest)
-		return
-	}
-
-	var tableName string
-	var columnName string
-	
-	switch entityType {
-	case "project":
-		tableName = "project_tags"
-		columnName = "project_id"
-	case "goal":
-		tableName = "goal_tags"
-		columnName = "goal_id"
-	case "task":
-		tableName = "task_tags"
-		columnName = "task_id"
-	default:
-		http.Error(w, "Invalid entity type", http.StatusBadRequest)
+		badRequest(w, r, "Invalid entity ID")
 		return
 	}
 
-	rows, err := h.DB.Query(`
-		SELECT t.id, t.name, t.color, t.parent_id, t.created_at 
-		FROM tags t 
-		JOIN `+tableName+` et ON t.id = et.tag_id 
-		WHERE et.`+columnName+` = $1 
-		ORDER BY t.name
-	`, entityID)
-	
+	tags, err := h.Store.ListForEntity(r.Context(), entityType, entityID)
 	if err != nil {
-		http.Error(w, "Failed to fetch tags", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch tags")
 		return
 	}
-	defer rows.Close()
-
-	var tags []map[string]interface{}
-	for rows.Next() {
-		var id, parentID sql.NullInt64
-		var name, color, createdAt string
-		
-		err := rows.Scan(&id, &name, &color, &parentID, &createdAt)
-		if err != nil {
-			http.Error(w, "Failed to scan tag", http.StatusInternalServerError)
-			return
-		}
-		
-		tag := map[string]interface{}{
-			"id":         id.Int64,
-			"name":       name,
-			"color":      color,
-			"created_at": createdAt,
-		}
-		
-		if parentID.Valid {
-			tag["parent_id"] = parentID.Int64
-		}
-		
-		tags = append(tags, tag)
-	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(tags)
-}
\ No newline at end of file
+}
@@ -1,42 +1,29 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
 
 	"github.com/gorilla/mux"
 )
 
 type TagHandler struct {
-	DB *sql.DB
+	Store store.TagStore
+	Rules validation.Rules[models.Tag]
 }
 
 func (h *TagHandler) GetTags(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.DB.Query(`
-		SELECT id, name, color, parent_id, created_at 
-		FROM tags 
-		ORDER BY name
-	`)
+	tags, err := h.Store.List(r.Context(), store.TagFilter{})
 	if err != nil {
-		http.Error(w, "Failed to fetch tags", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch tags")
 		return
 	}
-	defer rows.Close()
-
-	var tags []models.Tag
-	for rows.Next() {
-		var t models.Tag
-		err := rows.Scan(&t.ID, &t.Name, &t.Color, &t.ParentID, &t.CreatedAt)
-		if err != nil {
-			http.Error(w, "Failed to scan tag", http.StatusInternalServerError)
-			return
-		}
-		tags = append(tags, t)
-	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(tags)
@@ -46,22 +33,17 @@ func (h *TagHandler) GetTag(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid tag ID")
 		return
 	}
 
-	var t models.Tag
-	err = h.DB.QueryRow(`
-		SELECT id, name, color, parent_id, created_at 
-		FROM tags WHERE id = $1
-	`, id).Scan(&t.ID, &t.Name, &t.Color, &t.ParentID, &t.CreatedAt)
-
-	if err == sql.ErrNoRows {
-		http.Error(w, "Tag not found", http.StatusNotFound)
+	t, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Tag not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to fetch tag", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch tag")
 		return
 	}
 
@@ -72,18 +54,18 @@ func (h *TagHandler) GetTag(w http.ResponseWriter, r *http.Request) {
 func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
 	var t models.Tag
 	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
-	err := h.DB.QueryRow(`
-		INSERT INTO tags (name, color, parent_id) 
-		VALUES ($1, $2, $3) 
-		RETURNING id, created_at
-	`, t.Name, t.Color, t.ParentID).Scan(&t.ID, &t.CreatedAt)
+	validation.Defaults(&t)
+	if err := h.Rules.Validate(r.Context(), &t); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err != nil {
-		http.Error(w, "Failed to create tag", http.StatusInternalServerError)
+	if err := h.Store.Create(r.Context(), &t); err != nil {
+		storeError(w, r, err, "Failed to create tag")
 		return
 	}
 
@@ -96,31 +78,72 @@ func (h *TagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid tag ID")
 		return
 	}
 
 	var t models.Tag
 	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
 	t.ID = id
-	result, err := h.DB.Exec(`
-		UPDATE tags 
-		SET name = $2, color = $3, parent_id = $4
-		WHERE id = $1
-	`, t.ID, t.Name, t.Color, t.ParentID)
+	if err := h.Rules.Validate(r.Context(), &t); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
+	err = h.Store.Update(r.Context(), &t)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Tag not found")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to update tag", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to update tag")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Tag not found", http.StatusNotFound)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+func (h *TagHandler) PatchTag(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid tag ID")
+		return
+	}
+
+	t, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Tag not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch tag")
+		return
+	}
+
+	if err := decodePatch(r, t); err != nil {
+		patchError(w, r, err)
+		return
+	}
+
+	t.ID = id
+	if err := h.Rules.Validate(r.Context(), t); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	err = h.Store.Update(r.Context(), t)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Tag not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to update tag")
 		return
 	}
 
@@ -132,21 +155,19 @@ func (h *TagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid tag ID")
 		return
 	}
 
-	result, err := h.DB.Exec("DELETE FROM tags WHERE id = $1", id)
-	if err != nil {
-		http.Error(w, "Failed to delete tag", http.StatusInternalServerError)
+	err = h.Store.Delete(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Tag not found")
 		return
 	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Tag not found", http.StatusNotFound)
+	if err != nil {
+		storeError(w, r, err, "Failed to delete tag")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
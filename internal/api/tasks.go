@@ -1,67 +1,75 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"go-goal/internal/conflicts"
 	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
 
 	"github.com/gorilla/mux"
 )
 
 type TaskHandler struct {
-	DB *sql.DB
+	Store store.TaskStore
+	Rules validation.Rules[models.Task]
+	// Conflicts, if set, evaluates flow rules after writes.
+	Conflicts *conflicts.Engine
 }
 
 func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.DB.Query(`
-		SELECT id, title, description, goal_id, project_id, status, priority, due_date, created_at, updated_at 
-		FROM tasks 
-		ORDER BY priority DESC, created_at DESC
-	`)
+	q, err := parseListQuery(r, "status", "priority", "project_id", "goal_id", "flow_id", "tag", "due_before", "due_after", "q")
 	if err != nil {
-		http.Error(w, "Failed to fetch tasks", http.StatusInternalServerError)
+		invalidQuery(w, r, err)
 		return
 	}
-	defer rows.Close()
 
-	var tasks []models.Task
-	for rows.Next() {
-		var t models.Task
-		err := rows.Scan(&t.ID, &t.Title, &t.Description, &t.GoalID, &t.ProjectID, &t.Status, &t.Priority, &t.DueDate, &t.CreatedAt, &t.UpdatedAt)
-		if err != nil {
-			http.Error(w, "Failed to scan task", http.StatusInternalServerError)
-			return
-		}
-		tasks = append(tasks, t)
+	filter := store.TaskFilter{
+		ProjectID: q.ProjectID,
+		GoalID:    q.GoalID,
+		FlowID:    q.FlowID,
+		Status:    q.Status,
+		Priority:  q.Priority,
+		TagID:     q.TagID,
+		TagName:   q.TagName,
+		DueBefore: q.DueBefore,
+		DueAfter:  q.DueAfter,
+		Search:    q.Search,
+	}
+	result, err := h.Store.ListPage(r.Context(), filter, q.Page)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch tasks")
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(tasks)
+	total, err := h.Store.Count(r.Context(), filter)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch tasks")
+		return
+	}
+
+	writeList(w, r, result, total)
 }
 
 func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid task ID")
 		return
 	}
 
-	var t models.Task
-	err = h.DB.QueryRow(`
-		SELECT id, title, description, goal_id, project_id, status, priority, due_date, created_at, updated_at 
-		FROM tasks WHERE id = $1
-	`, id).Scan(&t.ID, &t.Title, &t.Description, &t.GoalID, &t.ProjectID, &t.Status, &t.Priority, &t.DueDate, &t.CreatedAt, &t.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	t, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Task not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to fetch task", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch task")
 		return
 	}
 
@@ -72,80 +80,141 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	var t models.Task
 	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
-	err := h.DB.QueryRow(`
-		INSERT INTO tasks (title, description, goal_id, project_id, status, priority, due_date) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7) 
-		RETURNING id, created_at, updated_at
-	`, t.Title, t.Description, t.GoalID, t.ProjectID, t.Status, t.Priority, t.DueDate).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	validation.Defaults(&t)
+	if err := h.Rules.Validate(r.Context(), &t); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err != nil {
-		http.Error(w, "Failed to create task", http.StatusInternalServerError)
+	if err := h.Store.Create(r.Context(), &t); err != nil {
+		storeError(w, r, err, "Failed to create task")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(t)
+	json.NewEncoder(w).Encode(taskResponse{&t, flowWarnings(r, h.Conflicts, conflicts.TaskSubject(&t))})
 }
 
 func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid task ID")
+		return
+	}
+
+	before, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Task not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch task")
 		return
 	}
 
 	var t models.Task
 	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
 	t.ID = id
-	err = h.DB.QueryRow(`
-		UPDATE tasks 
-		SET title = $2, description = $3, goal_id = $4, project_id = $5, status = $6, priority = $7, due_date = $8
-		WHERE id = $1 
-		RETURNING updated_at
-	`, t.ID, t.Title, t.Description, t.GoalID, t.ProjectID, t.Status, t.Priority, t.DueDate).Scan(&t.UpdatedAt)
+	if err := h.Rules.Validate(r.Context(), &t); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err == sql.ErrNoRows {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	err = h.Store.Update(r.Context(), &t)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Task not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to update task", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to update task")
 		return
 	}
 
+	var warnings []models.FlowWarning
+	if conflicts.TaskMoved(before, &t) {
+		warnings = flowWarnings(r, h.Conflicts, conflicts.TaskSubject(&t))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(t)
+	json.NewEncoder(w).Encode(taskResponse{&t, warnings})
 }
 
-func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+func (h *TaskHandler) PatchTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid task ID")
 		return
 	}
 
-	result, err := h.DB.Exec("DELETE FROM tasks WHERE id = $1", id)
+	t, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Task not found")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to delete task", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch task")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	before := *t
+	if err := decodePatch(r, t); err != nil {
+		patchError(w, r, err)
+		return
+	}
+
+	t.ID = id
+	if err := h.Rules.Validate(r.Context(), t); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	err = h.Store.Update(r.Context(), t)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Task not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to update task")
+		return
+	}
+
+	var warnings []models.FlowWarning
+	if conflicts.TaskMoved(&before, t) {
+		warnings = flowWarnings(r, h.Conflicts, conflicts.TaskSubject(t))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(taskResponse{t, warnings})
+}
+
+func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid task ID")
+		return
+	}
+
+	err = h.Store.Delete(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Task not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to delete task")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+type TokenHandler struct {
+	Store store.TokenStore
+	Rules validation.Rules[models.APIToken]
+}
+
+// createdToken is the response to creating a token, the only time the
+// token itself is shown.
+type createdToken struct {
+	models.APIToken
+	Token string `json:"token"`
+}
+
+func (h *TokenHandler) GetTokens(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFrom(r.Context())
+	tokens, err := h.Store.List(r.Context(), user.ID)
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch API tokens")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+func (h *TokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	var t models.APIToken
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		invalidJSON(w, r, err)
+		return
+	}
+
+	t.Name = strings.TrimSpace(t.Name)
+	if err := h.Rules.Validate(r.Context(), &t); err != nil {
+		invalid(w, r, err)
+		return
+	}
+	// A token cannot grant more than the request creating it holds.
+	for _, scope := range t.Scopes {
+		if !auth.HasScope(r.Context(), scope) {
+			insufficientScope(w, r, scope)
+			return
+		}
+	}
+
+	token, prefix, hash := auth.NewAPIToken()
+	t.UserID = auth.UserFrom(r.Context()).ID
+	t.Prefix, t.TokenHash = prefix, hash
+	if err := h.Store.Create(r.Context(), &t); err != nil {
+		storeError(w, r, err, "Failed to create API token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdToken{APIToken: t, Token: token})
+}
+
+func (h *TokenHandler) DeleteToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid token ID")
+		return
+	}
+
+	err = h.Store.Delete(r.Context(), auth.UserFrom(r.Context()).ID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "API token not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to revoke API token")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
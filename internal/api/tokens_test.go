@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenHandler(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	stores := store.New(db)
+	h := &TokenHandler{Store: stores.Tokens, Rules: validation.New(stores).APIToken}
+	ctx := auth.WithUser(context.Background(), &models.User{ID: 4})
+	create := func(ctx context.Context, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/api/v1/tokens", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.CreateToken(w, r.WithContext(ctx))
+		return w
+	}
+
+	t.Run("should return the token once on create", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO api_tokens`).
+			WithArgs(4, "CI", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+
+		w := create(ctx, `{"name":" CI ","scopes":["read"]}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var body struct {
+			Name   string `json:"name"`
+			Prefix string `json:"prefix"`
+			Token  string `json:"token"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "CI", body.Name)
+		assert.True(t, strings.HasPrefix(body.Token, body.Prefix))
+		assert.NotContains(t, w.Body.String(), "token_hash")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject unknown scopes and past expiry", func(t *testing.T) {
+		w := create(ctx, `{"name":"CI","scopes":["root"],"expires_at":"2000-01-01T00:00:00Z"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		p := decodeProblem(t, w)
+		require.Len(t, p.Errors, 2)
+		assert.Equal(t, "scopes", p.Errors[0].Field)
+		assert.Equal(t, "expires_at", p.Errors[1].Field)
+	})
+
+	t.Run("should not let a token mint a broader one", func(t *testing.T) {
+		w := create(auth.WithScopes(ctx, []string{auth.ScopeWrite}), `{"name":"CI","scopes":["admin"]}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, codeInsufficientScope, decodeProblem(t, w).Code)
+	})
+
+	t.Run("should only revoke the user's own tokens", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM api_tokens WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(8, 4).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		r := httptest.NewRequest("DELETE", "/api/v1/tokens/8", nil)
+		r = mux.SetURLVars(r.WithContext(ctx), map[string]string{"id": "8"})
+		w := httptest.NewRecorder()
+		h.DeleteToken(w, r)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
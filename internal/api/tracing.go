@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// untracedPaths are polled by infrastructure and would drown out request
+// traces.
+var untracedPaths = map[string]bool{
+	"/healthz": true,
+	"/health":  true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// Trace serves next inside a server span named after the route of router
+// the request matches, continuing any trace propagated by the caller.
+func Trace(router *mux.Router, next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.request",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + routeTemplate(router, r)
+		}),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !untracedPaths[r.URL.Path]
+		}),
+	)
+}
@@ -1,42 +1,29 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
 
 	"github.com/gorilla/mux"
 )
 
 type WorkspaceHandler struct {
-	DB *sql.DB
+	Store store.WorkspaceStore
+	Rules validation.Rules[models.Workspace]
 }
 
 func (h *WorkspaceHandler) GetWorkspaces(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.DB.Query(`
-		SELECT id, name, description, created_at 
-		FROM workspaces 
-		ORDER BY created_at DESC
-	`)
+	workspaces, err := h.Store.List(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to fetch workspaces", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch workspaces")
 		return
 	}
-	defer rows.Close()
-
-	var workspaces []models.Workspace
-	for rows.Next() {
-		var workspace models.Workspace
-		err := rows.Scan(&workspace.ID, &workspace.Name, &workspace.Description, &workspace.CreatedAt)
-		if err != nil {
-			http.Error(w, "Failed to scan workspace", http.StatusInternalServerError)
-			return
-		}
-		workspaces = append(workspaces, workspace)
-	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(workspaces)
@@ -46,22 +33,17 @@ func (h *WorkspaceHandler) GetWorkspace(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid workspace ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid workspace ID")
 		return
 	}
 
-	var ws models.Workspace
-	err = h.DB.QueryRow(`
-		SELECT id, name, description, created_at 
-		FROM workspaces WHERE id = $1
-	`, id).Scan(&ws.ID, &ws.Name, &ws.Description, &ws.CreatedAt)
-
-	if err == sql.ErrNoRows {
-		http.Error(w, "Workspace not found", http.StatusNotFound)
+	ws, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Workspace not found")
 		return
 	}
 	if err != nil {
-		http.Error(w, "Failed to fetch workspace", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to fetch workspace")
 		return
 	}
 
@@ -72,18 +54,18 @@ func (h *WorkspaceHandler) GetWorkspace(w http.ResponseWriter, r *http.Request)
 func (h *WorkspaceHandler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
 	var ws models.Workspace
 	if err := json.NewDecoder(r.Body).Decode(&ws); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
-	err := h.DB.QueryRow(`
-		INSERT INTO workspaces (name, description) 
-		VALUES ($1, $2) 
-		RETURNING id, created_at
-	`, ws.Name, ws.Description).Scan(&ws.ID, &ws.CreatedAt)
+	validation.Defaults(&ws)
+	if err := h.Rules.Validate(r.Context(), &ws); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
-	if err != nil {
-		http.Error(w, "Failed to create workspace", http.StatusInternalServerError)
+	if err := h.Store.Create(r.Context(), &ws); err != nil {
+		storeError(w, r, err, "Failed to create workspace")
 		return
 	}
 
@@ -96,31 +78,72 @@ func (h *WorkspaceHandler) UpdateWorkspace(w http.ResponseWriter, r *http.Reques
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid workspace ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid workspace ID")
 		return
 	}
 
 	var ws models.Workspace
 	if err := json.NewDecoder(r.Body).Decode(&ws); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		invalidJSON(w, r, err)
 		return
 	}
 
 	ws.ID = id
-	result, err := h.DB.Exec(`
-		UPDATE workspaces 
-		SET name = $2, description = $3
-		WHERE id = $1
-	`, ws.ID, ws.Name, ws.Description)
+	if err := h.Rules.Validate(r.Context(), &ws); err != nil {
+		invalid(w, r, err)
+		return
+	}
 
+	err = h.Store.Update(r.Context(), &ws)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Workspace not found")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to update workspace", http.StatusInternalServerError)
+		storeError(w, r, err, "Failed to update workspace")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Workspace not found", http.StatusNotFound)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws)
+}
+
+func (h *WorkspaceHandler) PatchWorkspace(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		badRequest(w, r, "Invalid workspace ID")
+		return
+	}
+
+	ws, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Workspace not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to fetch workspace")
+		return
+	}
+
+	if err := decodePatch(r, ws); err != nil {
+		patchError(w, r, err)
+		return
+	}
+
+	ws.ID = id
+	if err := h.Rules.Validate(r.Context(), ws); err != nil {
+		invalid(w, r, err)
+		return
+	}
+
+	err = h.Store.Update(r.Context(), ws)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Workspace not found")
+		return
+	}
+	if err != nil {
+		storeError(w, r, err, "Failed to update workspace")
 		return
 	}
 
@@ -132,21 +155,19 @@ func (h *WorkspaceHandler) DeleteWorkspace(w http.ResponseWriter, r *http.Reques
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid workspace ID", http.StatusBadRequest)
+		badRequest(w, r, "Invalid workspace ID")
 		return
 	}
 
-	result, err := h.DB.Exec("DELETE FROM workspaces WHERE id = $1", id)
-	if err != nil {
-		http.Error(w, "Failed to delete workspace", http.StatusInternalServerError)
+	err = h.Store.Delete(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		notFound(w, r, "Workspace not found")
 		return
 	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Workspace not found", http.StatusNotFound)
+	if err != nil {
+		storeError(w, r, err, "Failed to delete workspace")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
@@ -0,0 +1,162 @@
+// Package auth hashes passwords, issues session tokens and carries the
+// signed-in user through request contexts for the REST handlers and the
+// GraphQL resolvers alike.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"go-goal/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnauthenticated is returned when an operation needs a signed-in user
+// and the request has none.
+var ErrUnauthenticated = errors.New("authentication required")
+
+// ErrInsufficientScope is returned when an API token lacks the scope an
+// operation needs.
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+// API token scopes. Each scope includes the ones before it: write tokens
+// can read, admin tokens can also manage tokens.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// Scopes lists the API token scopes from least to most privileged.
+var Scopes = []string{ScopeRead, ScopeWrite, ScopeAdmin}
+
+// Workspace roles. Each role includes the ones before it: commenters can
+// read and write notes, editors can change any entity and owners can also
+// manage the workspace and its members.
+const (
+	RoleViewer    = "viewer"
+	RoleCommenter = "commenter"
+	RoleEditor    = "editor"
+	RoleOwner     = "owner"
+)
+
+// Roles lists the workspace roles from least to most privileged.
+var Roles = []string{RoleViewer, RoleCommenter, RoleEditor, RoleOwner}
+
+// HasRole reports whether a member holding role have may act as need. An
+// empty or unknown have, as for non-members, holds no role.
+func HasRole(have, need string) bool {
+	i := slices.Index(Roles, have)
+	return i >= 0 && i >= slices.Index(Roles, need)
+}
+
+// TokenPrefix marks API tokens so secret scanners can recognise them.
+const TokenPrefix = "gg_"
+
+// passwordCost is the bcrypt work factor for new password hashes.
+const passwordCost = 12
+
+// HashPassword returns the bcrypt hash of password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	return string(hash), err
+}
+
+// dummyHash is compared against when the account does not exist, so a
+// failed login takes as long whether or not the email is registered.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("go-goal"), passwordCost)
+	return hash
+})
+
+// CheckPassword reports whether password matches the hash of u. A nil user
+// is checked against a dummy hash and never matches.
+func CheckPassword(u *models.User, password string) bool {
+	if u == nil {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+// NewToken returns a random session token and the hash to store for it.
+func NewToken() (token string, hash []byte) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token)
+}
+
+// NewAPIToken returns a random API token, its display prefix and the hash
+// to store for it.
+func NewAPIToken() (token, prefix string, hash []byte) {
+	secret, _ := NewToken()
+	token = TokenPrefix + secret
+	return token, token[:len(TokenPrefix)+8], HashToken(token)
+}
+
+// HashToken returns the SHA-256 hash under which token is stored.
+func HashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+type userKey struct{}
+
+// WithUser returns a context carrying the signed-in user.
+func WithUser(ctx context.Context, u *models.User) context.Context {
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+// UserFrom returns the signed-in user of ctx, or nil.
+func UserFrom(ctx context.Context) *models.User {
+	u, _ := ctx.Value(userKey{}).(*models.User)
+	return u
+}
+
+// RequireUser returns the signed-in user of ctx or ErrUnauthenticated.
+func RequireUser(ctx context.Context) (*models.User, error) {
+	if u := UserFrom(ctx); u != nil {
+		return u, nil
+	}
+	return nil, ErrUnauthenticated
+}
+
+type scopesKey struct{}
+
+// WithScopes returns a context restricted to the scopes of the API token
+// that authenticated the request. Contexts without scopes, such as those of
+// session requests, are unrestricted.
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey{}, scopes)
+}
+
+// HasScope reports whether the request of ctx may act with scope.
+func HasScope(ctx context.Context, scope string) bool {
+	granted, ok := ctx.Value(scopesKey{}).([]string)
+	if !ok {
+		return true
+	}
+	need := slices.Index(Scopes, scope)
+	for _, g := range granted {
+		if slices.Index(Scopes, g) >= need {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireScope returns ErrInsufficientScope unless ctx has scope.
+func RequireScope(ctx context.Context, scope string) error {
+	if !HasScope(ctx, scope) {
+		return fmt.Errorf("%w: %s", ErrInsufficientScope, scope)
+	}
+	return nil
+}
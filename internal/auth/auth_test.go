@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go-goal/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswords(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	require.NoError(t, err)
+	u := &models.User{PasswordHash: hash}
+
+	assert.True(t, CheckPassword(u, "correct horse"))
+	assert.False(t, CheckPassword(u, "battery staple"))
+	assert.False(t, CheckPassword(nil, "correct horse"))
+}
+
+func TestNewToken(t *testing.T) {
+	token, hash := NewToken()
+	other, _ := NewToken()
+
+	assert.Len(t, token, 43)
+	assert.NotEqual(t, token, other)
+	assert.Equal(t, HashToken(token), hash)
+}
+
+func TestUserContext(t *testing.T) {
+	_, err := RequireUser(context.Background())
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	u := &models.User{ID: 7}
+	got, err := RequireUser(WithUser(context.Background(), u))
+	require.NoError(t, err)
+	assert.Same(t, u, got)
+}
+
+func TestNewAPIToken(t *testing.T) {
+	token, prefix, hash := NewAPIToken()
+
+	assert.True(t, strings.HasPrefix(token, "gg_"))
+	assert.Len(t, prefix, 11)
+	assert.True(t, strings.HasPrefix(token, prefix))
+	assert.Equal(t, HashToken(token), hash)
+}
+
+func TestHasScope(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, HasScope(ctx, ScopeAdmin), "sessions are unrestricted")
+
+	read := WithScopes(ctx, []string{ScopeRead})
+	assert.True(t, HasScope(read, ScopeRead))
+	assert.False(t, HasScope(read, ScopeWrite))
+	assert.ErrorIs(t, RequireScope(read, ScopeWrite), ErrInsufficientScope)
+
+	write := WithScopes(ctx, []string{ScopeWrite})
+	assert.True(t, HasScope(write, ScopeRead))
+	assert.False(t, HasScope(write, ScopeAdmin))
+
+	assert.True(t, HasScope(WithScopes(ctx, []string{ScopeAdmin}), ScopeWrite))
+	assert.False(t, HasScope(WithScopes(ctx, nil), ScopeRead))
+}
+
+func TestHasRole(t *testing.T) {
+	assert.True(t, HasRole(RoleOwner, RoleEditor))
+	assert.True(t, HasRole(RoleCommenter, RoleCommenter))
+	assert.False(t, HasRole(RoleViewer, RoleCommenter))
+	assert.False(t, HasRole("", RoleViewer), "non-members hold no role")
+}
@@ -0,0 +1,434 @@
+// Package conflicts evaluates the rules flows declare against the tasks,
+// goals and projects attached to them. Only active flows impose rules:
+//
+//   - two active flows linked by a conflicts_with relationship are reported
+//     as a conflicting pair;
+//   - the quiet hours of a flow apply to the tasks and goals of the flows
+//     declared in conflict with it;
+//   - the weekly task limit and forbidden tags of a flow apply to the
+//     flow's own entities.
+//
+// Rules only ever produce warnings; writes are never rejected.
+package conflicts
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+)
+
+// Rule names reported in models.FlowWarning.Rule.
+const (
+	RuleConflictsWith  = "conflicts_with"
+	RuleQuietHours     = "quiet_hours"
+	RuleMaxWeeklyTasks = "max_weekly_tasks"
+	RuleForbiddenTag   = "forbidden_tag"
+)
+
+// activeStatus is the flow status under which a flow's rules apply.
+const activeStatus = "active"
+
+// Subject is an entity the rules are evaluated against.
+type Subject struct {
+	EntityType string
+	ID         int
+	Title      string
+	FlowID     *int
+	DueDate    *time.Time
+}
+
+func TaskSubject(t *models.Task) Subject {
+	return Subject{EntityType: "task", ID: t.ID, Title: t.Title, FlowID: t.FlowID, DueDate: t.DueDate}
+}
+
+func GoalSubject(g *models.Goal) Subject {
+	return Subject{EntityType: "goal", ID: g.ID, Title: g.Title, FlowID: g.FlowID, DueDate: g.DueDate}
+}
+
+func ProjectSubject(p *models.Project) Subject {
+	return Subject{EntityType: "project", ID: p.ID, Title: p.Title, FlowID: p.FlowID}
+}
+
+// The rules an entity breaks depend on its flow, parents and due date only.
+// TaskMoved, GoalMoved and ProjectMoved report whether an update from before
+// to after changed any of them, so that updates evaluate the rules again
+// only when they may break different ones.
+
+func TaskMoved(before, after *models.Task) bool {
+	return !sameID(before.FlowID, after.FlowID) || !sameID(before.GoalID, after.GoalID) ||
+		!sameID(before.ProjectID, after.ProjectID) || !sameTime(before.DueDate, after.DueDate)
+}
+
+func GoalMoved(before, after *models.Goal) bool {
+	return !sameID(before.FlowID, after.FlowID) || !sameID(before.ProjectID, after.ProjectID) ||
+		!sameTime(before.DueDate, after.DueDate)
+}
+
+func ProjectMoved(before, after *models.Project) bool {
+	return !sameID(before.FlowID, after.FlowID) || !sameID(before.WorkspaceID, after.WorkspaceID)
+}
+
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// Engine evaluates flow rules, reading flows and entities through a store.
+type Engine struct {
+	store *store.Store
+}
+
+// New returns an Engine whose lookups go through s.
+func New(s *store.Store) *Engine {
+	return &Engine{store: s}
+}
+
+// Check returns the warnings raised by the subject, typically an entity
+// that was just created or moved to another flow.
+func (e *Engine) Check(ctx context.Context, s Subject) ([]models.FlowWarning, error) {
+	warnings := []models.FlowWarning{}
+	if s.FlowID == nil {
+		return warnings, nil
+	}
+
+	rules, err := e.load(ctx, store.FlowFilter{IDs: []int{*s.FlowID}}, true)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, rules.quietHours(s)...)
+
+	if _, ok := rules.flows[*s.FlowID]; !ok || s.ID == 0 {
+		return warnings, nil
+	}
+	if c, ok := rules.constraints[*s.FlowID]; ok && c.MaxWeeklyTasks != nil && s.EntityType == "task" && s.DueDate != nil {
+		tasks, err := e.store.Tasks.List(ctx, store.TaskFilter{FlowID: s.FlowID})
+		if err != nil {
+			return nil, err
+		}
+		week := weekOf(*s.DueDate)
+		if n := weeklyLoad(tasks)[week]; n > *c.MaxWeeklyTasks {
+			warnings = append(warnings, rules.overloaded(*s.FlowID, week, n, s))
+		}
+	}
+	if c, ok := rules.constraints[*s.FlowID]; ok && len(c.ForbiddenTags) > 0 {
+		tags, err := e.store.Tags.ListForEntity(ctx, s.EntityType, s.ID)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, rules.forbiddenTags(s, tags)...)
+	}
+	return warnings, nil
+}
+
+// Report returns every warning raised by the active flows of a workspace,
+// or of all workspaces the user of ctx can see when workspaceID is nil:
+// conflicting pairs first, then the rules broken by open tasks and goals and
+// by projects.
+func (e *Engine) Report(ctx context.Context, workspaceID *int) ([]models.FlowWarning, error) {
+	status := activeStatus
+	rules, err := e.load(ctx, store.FlowFilter{WorkspaceID: workspaceID, Status: &status}, false)
+	if err != nil {
+		return nil, err
+	}
+	warnings := rules.pairs()
+
+	ids := rules.flowIDs()
+	if len(ids) == 0 {
+		return warnings, nil
+	}
+	tasks, err := e.store.Tasks.List(ctx, store.TaskFilter{FlowIDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	goals, err := e.store.Goals.List(ctx, store.GoalFilter{FlowIDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	projects, err := e.store.Projects.List(ctx, store.ProjectFilter{FlowIDs: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	subjects := make([]Subject, 0, len(tasks)+len(goals)+len(projects))
+	for i := range tasks {
+		if open(tasks[i].Status) {
+			subjects = append(subjects, TaskSubject(&tasks[i]))
+		}
+	}
+	for i := range goals {
+		if open(goals[i].Status) {
+			subjects = append(subjects, GoalSubject(&goals[i]))
+		}
+	}
+	for i := range projects {
+		subjects = append(subjects, ProjectSubject(&projects[i]))
+	}
+
+	for _, s := range subjects {
+		warnings = append(warnings, rules.quietHours(s)...)
+	}
+
+	tasksByFlow := map[int][]models.Task{}
+	for _, t := range tasks {
+		tasksByFlow[*t.FlowID] = append(tasksByFlow[*t.FlowID], t)
+	}
+	for _, id := range ids {
+		c, ok := rules.constraints[id]
+		if !ok || c.MaxWeeklyTasks == nil {
+			continue
+		}
+		load := weeklyLoad(tasksByFlow[id])
+		weeks := make([]time.Time, 0, len(load))
+		for week := range load {
+			weeks = append(weeks, week)
+		}
+		slices.SortFunc(weeks, time.Time.Compare)
+		for _, week := range weeks {
+			if load[week] > *c.MaxWeeklyTasks {
+				warnings = append(warnings, rules.overloaded(id, week, load[week], Subject{}))
+			}
+		}
+	}
+
+	tags := map[string]map[int][]models.Tag{}
+	for _, entityType := range []string{"task", "goal", "project"} {
+		var entityIDs []int
+		for _, s := range subjects {
+			if s.EntityType == entityType && len(rules.constraints[*s.FlowID].ForbiddenTags) > 0 {
+				entityIDs = append(entityIDs, s.ID)
+			}
+		}
+		if len(entityIDs) == 0 {
+			continue
+		}
+		if tags[entityType], err = e.store.Tags.ListForEntities(ctx, entityType, entityIDs); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range subjects {
+		warnings = append(warnings, rules.forbiddenTags(s, tags[s.EntityType][s.ID])...)
+	}
+	return warnings, nil
+}
+
+// ruleSet holds the active flows being evaluated, the flows declared in
+// conflict with them and the constraints of both.
+type ruleSet struct {
+	flows       map[int]*models.Flow
+	conflicting map[int][]int
+	constraints map[int]models.FlowConstraints
+}
+
+// load reads the active flows matching filter with their relationships and
+// constraints. With partners set, active flows declared in conflict with
+// them are loaded too.
+func (e *Engine) load(ctx context.Context, filter store.FlowFilter, partners bool) (*ruleSet, error) {
+	rules := &ruleSet{flows: map[int]*models.Flow{}, conflicting: map[int][]int{}}
+
+	flows, err := e.store.Flows.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for i := range flows {
+		if flows[i].Status == activeStatus {
+			rules.flows[flows[i].ID] = &flows[i]
+			ids = append(ids, flows[i].ID)
+		}
+	}
+	if len(ids) == 0 {
+		return rules, nil
+	}
+
+	relationships, err := e.store.Flows.ListRelationships(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	var others []int
+	for _, r := range relationships {
+		if r.Type != RuleConflictsWith {
+			continue
+		}
+		rules.conflicting[r.FlowID] = append(rules.conflicting[r.FlowID], r.RelatedFlowID)
+		rules.conflicting[r.RelatedFlowID] = append(rules.conflicting[r.RelatedFlowID], r.FlowID)
+		for _, id := range []int{r.FlowID, r.RelatedFlowID} {
+			if _, ok := rules.flows[id]; !ok && !slices.Contains(others, id) {
+				others = append(others, id)
+			}
+		}
+	}
+	if partners && len(others) > 0 {
+		flows, err := e.store.Flows.List(ctx, store.FlowFilter{IDs: others})
+		if err != nil {
+			return nil, err
+		}
+		for i := range flows {
+			if flows[i].Status == activeStatus {
+				rules.flows[flows[i].ID] = &flows[i]
+				ids = append(ids, flows[i].ID)
+			}
+		}
+	}
+
+	if rules.constraints, err = e.store.Flows.ListConstraints(ctx, ids); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// flowIDs returns the IDs of the loaded flows in ascending order.
+func (rs *ruleSet) flowIDs() []int {
+	ids := make([]int, 0, len(rs.flows))
+	for id := range rs.flows {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// pairs reports each pair of loaded flows declared in conflict once.
+func (rs *ruleSet) pairs() []models.FlowWarning {
+	warnings := []models.FlowWarning{}
+	for _, id := range rs.flowIDs() {
+		for _, other := range rs.conflicting[id] {
+			if other <= id || rs.flows[other] == nil {
+				continue
+			}
+			f := rs.flows[id]
+			warnings = append(warnings, models.FlowWarning{
+				Rule:          RuleConflictsWith,
+				FlowID:        f.ID,
+				FlowTitle:     f.Title,
+				RelatedFlowID: &other,
+				Message:       fmt.Sprintf("%s and %s are both active but conflict", f.Title, rs.flows[other].Title),
+			})
+		}
+	}
+	return warnings
+}
+
+// quietHours reports the active flows in conflict with the subject's flow
+// whose quiet hours the subject is due in.
+func (rs *ruleSet) quietHours(s Subject) []models.FlowWarning {
+	var warnings []models.FlowWarning
+	if s.FlowID == nil || s.DueDate == nil || dateOnly(*s.DueDate) {
+		return warnings
+	}
+	for _, id := range rs.conflicting[*s.FlowID] {
+		f, c := rs.flows[id], rs.constraints[id]
+		if f == nil || c.QuietStart == nil || c.QuietEnd == nil || !within(*s.DueDate, *c.QuietStart, *c.QuietEnd) {
+			continue
+		}
+		warnings = append(warnings, models.FlowWarning{
+			Rule:          RuleQuietHours,
+			FlowID:        f.ID,
+			FlowTitle:     f.Title,
+			RelatedFlowID: s.FlowID,
+			EntityType:    s.EntityType,
+			EntityID:      s.ID,
+			Message: fmt.Sprintf("%s %q is due at %s, within the quiet hours of %s (%s-%s)",
+				s.EntityType, s.Title, s.DueDate.Format("15:04"), f.Title, *c.QuietStart, *c.QuietEnd),
+		})
+	}
+	return warnings
+}
+
+// overloaded reports a week in which a flow has n tasks due, more than its
+// limit. s is the task that tipped it over, if any.
+func (rs *ruleSet) overloaded(flowID int, week time.Time, n int, s Subject) models.FlowWarning {
+	f := rs.flows[flowID]
+	return models.FlowWarning{
+		Rule:       RuleMaxWeeklyTasks,
+		FlowID:     f.ID,
+		FlowTitle:  f.Title,
+		EntityType: s.EntityType,
+		EntityID:   s.ID,
+		Message: fmt.Sprintf("%s has %d tasks due in the week of %s, more than its limit of %d",
+			f.Title, n, week.Format(time.DateOnly), *rs.constraints[flowID].MaxWeeklyTasks),
+	}
+}
+
+// forbiddenTags reports the tags of the subject its flow forbids. Tag names
+// are compared case-insensitively.
+func (rs *ruleSet) forbiddenTags(s Subject, tags []models.Tag) []models.FlowWarning {
+	var warnings []models.FlowWarning
+	if s.FlowID == nil || rs.flows[*s.FlowID] == nil {
+		return warnings
+	}
+	f, c := rs.flows[*s.FlowID], rs.constraints[*s.FlowID]
+	for _, t := range tags {
+		if !slices.ContainsFunc(c.ForbiddenTags, func(name string) bool { return strings.EqualFold(name, t.Name) }) {
+			continue
+		}
+		warnings = append(warnings, models.FlowWarning{
+			Rule:       RuleForbiddenTag,
+			FlowID:     f.ID,
+			FlowTitle:  f.Title,
+			EntityType: s.EntityType,
+			EntityID:   s.ID,
+			Message:    fmt.Sprintf("%s %q is tagged %q, which %s forbids", s.EntityType, s.Title, t.Name, f.Title),
+		})
+	}
+	return warnings
+}
+
+// open reports whether a task or goal status still counts toward the rules.
+func open(status string) bool {
+	return status != "completed" && status != "cancelled"
+}
+
+// dateOnly reports whether a due date carries no time of day. Dates given
+// as YYYY-MM-DD are stored at midnight.
+func dateOnly(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0
+}
+
+// within reports whether the time of day of t falls in the window from
+// start to end, given as HH:MM. Windows whose end is not after their start
+// wrap past midnight.
+func within(t time.Time, start, end string) bool {
+	m := t.Hour()*60 + t.Minute()
+	from, to := minutes(start), minutes(end)
+	if from < to {
+		return m >= from && m < to
+	}
+	return m >= from || m < to
+}
+
+func minutes(clock string) int {
+	h, _ := strconv.Atoi(clock[:2])
+	m, _ := strconv.Atoi(clock[3:])
+	return h*60 + m
+}
+
+// weekOf returns the Monday starting the week of t.
+func weekOf(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// weeklyLoad counts the open tasks due in each week.
+func weeklyLoad(tasks []models.Task) map[time.Time]int {
+	load := map[time.Time]int{}
+	for _, t := range tasks {
+		if t.DueDate != nil && open(t.Status) {
+			load[weekOf(*t.DueDate)]++
+		}
+	}
+	return load
+}
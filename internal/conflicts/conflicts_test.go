@@ -0,0 +1,202 @@
+package conflicts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	flowColumns         = []string{"id", "title", "description", "color", "status", "start_date", "end_date", "parent_id", "workspace_id", "created_at", "updated_at"}
+	relationshipColumns = []string{"id", "flow_id", "related_flow_id", "relationship_type", "created_at"}
+	constraintColumns   = []string{"flow_id", "quiet_start", "quiet_end", "max_weekly_tasks", "forbidden_tags"}
+	taskColumns         = []string{"id", "title", "description", "goal_id", "project_id", "flow_id", "status", "priority", "due_date", "created_at", "updated_at"}
+	goalColumns         = []string{"id", "title", "description", "project_id", "flow_id", "status", "priority", "due_date", "created_at", "updated_at"}
+	projectColumns      = []string{"id", "title", "description", "status", "workspace_id", "flow_id", "created_at", "updated_at"}
+)
+
+func TestWithin(t *testing.T) {
+	at := func(clock string) time.Time {
+		parsed, err := time.Parse("15:04", clock)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	t.Run("should match times inside a daytime window", func(t *testing.T) {
+		assert.True(t, within(at("09:00"), "09:00", "17:00"))
+		assert.False(t, within(at("17:00"), "09:00", "17:00"))
+	})
+
+	t.Run("should wrap windows past midnight", func(t *testing.T) {
+		assert.True(t, within(at("23:30"), "22:00", "07:00"))
+		assert.True(t, within(at("06:59"), "22:00", "07:00"))
+		assert.False(t, within(at("12:00"), "22:00", "07:00"))
+	})
+}
+
+func TestWeekOf(t *testing.T) {
+	t.Run("should start weeks on Monday", func(t *testing.T) {
+		monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+		assert.Equal(t, monday, weekOf(time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC)))
+		assert.Equal(t, monday, weekOf(time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC)))
+	})
+}
+
+func TestTaskMoved(t *testing.T) {
+	flowID, otherFlowID := 1, 2
+	due := time.Date(2024, 3, 5, 23, 0, 0, 0, time.UTC)
+
+	t.Run("should ignore changes the rules do not depend on", func(t *testing.T) {
+		sameDue := due.In(time.FixedZone("CET", 3600))
+		sameFlowID := flowID
+		before := models.Task{Title: "Deploy", Status: "pending", FlowID: &flowID, DueDate: &due}
+		after := models.Task{Title: "Ship", Status: "completed", FlowID: &sameFlowID, DueDate: &sameDue}
+
+		assert.False(t, TaskMoved(&before, &after))
+	})
+
+	t.Run("should notice a new flow, parent or due date", func(t *testing.T) {
+		goalID := 3
+		later := due.AddDate(0, 0, 1)
+		before := models.Task{FlowID: &flowID, DueDate: &due}
+
+		assert.True(t, TaskMoved(&before, &models.Task{FlowID: &otherFlowID, DueDate: &due}))
+		assert.True(t, TaskMoved(&before, &models.Task{FlowID: &flowID, GoalID: &goalID, DueDate: &due}))
+		assert.True(t, TaskMoved(&before, &models.Task{FlowID: &flowID, DueDate: &later}))
+		assert.True(t, TaskMoved(&before, &models.Task{DueDate: &due}))
+	})
+}
+
+func TestCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	e := New(store.New(db))
+	ctx := context.Background()
+	flowID := 1
+	due := time.Date(2024, 3, 5, 23, 0, 0, 0, time.UTC)
+
+	t.Run("should warn about tasks due in the quiet hours of a conflicting flow", func(t *testing.T) {
+		mock.ExpectQuery(`FROM flows WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows(flowColumns).
+				AddRow(1, "Work", "", "", "active", nil, nil, nil, 1, time.Now(), time.Now()))
+		mock.ExpectQuery(`FROM flow_relationships`).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows(relationshipColumns).AddRow(1, 2, 1, "conflicts_with", time.Now()))
+		mock.ExpectQuery(`FROM flows WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{2})).
+			WillReturnRows(sqlmock.NewRows(flowColumns).
+				AddRow(2, "Sleep", "", "", "active", nil, nil, nil, 1, time.Now(), time.Now()))
+		mock.ExpectQuery(`FROM flow_constraints c`).
+			WithArgs(pq.Array([]int{1, 2})).
+			WillReturnRows(sqlmock.NewRows(constraintColumns).AddRow(2, "22:00", "07:00", nil, "{}"))
+
+		warnings, err := e.Check(ctx, Subject{EntityType: "task", ID: 5, Title: "Deploy", FlowID: &flowID, DueDate: &due})
+
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		assert.Equal(t, RuleQuietHours, warnings[0].Rule)
+		assert.Equal(t, 2, warnings[0].FlowID)
+		assert.Equal(t, 5, warnings[0].EntityID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should warn when a task exceeds the weekly limit of its flow", func(t *testing.T) {
+		mock.ExpectQuery(`FROM flows WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows(flowColumns).
+				AddRow(1, "Work", "", "", "active", nil, nil, nil, 1, time.Now(), time.Now()))
+		mock.ExpectQuery(`FROM flow_relationships`).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows(relationshipColumns))
+		mock.ExpectQuery(`FROM flow_constraints c`).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows(constraintColumns).AddRow(1, nil, nil, 1, "{}"))
+		mock.ExpectQuery(`FROM tasks WHERE flow_id = \$1`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).
+				AddRow(4, "Review", "", nil, 1, 1, "pending", 1, due.AddDate(0, 0, 1), time.Now(), time.Now()).
+				AddRow(5, "Deploy", "", nil, 1, 1, "pending", 1, due, time.Now(), time.Now()))
+
+		warnings, err := e.Check(ctx, Subject{EntityType: "task", ID: 5, Title: "Deploy", FlowID: &flowID, DueDate: &due})
+
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		assert.Equal(t, RuleMaxWeeklyTasks, warnings[0].Rule)
+		assert.Contains(t, warnings[0].Message, "2 tasks due in the week of 2024-03-04")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should ignore flows that are not active", func(t *testing.T) {
+		mock.ExpectQuery(`FROM flows WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows(flowColumns).
+				AddRow(1, "Work", "", "", "paused", nil, nil, nil, 1, time.Now(), time.Now()))
+
+		warnings, err := e.Check(ctx, Subject{EntityType: "task", ID: 5, FlowID: &flowID, DueDate: &due})
+
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestReport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	e := New(store.New(db))
+	workspaceID := 3
+
+	t.Run("should report conflicting pairs and forbidden tags", func(t *testing.T) {
+		mock.ExpectQuery(`FROM flows WHERE workspace_id = \$1 AND status = \$2`).
+			WithArgs(3, "active").
+			WillReturnRows(sqlmock.NewRows(flowColumns).
+				AddRow(2, "Sleep", "", "", "active", nil, nil, nil, 3, time.Now(), time.Now()).
+				AddRow(1, "Work", "", "", "active", nil, nil, nil, 3, time.Now(), time.Now()))
+		mock.ExpectQuery(`FROM flow_relationships`).
+			WithArgs(pq.Array([]int{2, 1})).
+			WillReturnRows(sqlmock.NewRows(relationshipColumns).AddRow(1, 2, 1, "conflicts_with", time.Now()))
+		mock.ExpectQuery(`FROM flow_constraints c`).
+			WithArgs(pq.Array([]int{2, 1})).
+			WillReturnRows(sqlmock.NewRows(constraintColumns).AddRow(1, nil, nil, nil, "{urgent}"))
+		mock.ExpectQuery(`FROM tasks WHERE flow_id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{1, 2})).
+			WillReturnRows(sqlmock.NewRows(taskColumns).
+				AddRow(5, "Deploy", "", nil, 1, 1, "pending", 1, nil, time.Now(), time.Now()).
+				AddRow(6, "Done", "", nil, 1, 1, "completed", 1, nil, time.Now(), time.Now()))
+		mock.ExpectQuery(`FROM goals WHERE flow_id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{1, 2})).
+			WillReturnRows(sqlmock.NewRows(goalColumns))
+		mock.ExpectQuery(`FROM projects WHERE flow_id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{1, 2})).
+			WillReturnRows(sqlmock.NewRows(projectColumns))
+		mock.ExpectQuery(`FROM tags t`).
+			WithArgs(pq.Array([]int{5})).
+			WillReturnRows(sqlmock.NewRows([]string{"entity_id", "id", "name", "color", "parent_id", "created_at"}).
+				AddRow(5, 9, "Urgent", "", nil, time.Now()))
+
+		warnings, err := e.Report(context.Background(), &workspaceID)
+
+		require.NoError(t, err)
+		require.Len(t, warnings, 2)
+		assert.Equal(t, RuleConflictsWith, warnings[0].Rule)
+		assert.Equal(t, 1, warnings[0].FlowID)
+		assert.Equal(t, 2, *warnings[0].RelatedFlowID)
+		assert.Equal(t, RuleForbiddenTag, warnings[1].Rule)
+		assert.Equal(t, 5, warnings[1].EntityID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -1,20 +1,80 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 )
 
-func Connect(databaseURL string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", databaseURL)
+// Pool configures the connection pool opened by Connect. Zero values keep
+// the database/sql defaults: unlimited open connections, two idle ones and
+// no lifetime limits.
+type Pool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// StatementTimeout makes the server abort any statement running longer,
+	// including ones whose client has gone away. Zero leaves the server's
+	// setting in place.
+	StatementTimeout time.Duration
+}
+
+// Connect opens a pool for databaseURL configured by pool and verifies it
+// with a ping bounded by ctx. Every statement is traced as a child of the
+// span in its context, using the global tracer provider.
+func Connect(ctx context.Context, databaseURL string, pool Pool) (*sql.DB, error) {
+	dsn, err := withStatementTimeout(databaseURL, pool.StatementTimeout)
+	if err != nil {
+		return nil, err
+	}
+	db, err := otelsql.Open("postgres", dsn,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
 	if err != nil {
 		return nil, err
 	}
-	
-	if err := db.Ping(); err != nil {
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
 		return nil, err
 	}
-	
 	return db, nil
-}
\ No newline at end of file
+}
+
+// withStatementTimeout adds a statement_timeout run-time parameter to
+// databaseURL, which lib/pq sends when each connection starts. A timeout
+// already present in the URL wins.
+func withStatementTimeout(databaseURL string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return databaseURL, nil
+	}
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	if query.Has("statement_timeout") {
+		return databaseURL, nil
+	}
+	query.Set("statement_timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
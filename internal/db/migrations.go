@@ -1,50 +1,413 @@
 package db
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
 )
 
-func RunMigrations(db *sql.DB) error {
-	// Create migrations table if it doesn't exist
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS migrations (
-			version INTEGER PRIMARY KEY,
-			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
+// migrationLockID is the key of the Postgres advisory lock held while
+// migrations run, so two instances starting at once don't race.
+const migrationLockID = 7_430_552_190
+
+// ErrChecksumMismatch is returned when a migration file that has already been
+// applied no longer matches the checksum recorded at the time.
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+?)(\.down)?\.sql$`)
+
+// Migration is a single numbered schema change.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus describes a known migration and whether it has been applied.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// SchemaState summarises the applied migrations against the embedded set.
+type SchemaState struct {
+	Applied  int // highest applied version, 0 when none
+	Latest   int // highest embedded version
+	Pending  int // embedded migrations not applied yet
+	Modified int // applied migrations whose file changed since
+}
+
+// Migrator applies and rolls back the migrations found in an fs.FS.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator loads every NNN_name.sql (and optional NNN_name.down.sql) file
+// from fsys, ordered by version.
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
 	if err != nil {
-		return fmt.Errorf("failed to create migrations table: %w", err)
+		return nil, err
 	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
 
-	// Run migration 001
-	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM migrations WHERE version = 1").Scan(&count)
+// LoadMigrations reads and orders the migration files in fsys.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
 	if err != nil {
-		return fmt.Errorf("failed to check migration status: %w", err)
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %03d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] != "" {
+			m.Down = string(content)
+			continue
+		}
+		if m.Up != "" {
+			return nil, fmt.Errorf("duplicate migration version %03d", version)
+		}
+		m.Up = string(content)
+		sum := sha256.Sum256(content)
+		m.Checksum = hex.EncodeToString(sum[:])
 	}
 
-	if count == 0 {
-		// Read and execute migration file
-		migrationSQL, err := ioutil.ReadFile("migrations/001_create_tables.sql")
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s has a down file but no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrations returns the known migrations in version order.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up verifies the checksums of applied migrations and then applies every
+// pending one in order, each inside its own transaction.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.verify(ctx, conn)
 		if err != nil {
-			return fmt.Errorf("failed to read migration file: %w", err)
+			return err
 		}
 
-		_, err = db.Exec(string(migrationSQL))
+		for _, migration := range m.migrations {
+			if _, ok := records[migration.Version]; ok {
+				continue
+			}
+			if err := applyMigration(ctx, conn, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the most recently applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.verify(ctx, conn)
 		if err != nil {
-			return fmt.Errorf("failed to execute migration: %w", err)
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := records[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %03d_%s has no down migration", migration.Version, migration.Name)
+			}
+			if err := revertMigration(ctx, conn, migration); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
 		}
+		return nil
+	})
+	return reverted, err
+}
 
-		// Mark migration as applied
-		_, err = db.Exec("INSERT INTO migrations (version) VALUES (1)")
+// Verify checks every applied migration against the embedded files without
+// applying anything.
+func (m *Migrator) Verify(ctx context.Context) error {
+	return m.withConn(ctx, func(conn *sql.Conn) error {
+		_, err := m.verify(ctx, conn)
+		return err
+	})
+}
+
+// Status reports every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		records, err := loadRecords(ctx, conn)
 		if err != nil {
-			return fmt.Errorf("failed to record migration: %w", err)
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if record, ok := records[migration.Version]; ok {
+				appliedAt := record.appliedAt
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Pending returns the number of embedded migrations that have not been applied.
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// AppliedVersion returns the highest applied migration version, or 0 when
+// none has been applied. It may exceed the embedded set when the database
+// was migrated by a newer build.
+func (m *Migrator) AppliedVersion(ctx context.Context) (int, error) {
+	var version int
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		return conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM migrations").Scan(&version)
+	})
+	return version, err
+}
+
+// Inspect reads the applied migrations without writing anything. Unlike
+// Status it never creates or alters the migrations table, so it is safe to
+// call from health probes; a database without the table has every
+// migration pending.
+func (m *Migrator) Inspect(ctx context.Context) (SchemaState, error) {
+	var state SchemaState
+	for _, migration := range m.migrations {
+		state.Latest = max(state.Latest, migration.Version)
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, checksum FROM migrations")
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" {
+		state.Pending = len(m.migrations)
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to check migration status: %w", err)
+	}
+	defer rows.Close()
+
+	checksums := map[int]sql.NullString{}
+	for rows.Next() {
+		var version int
+		var checksum sql.NullString
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return state, fmt.Errorf("failed to scan migration: %w", err)
 		}
+		checksums[version] = checksum
+		state.Applied = max(state.Applied, version)
+	}
+	if err := rows.Err(); err != nil {
+		return state, err
+	}
 
-		fmt.Println("Migration 001 applied successfully")
+	for _, migration := range m.migrations {
+		checksum, ok := checksums[migration.Version]
+		switch {
+		case !ok:
+			state.Pending++
+		case checksum.Valid && checksum.String != migration.Checksum:
+			state.Modified++
+		}
 	}
+	return state, nil
+}
+
+type migrationRecord struct {
+	checksum  sql.NullString
+	appliedAt time.Time
+}
 
+// verify checks every applied migration against its file. Rows recorded by
+// the old single-file runner have no checksum yet and are backfilled.
+func (m *Migrator) verify(ctx context.Context, conn *sql.Conn) (map[int]migrationRecord, error) {
+	records, err := loadRecords(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	for version, record := range records {
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("applied migration %03d is not in the embedded set", version)
+		}
+		if !record.checksum.Valid {
+			_, err := conn.ExecContext(ctx, "UPDATE migrations SET name = $2, checksum = $3 WHERE version = $1",
+				version, migration.Name, migration.Checksum)
+			if err != nil {
+				return nil, fmt.Errorf("failed to record checksum for migration %03d: %w", version, err)
+			}
+			continue
+		}
+		if record.checksum.String != migration.Checksum {
+			return nil, fmt.Errorf("%w: %03d_%s was modified after it was applied", ErrChecksumMismatch, version, migration.Name)
+		}
+	}
+	return records, nil
+}
+
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	return m.withConn(ctx, func(conn *sql.Conn) error {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+		return fn(conn)
+	})
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		ALTER TABLE migrations ADD COLUMN IF NOT EXISTS name VARCHAR(255);
+		ALTER TABLE migrations ADD COLUMN IF NOT EXISTS checksum VARCHAR(64);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+func loadRecords(ctx context.Context, conn *sql.Conn) (map[int]migrationRecord, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check migration status: %w", err)
+	}
+	defer rows.Close()
+
+	records := map[int]migrationRecord{}
+	for rows.Next() {
+		var version int
+		var record migrationRecord
+		if err := rows.Scan(&version, &record.checksum, &record.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		records[version] = record
+	}
+	return records, rows.Err()
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+		return fmt.Errorf("failed to execute migration %03d_%s: %w", migration.Version, migration.Name, err)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO migrations (version, name, checksum) VALUES ($1, $2, $3)",
+		migration.Version, migration.Name, migration.Checksum)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %03d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return tx.Commit()
+}
+
+func revertMigration(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+		return fmt.Errorf("failed to revert migration %03d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM migrations WHERE version = $1", migration.Version); err != nil {
+		return fmt.Errorf("failed to unrecord migration %03d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"go-goal/migrations"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("should order migrations by version and pair down files", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"002_second.sql":      {Data: []byte("CREATE TABLE b ();")},
+			"001_first.sql":       {Data: []byte("CREATE TABLE a ();")},
+			"001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+			"README.md":           {Data: []byte("ignored")},
+			"010_tenth.sql":       {Data: []byte("SELECT 1;")},
+			"010_tenth.down.sql":  {Data: []byte("SELECT 2;")},
+			"notes/003_inner.sql": {Data: []byte("ignored")},
+		}
+
+		loaded, err := LoadMigrations(fsys)
+
+		require.NoError(t, err)
+		require.Len(t, loaded, 3)
+		assert.Equal(t, []int{1, 2, 10}, []int{loaded[0].Version, loaded[1].Version, loaded[2].Version})
+		assert.Equal(t, "first", loaded[0].Name)
+		assert.Equal(t, "DROP TABLE a;", loaded[0].Down)
+		assert.Empty(t, loaded[1].Down)
+		assert.Len(t, loaded[0].Checksum, 64)
+	})
+
+	t.Run("should reject duplicate versions", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"001_first.sql": {Data: []byte("SELECT 1;")},
+			"001_other.sql": {Data: []byte("SELECT 2;")},
+		}
+
+		_, err := LoadMigrations(fsys)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject a down file without an up file", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"001_first.down.sql": {Data: []byte("SELECT 1;")},
+		}
+
+		_, err := LoadMigrations(fsys)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should load the embedded migrations", func(t *testing.T) {
+		loaded, err := LoadMigrations(migrations.FS)
+
+		require.NoError(t, err)
+		require.NotEmpty(t, loaded)
+		for i, m := range loaded {
+			assert.Equal(t, i+1, m.Version, "migrations must be numbered without gaps")
+			assert.NotEmpty(t, m.Down, "migration %03d_%s needs a down file", m.Version, m.Name)
+		}
+	})
+}
+
+func TestMigratorUp(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_first.sql":  {Data: []byte("CREATE TABLE a ();")},
+		"002_second.sql": {Data: []byte("CREATE TABLE b ();")},
+	}
+
+	setup := func(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		m, err := NewMigrator(db, fsys)
+		require.NoError(t, err)
+
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SELECT pg_advisory_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+		return m, mock
+	}
+
+	t.Run("should apply only pending migrations", func(t *testing.T) {
+		m, mock := setup(t)
+		first := m.Migrations()[0]
+
+		mock.ExpectQuery(`SELECT version, checksum, applied_at FROM migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+				AddRow(1, first.Checksum, time.Now()))
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE b`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO migrations`).
+			WithArgs(2, "second", m.Migrations()[1].Checksum).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectExec(`SELECT pg_advisory_unlock`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		applied, err := m.Up(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		assert.Equal(t, 2, applied[0].Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should refuse to run when an applied file changed", func(t *testing.T) {
+		m, mock := setup(t)
+
+		mock.ExpectQuery(`SELECT version, checksum, applied_at FROM migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+				AddRow(1, "0000", time.Now()))
+		mock.ExpectExec(`SELECT pg_advisory_unlock`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		applied, err := m.Up(context.Background())
+
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+		assert.Empty(t, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should backfill checksums recorded by the old runner", func(t *testing.T) {
+		m, mock := setup(t)
+
+		mock.ExpectQuery(`SELECT version, checksum, applied_at FROM migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+				AddRow(1, nil, time.Now()).
+				AddRow(2, m.Migrations()[1].Checksum, time.Now()))
+		mock.ExpectExec(`UPDATE migrations SET name = \$2, checksum = \$3 WHERE version = \$1`).
+			WithArgs(1, "first", m.Migrations()[0].Checksum).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`SELECT pg_advisory_unlock`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		applied, err := m.Up(context.Background())
+
+		assert.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
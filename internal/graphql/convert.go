@@ -0,0 +1,348 @@
+package graphql
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-goal/internal/models"
+	"go-goal/internal/validation"
+)
+
+// The helpers below translate store models into the gqlgen types. Nullable
+// foreign keys that the schema declares as non-null fall back to zero.
+
+func toProject(p *models.Project) *Project {
+	project := &Project{
+		ID:          strconv.Itoa(p.ID),
+		Title:       p.Title,
+		Description: &p.Description,
+		Status:      p.Status,
+		FlowID:      p.FlowID,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+	if p.WorkspaceID != nil {
+		project.WorkspaceID = *p.WorkspaceID
+	}
+	return project
+}
+
+func toGoal(g *models.Goal) *Goal {
+	goal := &Goal{
+		ID:          strconv.Itoa(g.ID),
+		Title:       g.Title,
+		Description: &g.Description,
+		Priority:    strconv.Itoa(g.Priority),
+		DueDate:     g.DueDate,
+		Status:      g.Status,
+		FlowID:      g.FlowID,
+		CreatedAt:   g.CreatedAt,
+		UpdatedAt:   g.UpdatedAt,
+	}
+	if g.ProjectID != nil {
+		goal.ProjectID = *g.ProjectID
+	}
+	return goal
+}
+
+func toTask(t *models.Task) *Task {
+	task := &Task{
+		ID:          strconv.Itoa(t.ID),
+		Title:       t.Title,
+		Description: &t.Description,
+		Status:      t.Status,
+		Priority:    strconv.Itoa(t.Priority),
+		DueDate:     t.DueDate,
+		GoalID:      t.GoalID,
+		FlowID:      t.FlowID,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+	if t.ProjectID != nil {
+		task.ProjectID = *t.ProjectID
+	}
+	return task
+}
+
+func toTag(t *models.Tag) *Tag {
+	return &Tag{
+		ID:        strconv.Itoa(t.ID),
+		Name:      t.Name,
+		Color:     t.Color,
+		ParentID:  t.ParentID,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.CreatedAt,
+	}
+}
+
+func toNote(n *models.Note) *Note {
+	note := &Note{
+		ID:         strconv.Itoa(n.ID),
+		Title:      n.Title,
+		Content:    n.Content,
+		EntityType: n.EntityType,
+		CreatedAt:  n.CreatedAt,
+		UpdatedAt:  n.UpdatedAt,
+	}
+	if n.EntityID != nil {
+		note.EntityID = *n.EntityID
+	}
+	return note
+}
+
+func toWorkspace(ws *models.Workspace) *Workspace {
+	return &Workspace{
+		ID:          strconv.Itoa(ws.ID),
+		Name:        ws.Name,
+		Description: &ws.Description,
+		CreatedAt:   ws.CreatedAt,
+		UpdatedAt:   ws.CreatedAt,
+	}
+}
+
+func toUser(u *models.User) *User {
+	return &User{
+		ID:        strconv.Itoa(u.ID),
+		Email:     u.Email,
+		Name:      u.Name,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+func toWorkspaceMember(m *models.WorkspaceMember) *WorkspaceMember {
+	return &WorkspaceMember{
+		UserID:    m.UserID,
+		Email:     m.Email,
+		Name:      m.Name,
+		Role:      WorkspaceRole(strings.ToUpper(m.Role)),
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+func toWorkspaceMembers(members []models.WorkspaceMember) []*WorkspaceMember {
+	result := make([]*WorkspaceMember, len(members))
+	for i := range members {
+		result[i] = toWorkspaceMember(&members[i])
+	}
+	return result
+}
+
+// fromWorkspaceRole returns the stored form of a GraphQL workspace role.
+func fromWorkspaceRole(role WorkspaceRole) string {
+	return strings.ToLower(string(role))
+}
+
+func toFlow(f *models.Flow) *Flow {
+	return &Flow{
+		ID:          strconv.Itoa(f.ID),
+		Title:       f.Title,
+		Description: &f.Description,
+		Color:       f.Color,
+		Status:      f.Status,
+		StartDate:   f.StartDate,
+		EndDate:     f.EndDate,
+		ParentID:    f.ParentID,
+		WorkspaceID: f.WorkspaceID,
+		CreatedAt:   f.CreatedAt,
+		UpdatedAt:   f.UpdatedAt,
+	}
+}
+
+func toProjects(projects []models.Project) []*Project {
+	result := make([]*Project, len(projects))
+	for i := range projects {
+		result[i] = toProject(&projects[i])
+	}
+	return result
+}
+
+func toGoals(goals []models.Goal) []*Goal {
+	result := make([]*Goal, len(goals))
+	for i := range goals {
+		result[i] = toGoal(&goals[i])
+	}
+	return result
+}
+
+func toTasks(tasks []models.Task) []*Task {
+	result := make([]*Task, len(tasks))
+	for i := range tasks {
+		result[i] = toTask(&tasks[i])
+	}
+	return result
+}
+
+func toTags(tags []models.Tag) []*Tag {
+	result := make([]*Tag, len(tags))
+	for i := range tags {
+		result[i] = toTag(&tags[i])
+	}
+	return result
+}
+
+func toNotes(notes []models.Note) []*Note {
+	result := make([]*Note, len(notes))
+	for i := range notes {
+		result[i] = toNote(&notes[i])
+	}
+	return result
+}
+
+func toWorkspaces(workspaces []models.Workspace) []*Workspace {
+	result := make([]*Workspace, len(workspaces))
+	for i := range workspaces {
+		result[i] = toWorkspace(&workspaces[i])
+	}
+	return result
+}
+
+func toFlows(flows []models.Flow) []*Flow {
+	result := make([]*Flow, len(flows))
+	for i := range flows {
+		result[i] = toFlow(&flows[i])
+	}
+	return result
+}
+
+// parsePriority converts the GraphQL string priority into the integer
+// stored in the database.
+func parsePriority(priority string) (int, error) {
+	p, err := strconv.Atoi(priority)
+	if err != nil {
+		return 0, invalidInput(validation.Errors{{Field: "priority", Code: "invalid_type", Message: "must be an integer"}})
+	}
+	return p, nil
+}
+
+func toAuditEvent(e *models.AuditEvent) *AuditEvent {
+	event := &AuditEvent{
+		ID:                  strconv.FormatInt(e.ID, 10),
+		ActorID:             e.ActorID,
+		WorkspaceID:         e.WorkspaceID,
+		PreviousWorkspaceID: e.PreviousWorkspaceID,
+		EntityType:          e.EntityType,
+		EntityID:            e.EntityID,
+		Action:              e.Action,
+		Before:              optionalJSON(e.Before),
+		After:               optionalJSON(e.After),
+		CreatedAt:           e.CreatedAt,
+	}
+	if e.ActorEmail != "" {
+		event.ActorEmail = &e.ActorEmail
+	}
+	if e.RequestID != "" {
+		event.RequestID = &e.RequestID
+	}
+	return event
+}
+
+// optionalJSON returns a JSON document as a string, or nil when absent.
+func optionalJSON(doc json.RawMessage) *string {
+	if doc == nil {
+		return nil
+	}
+	s := string(doc)
+	return &s
+}
+
+func toFlowRelationship(r *models.FlowRelationship) *FlowRelationship {
+	return &FlowRelationship{
+		ID:            strconv.Itoa(r.ID),
+		Type:          FlowRelationshipType(strings.ToUpper(r.Type)),
+		FlowID:        r.FlowID,
+		RelatedFlowID: r.RelatedFlowID,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+func toFlowRelationships(relationships []models.FlowRelationship) []*FlowRelationship {
+	result := make([]*FlowRelationship, len(relationships))
+	for i := range relationships {
+		result[i] = toFlowRelationship(&relationships[i])
+	}
+	return result
+}
+
+// fromFlowRelationshipType returns the stored form of a GraphQL flow
+// relationship type.
+func fromFlowRelationshipType(t FlowRelationshipType) string {
+	return strings.ToLower(string(t))
+}
+
+func toFlowConstraints(c *models.FlowConstraints) *FlowConstraints {
+	return &FlowConstraints{
+		FlowID:         c.FlowID,
+		QuietStart:     c.QuietStart,
+		QuietEnd:       c.QuietEnd,
+		MaxWeeklyTasks: c.MaxWeeklyTasks,
+		ForbiddenTags:  c.ForbiddenTags,
+	}
+}
+
+func toFlowWarnings(warnings []models.FlowWarning) []*FlowWarning {
+	result := make([]*FlowWarning, len(warnings))
+	for i, w := range warnings {
+		result[i] = &FlowWarning{
+			Rule:          w.Rule,
+			FlowID:        w.FlowID,
+			FlowTitle:     w.FlowTitle,
+			RelatedFlowID: w.RelatedFlowID,
+			Message:       w.Message,
+		}
+		if w.EntityType != "" {
+			result[i].EntityType = &w.EntityType
+			result[i].EntityID = &w.EntityID
+		}
+	}
+	return result
+}
+
+func toFlowTransitions(transitions []models.FlowTransition) []*FlowTransition {
+	result := make([]*FlowTransition, len(transitions))
+	for i, t := range transitions {
+		result[i] = &FlowTransition{
+			ID:         strconv.Itoa(t.ID),
+			FlowID:     t.FlowID,
+			FromStatus: t.FromStatus,
+			ToStatus:   t.ToStatus,
+			ActorID:    t.ActorID,
+			CreatedAt:  t.CreatedAt,
+		}
+		if t.Reason != "" {
+			result[i].Reason = &t.Reason
+		}
+	}
+	return result
+}
+
+func toFlowStats(s *models.FlowStats) *FlowStats {
+	return &FlowStats{
+		TotalProjects:         s.TotalProjects,
+		ActiveProjects:        s.ActiveProjects,
+		TotalGoals:            s.TotalGoals,
+		CompletedGoals:        s.CompletedGoals,
+		TotalTasks:            s.TotalTasks,
+		CompletedTasks:        s.CompletedTasks,
+		PendingTasks:          s.PendingTasks,
+		GoalCompletionPercent: s.GoalCompletionPercent,
+		TaskCompletionPercent: s.TaskCompletionPercent,
+	}
+}
+
+func toFlowTimeline(t *models.FlowTimeline) *FlowTimeline {
+	timeline := &FlowTimeline{From: t.From, To: t.To, Weeks: make([]*time.Time, len(t.Weeks)), Flows: make([]*TimelineFlow, len(t.Flows))}
+	for i := range t.Weeks {
+		timeline.Weeks[i] = &t.Weeks[i]
+	}
+	for i := range t.Flows {
+		f := &t.Flows[i]
+		counts := make([]*WeekCount, len(f.CompletedTasks))
+		for j, c := range f.CompletedTasks {
+			counts[j] = &WeekCount{Week: c.Week, Count: c.Count}
+		}
+		timeline.Flows[i] = &TimelineFlow{Flow: toFlow(&f.Flow), Lane: f.Lane, OverlapGroup: f.OverlapGroup, CompletedTasks: counts}
+	}
+	return timeline
+}
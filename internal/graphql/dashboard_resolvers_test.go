@@ -0,0 +1,50 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-goal/internal/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	resolver := &queryResolver{
+		Resolver: &Resolver{Store: store.New(db)},
+	}
+
+	t.Run("should limit every section to the workspace when workspaceId is set", func(t *testing.T) {
+		workspaceID := 3
+		mock.ExpectQuery(`FROM tasks WHERE .* AND COALESCE\(.*\) = \$1 ORDER BY priority DESC LIMIT 10`).
+			WithArgs(workspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "title", "description", "goal_id", "project_id", "flow_id",
+				"status", "priority", "due_date", "created_at", "updated_at",
+			}).AddRow(1, "Task", "", nil, 3, nil, "in_progress", 2, nil, time.Now(), time.Now()))
+		mock.ExpectQuery(`FROM projects WHERE workspace_id = \$1 ORDER BY updated_at DESC LIMIT 5`).
+			WithArgs(workspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(`FROM goals WHERE due_date > CURRENT_DATE AND COALESCE\(.*\) = \$1 ORDER BY due_date ASC LIMIT 5`).
+			WithArgs(workspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM projects WHERE projects\.workspace_id = \$1\)`).
+			WithArgs(workspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"projects", "goals", "tasks", "completed", "pending"}).AddRow(1, 0, 1, 0, 1))
+
+		dashboard, err := resolver.Dashboard(context.Background(), &workspaceID)
+
+		require.NoError(t, err)
+		require.Len(t, dashboard.TodayTasks, 1)
+		assert.Equal(t, "Task", dashboard.TodayTasks[0].Title)
+		assert.Equal(t, 1, dashboard.WorkspaceStats.TotalProjects)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
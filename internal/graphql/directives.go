@@ -0,0 +1,79 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/store"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Directives returns the implementations of the schema directives, for
+// Config.Directives.
+func (r *Resolver) Directives() DirectiveRoot {
+	return DirectiveRoot{HasRole: r.HasRole}
+}
+
+// HasRole implements @hasRole. It rejects the field unless the signed-in
+// user holds at least role in the workspace, which is the parent object on
+// Workspace fields and otherwise the argument named idArg. Fields called
+// without that argument are left to the stores, which enforce roles on
+// every query anyway; the directive documents the requirement in the
+// schema and fails early with a FORBIDDEN error.
+func (r *Resolver) HasRole(ctx context.Context, obj any, next graphql.Resolver, role WorkspaceRole, idArg string) (any, error) {
+	workspaceID, ok, err := directiveWorkspace(ctx, obj, idArg)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return next(ctx)
+	}
+
+	u, err := auth.RequireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	have, err := r.Store.Members.Role(ctx, workspaceID, u.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("workspace not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check workspace role: %w", err)
+	}
+	if !auth.HasRole(have, fromWorkspaceRole(role)) {
+		return nil, fmt.Errorf("%w: needs the %s role", store.ErrForbidden, fromWorkspaceRole(role))
+	}
+	return next(ctx)
+}
+
+// directiveWorkspace finds the workspace a @hasRole field refers to.
+func directiveWorkspace(ctx context.Context, obj any, idArg string) (id int, ok bool, err error) {
+	if ws, isWorkspace := obj.(*Workspace); isWorkspace {
+		id, err = strconv.Atoi(ws.ID)
+		return id, err == nil, err
+	}
+
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil {
+		return 0, false, nil
+	}
+	switch v := fc.Args[idArg].(type) {
+	case int:
+		return v, true, nil
+	case *int:
+		if v != nil {
+			return *v, true, nil
+		}
+	case string:
+		id, err = strconv.Atoi(v)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid workspace ID: %w", err)
+		}
+		return id, true, nil
+	}
+	return 0, false, nil
+}
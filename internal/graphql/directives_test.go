@@ -0,0 +1,63 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	r := &Resolver{Store: store.New(db)}
+	ctx := auth.WithUser(context.Background(), &models.User{ID: 7})
+	next := func(ctx context.Context) (any, error) { return true, nil }
+	field := func(args map[string]any) context.Context {
+		return graphql.WithFieldContext(ctx, &graphql.FieldContext{Args: args})
+	}
+
+	t.Run("should pass members holding the role", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT role FROM workspace_members`).
+			WithArgs(3, 7).
+			WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("owner"))
+
+		res, err := r.HasRole(field(map[string]any{"id": "3"}), nil, next, WorkspaceRoleOwner, "id")
+
+		assert.NoError(t, err)
+		assert.Equal(t, true, res)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should forbid lower roles", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT role FROM workspace_members`).
+			WithArgs(3, 7).
+			WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("viewer"))
+
+		_, err := r.HasRole(field(map[string]any{"workspaceId": 3}), nil, next, WorkspaceRoleEditor, "workspaceId")
+
+		assert.ErrorIs(t, err, store.ErrForbidden)
+		assert.Equal(t, "FORBIDDEN", PresentError(ctx, err).Extensions["code"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should read the workspace from its parent object", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT role FROM workspace_members`).
+			WithArgs(4, 7).
+			WillReturnRows(sqlmock.NewRows([]string{"role"}))
+
+		_, err := r.HasRole(ctx, &Workspace{ID: "4"}, next, WorkspaceRoleViewer, "workspaceId")
+
+		assert.EqualError(t, err, "workspace not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
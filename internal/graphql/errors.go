@@ -0,0 +1,35 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/store"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// PresentError is installed as the gqlgen error presenter. It adds a code
+// extension to authentication and authorization failures, so clients can
+// tell them from other errors without parsing messages.
+func PresentError(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+	var code string
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		code = "UNAUTHENTICATED"
+	case errors.Is(err, store.ErrForbidden):
+		code = "FORBIDDEN"
+	case errors.Is(err, store.ErrLastOwner):
+		code = "LAST_OWNER"
+	default:
+		return presented
+	}
+	if presented.Extensions == nil {
+		presented.Extensions = map[string]any{}
+	}
+	presented.Extensions["code"] = code
+	return presented
+}
@@ -6,7 +6,10 @@ import (
 	"testing"
 	"time"
 
+	"go-goal/internal/store"
+
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -17,7 +20,7 @@ func TestGoalProjectResolver(t *testing.T) {
 	defer db.Close()
 
 	resolver := &goalResolver{
-		Resolver: &Resolver{DB: db},
+		Resolver: &Resolver{Store: store.New(db)},
 	}
 
 	t.Run("should return associated project when projectID exists", func(t *testing.T) {
@@ -28,12 +31,12 @@ func TestGoalProjectResolver(t *testing.T) {
 		}
 
 		rows := sqlmock.NewRows([]string{
-			"id", "title", "description", "status", "workspace_id", "created_at", "updated_at",
+			"id", "title", "description", "status", "workspace_id", "flow_id", "created_at", "updated_at",
 		}).
-			AddRow(2, "Test Project", "Description", "active", 1, time.Now(), time.Now())
+			AddRow(2, "Test Project", "Description", "active", 1, nil, time.Now(), time.Now())
 
-		mock.ExpectQuery(`SELECT id, title, description, status, workspace_id, created_at, updated_at FROM projects WHERE id = \$1`).
-			WithArgs(2).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), COALESCE\(status, ''\), workspace_id, flow_id, created_at, updated_at FROM projects WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{2})).
 			WillReturnRows(rows)
 
 		project, err := resolver.Project(context.Background(), goal)
@@ -53,11 +56,11 @@ func TestGoalProjectResolver(t *testing.T) {
 		}
 
 		rows := sqlmock.NewRows([]string{
-			"id", "title", "description", "status", "workspace_id", "created_at", "updated_at",
+			"id", "title", "description", "status", "workspace_id", "flow_id", "created_at", "updated_at",
 		})
 
-		mock.ExpectQuery(`SELECT id, title, description, status, workspace_id, created_at, updated_at FROM projects WHERE id = \$1`).
-			WithArgs(999).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), COALESCE\(status, ''\), workspace_id, flow_id, created_at, updated_at FROM projects WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{999})).
 			WillReturnRows(rows)
 
 		project, err := resolver.Project(context.Background(), goal)
@@ -75,8 +78,8 @@ func TestGoalProjectResolver(t *testing.T) {
 			ProjectID: 2,
 		}
 
-		mock.ExpectQuery(`SELECT id, title, description, status, workspace_id, created_at, updated_at FROM projects WHERE id = \$1`).
-			WithArgs(2).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), COALESCE\(status, ''\), workspace_id, flow_id, created_at, updated_at FROM projects WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{2})).
 			WillReturnError(sql.ErrConnDone)
 
 		project, err := resolver.Project(context.Background(), goal)
@@ -94,7 +97,7 @@ func TestGoalFlowResolver(t *testing.T) {
 	defer db.Close()
 
 	resolver := &goalResolver{
-		Resolver: &Resolver{DB: db},
+		Resolver: &Resolver{Store: store.New(db)},
 	}
 
 	t.Run("should return associated flow when flowID exists", func(t *testing.T) {
@@ -111,8 +114,8 @@ func TestGoalFlowResolver(t *testing.T) {
 		}).
 			AddRow(3, "Test Flow", "Description", "#FF0000", "active", nil, nil, nil, 1, time.Now(), time.Now())
 
-		mock.ExpectQuery(`SELECT id, title, description, color, status, start_date, end_date, parent_id, workspace_id, created_at, updated_at FROM flows WHERE id = \$1`).
-			WithArgs(3).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), COALESCE\(color, ''\), COALESCE\(status, ''\), start_date, end_date, parent_id, workspace_id, created_at, updated_at FROM flows WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{3})).
 			WillReturnRows(rows)
 
 		flow, err := resolver.Flow(context.Background(), goal)
@@ -151,8 +154,8 @@ func TestGoalFlowResolver(t *testing.T) {
 			"parent_id", "workspace_id", "created_at", "updated_at",
 		})
 
-		mock.ExpectQuery(`SELECT id, title, description, color, status, start_date, end_date, parent_id, workspace_id, created_at, updated_at FROM flows WHERE id = \$1`).
-			WithArgs(999).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), COALESCE\(color, ''\), COALESCE\(status, ''\), start_date, end_date, parent_id, workspace_id, created_at, updated_at FROM flows WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{999})).
 			WillReturnRows(rows)
 
 		flow, err := resolver.Flow(context.Background(), goal)
@@ -169,7 +172,7 @@ func TestGoalTasksResolver(t *testing.T) {
 	defer db.Close()
 
 	resolver := &goalResolver{
-		Resolver: &Resolver{DB: db},
+		Resolver: &Resolver{Store: store.New(db)},
 	}
 
 	t.Run("should return associated tasks ordered by priority and due date", func(t *testing.T) {
@@ -179,14 +182,14 @@ func TestGoalTasksResolver(t *testing.T) {
 		}
 
 		rows := sqlmock.NewRows([]string{
-			"id", "title", "description", "status", "priority", "due_date", 
-			"goal_id", "project_id", "context_id", "created_at", "updated_at",
+			"id", "title", "description", "goal_id", "project_id", "flow_id",
+			"status", "priority", "due_date", "created_at", "updated_at",
 		}).
-			AddRow(1, "Task 1", "Description 1", "pending", 1, nil, 1, 2, nil, time.Now(), time.Now()).
-			AddRow(2, "Task 2", "Description 2", "in_progress", 2, nil, 1, 2, nil, time.Now(), time.Now())
+			AddRow(1, "Task 1", "Description 1", 1, 2, nil, "pending", 1, nil, time.Now(), time.Now()).
+			AddRow(2, "Task 2", "Description 2", 1, 2, nil, "in_progress", 2, nil, time.Now(), time.Now())
 
-		mock.ExpectQuery(`SELECT id, title, description, status, priority, due_date, goal_id, project_id, context_id, created_at, updated_at FROM tasks WHERE goal_id = \$1 ORDER BY priority DESC, due_date ASC`).
-			WithArgs(1).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), goal_id, project_id, flow_id, COALESCE\(status, ''\), priority, due_date, created_at, updated_at FROM tasks WHERE goal_id = ANY\(\$1\) ORDER BY priority DESC, due_date ASC`).
+			WithArgs(pq.Array([]int{1})).
 			WillReturnRows(rows)
 
 		tasks, err := resolver.Tasks(context.Background(), goal)
@@ -207,12 +210,12 @@ func TestGoalTasksResolver(t *testing.T) {
 		}
 
 		rows := sqlmock.NewRows([]string{
-			"id", "title", "description", "status", "priority", "due_date", 
-			"goal_id", "project_id", "context_id", "created_at", "updated_at",
+			"id", "title", "description", "goal_id", "project_id", "flow_id",
+			"status", "priority", "due_date", "created_at", "updated_at",
 		})
 
-		mock.ExpectQuery(`SELECT id, title, description, status, priority, due_date, goal_id, project_id, context_id, created_at, updated_at FROM tasks WHERE goal_id = \$1 ORDER BY priority DESC, due_date ASC`).
-			WithArgs(1).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), goal_id, project_id, flow_id, COALESCE\(status, ''\), priority, due_date, created_at, updated_at FROM tasks WHERE goal_id = ANY\(\$1\) ORDER BY priority DESC, due_date ASC`).
+			WithArgs(pq.Array([]int{1})).
 			WillReturnRows(rows)
 
 		tasks, err := resolver.Tasks(context.Background(), goal)
@@ -229,7 +232,7 @@ func TestGoalTagsResolver(t *testing.T) {
 	defer db.Close()
 
 	resolver := &goalResolver{
-		Resolver: &Resolver{DB: db},
+		Resolver: &Resolver{Store: store.New(db)},
 	}
 
 	t.Run("should return associated tags via many-to-many relationship", func(t *testing.T) {
@@ -239,13 +242,13 @@ func TestGoalTagsResolver(t *testing.T) {
 		}
 
 		rows := sqlmock.NewRows([]string{
-			"id", "name", "color", "parent_id", "created_at",
+			"goal_id", "id", "name", "color", "parent_id", "created_at",
 		}).
-			AddRow(1, "urgent", "#FF0000", nil, time.Now()).
-			AddRow(2, "work", "#00FF00", nil, time.Now())
+			AddRow(1, 1, "urgent", "#FF0000", nil, time.Now()).
+			AddRow(1, 2, "work", "#00FF00", nil, time.Now())
 
-		mock.ExpectQuery(`SELECT t\.id, t\.name, t\.color, t\.parent_id, t\.created_at FROM tags t JOIN goal_tags gt ON t\.id = gt\.tag_id WHERE gt\.goal_id = \$1 ORDER BY t\.name`).
-			WithArgs(1).
+		mock.ExpectQuery(`SELECT et\.goal_id, t\.id, t\.name, COALESCE\(t\.color, ''\), t\.parent_id, t\.created_at FROM tags t JOIN goal_tags et ON t\.id = et\.tag_id WHERE et\.goal_id = ANY\(\$1\) ORDER BY t\.name`).
+			WithArgs(pq.Array([]int{1})).
 			WillReturnRows(rows)
 
 		tags, err := resolver.Tags(context.Background(), goal)
@@ -267,11 +270,11 @@ func TestGoalTagsResolver(t *testing.T) {
 		}
 
 		rows := sqlmock.NewRows([]string{
-			"id", "name", "color", "parent_id", "created_at",
+			"goal_id", "id", "name", "color", "parent_id", "created_at",
 		})
 
-		mock.ExpectQuery(`SELECT t\.id, t\.name, t\.color, t\.parent_id, t\.created_at FROM tags t JOIN goal_tags gt ON t\.id = gt\.tag_id WHERE gt\.goal_id = \$1 ORDER BY t\.name`).
-			WithArgs(1).
+		mock.ExpectQuery(`SELECT et\.goal_id, t\.id, t\.name, COALESCE\(t\.color, ''\), t\.parent_id, t\.created_at FROM tags t JOIN goal_tags et ON t\.id = et\.tag_id WHERE et\.goal_id = ANY\(\$1\) ORDER BY t\.name`).
+			WithArgs(pq.Array([]int{1})).
 			WillReturnRows(rows)
 
 		tags, err := resolver.Tags(context.Background(), goal)
@@ -288,7 +291,7 @@ func TestGoalNotesResolver(t *testing.T) {
 	defer db.Close()
 
 	resolver := &goalResolver{
-		Resolver: &Resolver{DB: db},
+		Resolver: &Resolver{Store: store.New(db)},
 	}
 
 	t.Run("should return associated notes filtered by entity type and id", func(t *testing.T) {
@@ -303,8 +306,8 @@ func TestGoalNotesResolver(t *testing.T) {
 			AddRow(1, "Note 1", "Content 1", "goal", 1, time.Now(), time.Now()).
 			AddRow(2, "Note 2", "Content 2", "goal", 1, time.Now(), time.Now())
 
-		mock.ExpectQuery(`SELECT id, title, content, entity_type, entity_id, created_at, updated_at FROM notes WHERE entity_type = \$1 AND entity_id = \$2 ORDER BY created_at DESC`).
-			WithArgs("goal", 1).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(content, ''\), COALESCE\(entity_type, ''\), entity_id, created_at, updated_at FROM notes WHERE entity_type = \$1 AND entity_id = ANY\(\$2\) ORDER BY created_at DESC`).
+			WithArgs("goal", pq.Array([]int{1})).
 			WillReturnRows(rows)
 
 		notes, err := resolver.Notes(context.Background(), goal)
@@ -328,8 +331,8 @@ func TestGoalNotesResolver(t *testing.T) {
 			"id", "title", "content", "entity_type", "entity_id", "created_at", "updated_at",
 		})
 
-		mock.ExpectQuery(`SELECT id, title, content, entity_type, entity_id, created_at, updated_at FROM notes WHERE entity_type = \$1 AND entity_id = \$2 ORDER BY created_at DESC`).
-			WithArgs("goal", 1).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(content, ''\), COALESCE\(entity_type, ''\), entity_id, created_at, updated_at FROM notes WHERE entity_type = \$1 AND entity_id = ANY\(\$2\) ORDER BY created_at DESC`).
+			WithArgs("goal", pq.Array([]int{1})).
 			WillReturnRows(rows)
 
 		notes, err := resolver.Notes(context.Background(), goal)
@@ -8,10 +8,13 @@ import (
 	"time"
 
 	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 func TestGoalsQuery(t *testing.T) {
@@ -20,18 +23,18 @@ func TestGoalsQuery(t *testing.T) {
 	defer db.Close()
 
 	resolver := &queryResolver{
-		Resolver: &Resolver{DB: db},
+		Resolver: &Resolver{Store: store.New(db)},
 	}
 
 	t.Run("should return all goals when projectId is nil", func(t *testing.T) {
 		rows := sqlmock.NewRows([]string{
-			"id", "title", "description", "priority", "due_date", "status", 
-			"project_id", "context_id", "created_at", "updated_at",
+			"id", "title", "description", "project_id", "flow_id", "status",
+			"priority", "due_date", "created_at", "updated_at",
 		}).
 			AddRow(1, "Test Goal 1", "Description 1", 1, nil, "active", 1, nil, time.Now(), time.Now()).
-			AddRow(2, "Test Goal 2", "Description 2", 2, nil, "active", 1, nil, time.Now(), time.Now())
+			AddRow(2, "Test Goal 2", "Description 2", 1, nil, "active", 2, nil, time.Now(), time.Now())
 
-		mock.ExpectQuery(`SELECT id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at FROM goals ORDER BY priority DESC, due_date ASC`).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), project_id, flow_id, COALESCE\(status, ''\), priority, due_date, created_at, updated_at FROM goals ORDER BY priority DESC, due_date ASC`).
 			WillReturnRows(rows)
 
 		goals, err := resolver.Goals(context.Background(), nil)
@@ -46,12 +49,12 @@ func TestGoalsQuery(t *testing.T) {
 	t.Run("should filter goals by projectId when provided", func(t *testing.T) {
 		projectId := 1
 		rows := sqlmock.NewRows([]string{
-			"id", "title", "description", "priority", "due_date", "status", 
-			"project_id", "context_id", "created_at", "updated_at",
+			"id", "title", "description", "project_id", "flow_id", "status",
+			"priority", "due_date", "created_at", "updated_at",
 		}).
 			AddRow(1, "Project Goal", "Description", 1, nil, "active", 1, nil, time.Now(), time.Now())
 
-		mock.ExpectQuery(`SELECT id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at FROM goals WHERE \(\$1 IS NULL OR project_id = \$1\) ORDER BY priority DESC, due_date ASC`).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), project_id, flow_id, COALESCE\(status, ''\), priority, due_date, created_at, updated_at FROM goals WHERE project_id = \$1 ORDER BY priority DESC, due_date ASC`).
 			WithArgs(projectId).
 			WillReturnRows(rows)
 
@@ -65,7 +68,7 @@ func TestGoalsQuery(t *testing.T) {
 	})
 
 	t.Run("should handle database errors gracefully", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at FROM goals ORDER BY priority DESC, due_date ASC`).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), project_id, flow_id, COALESCE\(status, ''\), priority, due_date, created_at, updated_at FROM goals ORDER BY priority DESC, due_date ASC`).
 			WillReturnError(sql.ErrConnDone)
 
 		goals, err := resolver.Goals(context.Background(), nil)
@@ -78,11 +81,11 @@ func TestGoalsQuery(t *testing.T) {
 
 	t.Run("should return empty array when no goals found", func(t *testing.T) {
 		rows := sqlmock.NewRows([]string{
-			"id", "title", "description", "priority", "due_date", "status", 
-			"project_id", "context_id", "created_at", "updated_at",
+			"id", "title", "description", "project_id", "flow_id", "status",
+			"priority", "due_date", "created_at", "updated_at",
 		})
 
-		mock.ExpectQuery(`SELECT id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at FROM goals ORDER BY priority DESC, due_date ASC`).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), project_id, flow_id, COALESCE\(status, ''\), priority, due_date, created_at, updated_at FROM goals ORDER BY priority DESC, due_date ASC`).
 			WillReturnRows(rows)
 
 		goals, err := resolver.Goals(context.Background(), nil)
@@ -99,19 +102,19 @@ func TestGoalQuery(t *testing.T) {
 	defer db.Close()
 
 	resolver := &queryResolver{
-		Resolver: &Resolver{DB: db},
+		Resolver: &Resolver{Store: store.New(db)},
 	}
 
 	t.Run("should return goal when valid ID provided", func(t *testing.T) {
 		goalId := "1"
 		now := time.Now()
 		rows := sqlmock.NewRows([]string{
-			"id", "title", "description", "priority", "due_date", "status", 
-			"project_id", "context_id", "created_at", "updated_at",
+			"id", "title", "description", "project_id", "flow_id", "status",
+			"priority", "due_date", "created_at", "updated_at",
 		}).
 			AddRow(1, "Test Goal", "Test Description", 1, nil, "active", 1, nil, now, now)
 
-		mock.ExpectQuery(`SELECT id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at FROM goals WHERE id = \$1`).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), project_id, flow_id, COALESCE\(status, ''\), priority, due_date, created_at, updated_at FROM goals WHERE id = \$1`).
 			WithArgs(1).
 			WillReturnRows(rows)
 
@@ -130,11 +133,11 @@ func TestGoalQuery(t *testing.T) {
 	t.Run("should return nil when goal not found", func(t *testing.T) {
 		goalId := "999"
 		rows := sqlmock.NewRows([]string{
-			"id", "title", "description", "priority", "due_date", "status", 
-			"project_id", "context_id", "created_at", "updated_at",
+			"id", "title", "description", "project_id", "flow_id", "status",
+			"priority", "due_date", "created_at", "updated_at",
 		})
 
-		mock.ExpectQuery(`SELECT id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at FROM goals WHERE id = \$1`).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), project_id, flow_id, COALESCE\(status, ''\), priority, due_date, created_at, updated_at FROM goals WHERE id = \$1`).
 			WithArgs(999).
 			WillReturnRows(rows)
 
@@ -158,7 +161,7 @@ func TestGoalQuery(t *testing.T) {
 	t.Run("should handle database errors gracefully", func(t *testing.T) {
 		goalId := "1"
 
-		mock.ExpectQuery(`SELECT id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at FROM goals WHERE id = \$1`).
+		mock.ExpectQuery(`SELECT id, title, COALESCE\(description, ''\), project_id, flow_id, COALESCE\(status, ''\), priority, due_date, created_at, updated_at FROM goals WHERE id = \$1`).
 			WithArgs(1).
 			WillReturnError(sql.ErrConnDone)
 
@@ -190,18 +193,7 @@ func TestGoalConversion(t *testing.T) {
 			UpdatedAt:   now,
 		}
 
-		gqlGoal := &Goal{
-			ID:          strconv.Itoa(modelGoal.ID),
-			Title:       modelGoal.Title,
-			Description: &modelGoal.Description,
-			Priority:    "3",
-			DueDate:     modelGoal.DueDate,
-			Status:      modelGoal.Status,
-			ProjectID:   *modelGoal.ProjectID,
-			FlowID:      modelGoal.FlowID,
-			CreatedAt:   modelGoal.CreatedAt,
-			UpdatedAt:   modelGoal.UpdatedAt,
-		}
+		gqlGoal := toGoal(&modelGoal)
 
 		assert.Equal(t, strconv.Itoa(modelGoal.ID), gqlGoal.ID)
 		assert.Equal(t, modelGoal.Title, gqlGoal.Title)
@@ -231,21 +223,97 @@ func TestGoalConversion(t *testing.T) {
 			UpdatedAt:   now,
 		}
 
-		gqlGoal := &Goal{
-			ID:          strconv.Itoa(modelGoal.ID),
-			Title:       modelGoal.Title,
-			Description: &modelGoal.Description,
-			Priority:    "1",
-			DueDate:     modelGoal.DueDate,
-			Status:      modelGoal.Status,
-			ProjectID:   *modelGoal.ProjectID,
-			FlowID:      modelGoal.FlowID,
-			CreatedAt:   modelGoal.CreatedAt,
-			UpdatedAt:   modelGoal.UpdatedAt,
-		}
+		gqlGoal := toGoal(&modelGoal)
 
 		assert.Equal(t, "", *gqlGoal.Description)
 		assert.Nil(t, gqlGoal.DueDate)
 		assert.Nil(t, gqlGoal.FlowID)
 	})
-}
\ No newline at end of file
+}
+func TestCreateGoalValidation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	resolver := &mutationResolver{
+		Resolver: &Resolver{Store: store.New(db)},
+	}
+
+	t.Run("should reject invalid input with field errors", func(t *testing.T) {
+		goal, err := resolver.CreateGoal(context.Background(), CreateGoalInput{
+			Title:     "Ship it",
+			Priority:  "7",
+			Status:    "someday",
+			ProjectID: 1,
+		})
+
+		assert.Nil(t, goal)
+		var gqlErr *gqlerror.Error
+		require.ErrorAs(t, err, &gqlErr)
+		assert.Equal(t, "VALIDATION_FAILED", gqlErr.Extensions["code"])
+		fields := gqlErr.Extensions["errors"].([]validation.FieldError)
+		require.Len(t, fields, 2)
+		assert.Equal(t, "status", fields[0].Field)
+		assert.Equal(t, "priority", fields[1].Field)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject non-numeric priorities", func(t *testing.T) {
+		_, err := resolver.CreateGoal(context.Background(), CreateGoalInput{
+			Title:     "Ship it",
+			Priority:  "high",
+			Status:    "active",
+			ProjectID: 1,
+		})
+
+		var gqlErr *gqlerror.Error
+		require.ErrorAs(t, err, &gqlErr)
+		assert.Equal(t, []validation.FieldError{{Field: "priority", Code: "invalid_type", Message: "must be an integer"}}, gqlErr.Extensions["errors"])
+	})
+}
+
+func TestCreateGoalWarnings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	resolver := &mutationResolver{
+		Resolver: &Resolver{Store: store.New(db)},
+	}
+	flowColumns := []string{"id", "title", "description", "color", "status", "start_date", "end_date", "parent_id", "workspace_id", "created_at", "updated_at"}
+
+	t.Run("should return the rules the new goal breaks", func(t *testing.T) {
+		flowID := 1
+		due := time.Date(2024, 3, 5, 23, 0, 0, 0, time.UTC)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO goals`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(5, time.Now(), time.Now()))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`FROM flows WHERE id = ANY\(\$1\)`).
+			WillReturnRows(sqlmock.NewRows(flowColumns).
+				AddRow(1, "Work", "", "", "active", nil, nil, nil, 1, time.Now(), time.Now()))
+		mock.ExpectQuery(`FROM flow_relationships`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "flow_id", "related_flow_id", "relationship_type", "created_at"}).
+				AddRow(1, 2, 1, "conflicts_with", time.Now()))
+		mock.ExpectQuery(`FROM flows WHERE id = ANY\(\$1\)`).
+			WillReturnRows(sqlmock.NewRows(flowColumns).
+				AddRow(2, "Sleep", "", "", "active", nil, nil, nil, 1, time.Now(), time.Now()))
+		mock.ExpectQuery(`FROM flow_constraints c`).
+			WillReturnRows(sqlmock.NewRows([]string{"flow_id", "quiet_start", "quiet_end", "max_weekly_tasks", "forbidden_tags"}).
+				AddRow(2, "22:00", "07:00", nil, "{}"))
+
+		goal, err := resolver.CreateGoal(context.Background(), CreateGoalInput{
+			Title:     "Ship it",
+			Priority:  "3",
+			Status:    "active",
+			ProjectID: 1,
+			FlowID:    &flowID,
+			DueDate:   &due,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, goal.FlowWarnings, 1)
+		assert.Equal(t, 2, goal.FlowWarnings[0].FlowID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
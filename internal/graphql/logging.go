@@ -0,0 +1,58 @@
+package graphql
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go-goal/internal/logging"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// OperationLogger is a gqlgen extension logging one line per executed
+// operation through the request logger, so GraphQL activity carries the
+// same request ID as the HTTP request log. Operations that returned errors
+// are logged at warn level with the error messages.
+type OperationLogger struct{}
+
+var (
+	_ graphql.HandlerExtension    = OperationLogger{}
+	_ graphql.ResponseInterceptor = OperationLogger{}
+)
+
+func (OperationLogger) ExtensionName() string {
+	return "OperationLogger"
+}
+
+func (OperationLogger) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (OperationLogger) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	start := time.Now()
+	resp := next(ctx)
+	if !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	attrs := []slog.Attr{
+		slog.String("operation", oc.OperationName),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+	}
+	if oc.Operation != nil {
+		attrs = append(attrs, slog.String("type", string(oc.Operation.Operation)))
+	}
+	level := slog.LevelInfo
+	if resp != nil && len(resp.Errors) > 0 {
+		level = slog.LevelWarn
+		messages := make([]string, len(resp.Errors))
+		for i, err := range resp.Errors {
+			messages[i] = err.Message
+		}
+		attrs = append(attrs, slog.Any("errors", messages))
+	}
+	logging.FromContext(ctx).LogAttrs(ctx, level, "graphql operation", attrs...)
+	return resp
+}
@@ -0,0 +1,41 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"go-goal/internal/logging"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestOperationLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info", "json")
+	require.NoError(t, err)
+
+	ctx := logging.WithRequest(context.Background(), logger, "req-1")
+	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{
+		OperationName: "Dashboard",
+		Operation:     &ast.OperationDefinition{Operation: ast.Query},
+	})
+
+	OperationLogger{}.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("goal not found")}}
+	})
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "WARN", line["level"])
+	assert.Equal(t, "graphql operation", line["msg"])
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "Dashboard", line["operation"])
+	assert.Equal(t, "query", line["type"])
+	assert.Equal(t, []any{"goal not found"}, line["errors"])
+}
@@ -0,0 +1,109 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is a gqlgen extension recording executed operations by name and
+// type, with their duration, query complexity and error count. Clients
+// choose operation names, so only the names given to NewMetrics are used as
+// labels; other named operations are reported as "other" and unnamed ones as
+// "anonymous".
+type Metrics struct {
+	schema     graphql.ExecutableSchema
+	names      map[string]bool
+	operations *prometheus.CounterVec
+	errors     *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+	complexity *prometheus.HistogramVec
+}
+
+var (
+	_ graphql.HandlerExtension    = &Metrics{}
+	_ graphql.ResponseInterceptor = &Metrics{}
+)
+
+// NewMetrics returns the extension with its collectors registered on reg,
+// labelling the given operation names.
+func NewMetrics(reg prometheus.Registerer, operations ...string) *Metrics {
+	m := &Metrics{
+		names: make(map[string]bool, len(operations)),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "gogoal",
+			Name:      "graphql_operations_total",
+			Help:      "GraphQL operations by name and type.",
+		}, []string{"operation", "type"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "gogoal",
+			Name:      "graphql_errors_total",
+			Help:      "Errors returned in GraphQL responses by operation name.",
+		}, []string{"operation"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "gogoal",
+			Name:      "graphql_operation_duration_seconds",
+			Help:      "GraphQL operation latency by name and type.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "type"}),
+		complexity: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "gogoal",
+			Name:      "graphql_operation_complexity",
+			Help:      "Query complexity of GraphQL operations by name.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		}, []string{"operation"}),
+	}
+	for _, name := range operations {
+		m.names[name] = true
+	}
+	reg.MustRegister(m.operations, m.errors, m.duration, m.complexity)
+	return m
+}
+
+func (m *Metrics) ExtensionName() string {
+	return "Metrics"
+}
+
+func (m *Metrics) Validate(schema graphql.ExecutableSchema) error {
+	m.schema = schema
+	return nil
+}
+
+func (m *Metrics) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	start := time.Now()
+	resp := next(ctx)
+	if !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	name := m.label(oc.OperationName)
+	opType := ""
+	if oc.Operation != nil {
+		opType = string(oc.Operation.Operation)
+		if m.schema != nil {
+			m.complexity.WithLabelValues(name).Observe(float64(complexity.Calculate(ctx, m.schema, oc.Operation, oc.Variables)))
+		}
+	}
+	m.operations.WithLabelValues(name, opType).Inc()
+	m.duration.WithLabelValues(name, opType).Observe(time.Since(start).Seconds())
+	if resp != nil && len(resp.Errors) > 0 {
+		m.errors.WithLabelValues(name).Add(float64(len(resp.Errors)))
+	}
+	return resp
+}
+
+// label bounds the operation label to the known names.
+func (m *Metrics) label(name string) string {
+	switch {
+	case name == "":
+		return "anonymous"
+	case m.names[name]:
+		return name
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,44 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestMetrics(t *testing.T) {
+	schema := NewExecutableSchema(Config{Resolvers: &Resolver{}})
+	m := NewMetrics(prometheus.NewRegistry(), "Workspaces")
+	require.NoError(t, m.Validate(schema))
+
+	run := func(name, query string, errs gqlerror.List) {
+		doc, err := gqlparser.LoadQuery(schema.Schema(), query)
+		require.Nil(t, err)
+		ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+			OperationName: name,
+			Operation:     doc.Operations[0],
+		})
+		m.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			return &graphql.Response{Errors: errs}
+		})
+	}
+
+	run("Workspaces", "query Workspaces { workspaces { id name } }", nil)
+	run("Random1234", "query Random1234 { workspaces { id } }", nil)
+	run("", "{ workspaces { id } }", gqlerror.List{gqlerror.Errorf("boom"), gqlerror.Errorf("bang")})
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.operations.WithLabelValues("Workspaces", "query")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.operations.WithLabelValues("anonymous", "query")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.operations.WithLabelValues("other", "query")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.errors.WithLabelValues("anonymous")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.errors.WithLabelValues("Workspaces")))
+	assert.Equal(t, 3, testutil.CollectAndCount(m.operations))
+	assert.Equal(t, 3, testutil.CollectAndCount(m.complexity))
+}
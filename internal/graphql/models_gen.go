@@ -3,26 +3,26 @@
 package graphql
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 )
 
-type Flow struct {
-	ID          string     `json:"id"`
-	Title       string     `json:"title"`
-	Description *string    `json:"description,omitempty"`
-	Color       string     `json:"color"`
-	Status      string     `json:"status"`
-	StartDate   *time.Time `json:"startDate,omitempty"`
-	EndDate     *time.Time `json:"endDate,omitempty"`
-	ParentID    *int       `json:"parentId,omitempty"`
-	WorkspaceID int        `json:"workspaceId"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
-	Parent      *Flow      `json:"parent,omitempty"`
-	Children    []*Flow    `json:"children,omitempty"`
-	Projects    []*Project `json:"projects,omitempty"`
-	Goals       []*Goal    `json:"goals,omitempty"`
-	Tasks       []*Task    `json:"tasks,omitempty"`
+type AuditEvent struct {
+	ID                  string    `json:"id"`
+	ActorID             *int      `json:"actorId,omitempty"`
+	ActorEmail          *string   `json:"actorEmail,omitempty"`
+	WorkspaceID         *int      `json:"workspaceId,omitempty"`
+	PreviousWorkspaceID *int      `json:"previousWorkspaceId,omitempty"`
+	EntityType          string    `json:"entityType"`
+	EntityID            int       `json:"entityId"`
+	Action              string    `json:"action"`
+	Before              *string   `json:"before,omitempty"`
+	After               *string   `json:"after,omitempty"`
+	RequestID           *string   `json:"requestId,omitempty"`
+	CreatedAt           time.Time `json:"createdAt"`
 }
 
 type CreateFlowInput struct {
@@ -62,20 +62,20 @@ type CreateProjectInput struct {
 }
 
 type CreateTagInput struct {
-	Name     string  `json:"name"`
-	Color    string  `json:"color"`
-	ParentID *int    `json:"parentId,omitempty"`
+	Name     string `json:"name"`
+	Color    string `json:"color"`
+	ParentID *int   `json:"parentId,omitempty"`
 }
 
 type CreateTaskInput struct {
-	Title     string     `json:"title"`
-	Description *string  `json:"description,omitempty"`
-	Status    string     `json:"status"`
-	Priority  string     `json:"priority"`
-	DueDate   *time.Time `json:"dueDate,omitempty"`
-	GoalID    *int       `json:"goalId,omitempty"`
-	ProjectID int        `json:"projectId"`
-	FlowID    *int       `json:"flowId,omitempty"`
+	Title       string     `json:"title"`
+	Description *string    `json:"description,omitempty"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	GoalID      *int       `json:"goalId,omitempty"`
+	ProjectID   int        `json:"projectId"`
+	FlowID      *int       `json:"flowId,omitempty"`
 }
 
 type CreateWorkspaceInput struct {
@@ -84,92 +84,249 @@ type CreateWorkspaceInput struct {
 }
 
 type Dashboard struct {
-	TodayTasks      []*Task          `json:"todayTasks"`
-	RecentProjects  []*Project       `json:"recentProjects"`
-	UpcomingGoals   []*Goal          `json:"upcomingGoals"`
-	WorkspaceStats  *WorkspaceStats  `json:"workspaceStats"`
+	TodayTasks     []*Task         `json:"todayTasks"`
+	RecentProjects []*Project      `json:"recentProjects"`
+	UpcomingGoals  []*Goal         `json:"upcomingGoals"`
+	WorkspaceStats *WorkspaceStats `json:"workspaceStats"`
+}
+
+type Flow struct {
+	ID            string              `json:"id"`
+	Title         string              `json:"title"`
+	Description   *string             `json:"description,omitempty"`
+	Color         string              `json:"color"`
+	Status        string              `json:"status"`
+	StartDate     *time.Time          `json:"startDate,omitempty"`
+	EndDate       *time.Time          `json:"endDate,omitempty"`
+	ParentID      *int                `json:"parentId,omitempty"`
+	WorkspaceID   int                 `json:"workspaceId"`
+	CreatedAt     time.Time           `json:"createdAt"`
+	UpdatedAt     time.Time           `json:"updatedAt"`
+	Parent        *Flow               `json:"parent,omitempty"`
+	Children      []*Flow             `json:"children,omitempty"`
+	Stats         *FlowStats          `json:"stats"`
+	Projects      []*Project          `json:"projects,omitempty"`
+	Goals         []*Goal             `json:"goals,omitempty"`
+	Tasks         []*Task             `json:"tasks,omitempty"`
+	Relationships []*FlowRelationship `json:"relationships"`
+	Constraints   *FlowConstraints    `json:"constraints"`
+	Transitions   []*FlowTransition   `json:"transitions"`
+}
+
+type FlowConnection struct {
+	Edges    []*FlowEdge `json:"edges"`
+	PageInfo *PageInfo   `json:"pageInfo"`
+}
+
+type FlowConstraints struct {
+	FlowID         int      `json:"flowId"`
+	QuietStart     *string  `json:"quietStart,omitempty"`
+	QuietEnd       *string  `json:"quietEnd,omitempty"`
+	MaxWeeklyTasks *int     `json:"maxWeeklyTasks,omitempty"`
+	ForbiddenTags  []string `json:"forbiddenTags"`
+}
+
+type FlowConstraintsInput struct {
+	QuietStart     *string  `json:"quietStart,omitempty"`
+	QuietEnd       *string  `json:"quietEnd,omitempty"`
+	MaxWeeklyTasks *int     `json:"maxWeeklyTasks,omitempty"`
+	ForbiddenTags  []string `json:"forbiddenTags,omitempty"`
+}
+
+type FlowEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Flow  `json:"node"`
+}
+
+type FlowRelationship struct {
+	ID            string               `json:"id"`
+	Type          FlowRelationshipType `json:"type"`
+	FlowID        int                  `json:"flowId"`
+	RelatedFlowID int                  `json:"relatedFlowId"`
+	Flow          *Flow                `json:"flow,omitempty"`
+	RelatedFlow   *Flow                `json:"relatedFlow,omitempty"`
+	CreatedAt     time.Time            `json:"createdAt"`
+}
+
+type FlowStats struct {
+	TotalProjects         int     `json:"totalProjects"`
+	ActiveProjects        int     `json:"activeProjects"`
+	TotalGoals            int     `json:"totalGoals"`
+	CompletedGoals        int     `json:"completedGoals"`
+	TotalTasks            int     `json:"totalTasks"`
+	CompletedTasks        int     `json:"completedTasks"`
+	PendingTasks          int     `json:"pendingTasks"`
+	GoalCompletionPercent float64 `json:"goalCompletionPercent"`
+	TaskCompletionPercent float64 `json:"taskCompletionPercent"`
+}
+
+type FlowTimeline struct {
+	From  time.Time       `json:"from"`
+	To    time.Time       `json:"to"`
+	Weeks []*time.Time    `json:"weeks"`
+	Flows []*TimelineFlow `json:"flows"`
+}
+
+type FlowTransition struct {
+	ID         string    `json:"id"`
+	FlowID     int       `json:"flowId"`
+	FromStatus string    `json:"fromStatus"`
+	ToStatus   string    `json:"toStatus"`
+	Reason     *string   `json:"reason,omitempty"`
+	ActorID    *int      `json:"actorId,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type FlowWarning struct {
+	Rule          string  `json:"rule"`
+	FlowID        int     `json:"flowId"`
+	FlowTitle     string  `json:"flowTitle"`
+	RelatedFlowID *int    `json:"relatedFlowId,omitempty"`
+	EntityType    *string `json:"entityType,omitempty"`
+	EntityID      *int    `json:"entityId,omitempty"`
+	Message       string  `json:"message"`
 }
 
 type Goal struct {
-	ID          string     `json:"id"`
-	Title       string     `json:"title"`
-	Description *string    `json:"description,omitempty"`
-	Priority    string     `json:"priority"`
-	DueDate     *time.Time `json:"dueDate,omitempty"`
-	Status      string     `json:"status"`
-	ProjectID   int        `json:"projectId"`
-	FlowID      *int       `json:"flowId,omitempty"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
-	Project     *Project   `json:"project"`
-	Tasks       []*Task    `json:"tasks,omitempty"`
-	Notes       []*Note    `json:"notes,omitempty"`
-	Tags        []*Tag     `json:"tags,omitempty"`
-	Flow        *Flow      `json:"flow,omitempty"`
+	ID           string         `json:"id"`
+	Title        string         `json:"title"`
+	Description  *string        `json:"description,omitempty"`
+	Priority     string         `json:"priority"`
+	DueDate      *time.Time     `json:"dueDate,omitempty"`
+	Status       string         `json:"status"`
+	ProjectID    int            `json:"projectId"`
+	FlowID       *int           `json:"flowId,omitempty"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	Project      *Project       `json:"project"`
+	Tasks        []*Task        `json:"tasks,omitempty"`
+	Notes        []*Note        `json:"notes,omitempty"`
+	Tags         []*Tag         `json:"tags,omitempty"`
+	Flow         *Flow          `json:"flow,omitempty"`
+	FlowWarnings []*FlowWarning `json:"flowWarnings,omitempty"`
+}
+
+type GoalConnection struct {
+	Edges    []*GoalEdge `json:"edges"`
+	PageInfo *PageInfo   `json:"pageInfo"`
+}
+
+type GoalEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Goal  `json:"node"`
+}
+
+type Mutation struct {
 }
 
 type Note struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	EntityType string   `json:"entityType"`
-	EntityID  int       `json:"entityId"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Tags      []*Tag    `json:"tags,omitempty"`
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	EntityType string    `json:"entityType"`
+	EntityID   int       `json:"entityId"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	Tags       []*Tag    `json:"tags,omitempty"`
+}
+
+type NoteConnection struct {
+	Edges    []*NoteEdge `json:"edges"`
+	PageInfo *PageInfo   `json:"pageInfo"`
+}
+
+type NoteEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Note  `json:"node"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor,omitempty"`
+	EndCursor       *string `json:"endCursor,omitempty"`
 }
 
 type Project struct {
-	ID          string     `json:"id"`
-	Title       string     `json:"title"`
-	Description *string    `json:"description,omitempty"`
-	Status      string     `json:"status"`
-	WorkspaceID int        `json:"workspaceId"`
-	FlowID      *int       `json:"flowId,omitempty"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
-	Goals       []*Goal    `json:"goals,omitempty"`
-	Tasks       []*Task    `json:"tasks,omitempty"`
-	Notes       []*Note    `json:"notes,omitempty"`
-	Tags        []*Tag     `json:"tags,omitempty"`
-	Flow        *Flow      `json:"flow,omitempty"`
+	ID           string         `json:"id"`
+	Title        string         `json:"title"`
+	Description  *string        `json:"description,omitempty"`
+	Status       string         `json:"status"`
+	WorkspaceID  int            `json:"workspaceId"`
+	FlowID       *int           `json:"flowId,omitempty"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	Goals        []*Goal        `json:"goals,omitempty"`
+	Tasks        []*Task        `json:"tasks,omitempty"`
+	Notes        []*Note        `json:"notes,omitempty"`
+	Tags         []*Tag         `json:"tags,omitempty"`
+	Flow         *Flow          `json:"flow,omitempty"`
+	FlowWarnings []*FlowWarning `json:"flowWarnings,omitempty"`
+}
+
+type ProjectConnection struct {
+	Edges    []*ProjectEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+type ProjectEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Project `json:"node"`
 }
 
 type Query struct {
 }
 
 type Tag struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Color     string    `json:"color"`
-	ParentID  *int      `json:"parentId,omitempty"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Parent    *Tag      `json:"parent,omitempty"`
-	Children  []*Tag    `json:"children,omitempty"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Color     string     `json:"color"`
+	ParentID  *int       `json:"parentId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	Parent    *Tag       `json:"parent,omitempty"`
+	Children  []*Tag     `json:"children,omitempty"`
 	Projects  []*Project `json:"projects,omitempty"`
-	Goals     []*Goal   `json:"goals,omitempty"`
-	Tasks     []*Task   `json:"tasks,omitempty"`
-	Notes     []*Note   `json:"notes,omitempty"`
+	Goals     []*Goal    `json:"goals,omitempty"`
+	Tasks     []*Task    `json:"tasks,omitempty"`
+	Notes     []*Note    `json:"notes,omitempty"`
 }
 
 type Task struct {
-	ID          string     `json:"id"`
-	Title       string     `json:"title"`
-	Description *string    `json:"description,omitempty"`
-	Status      string     `json:"status"`
-	Priority    string     `json:"priority"`
-	DueDate     *time.Time `json:"dueDate,omitempty"`
-	GoalID      *int       `json:"goalId,omitempty"`
-	ProjectID   int        `json:"projectId"`
-	FlowID      *int       `json:"flowId,omitempty"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
-	Goal        *Goal      `json:"goal,omitempty"`
-	Project     *Project   `json:"project"`
-	Notes       []*Note    `json:"notes,omitempty"`
-	Tags        []*Tag     `json:"tags,omitempty"`
-	Flow        *Flow      `json:"flow,omitempty"`
+	ID           string         `json:"id"`
+	Title        string         `json:"title"`
+	Description  *string        `json:"description,omitempty"`
+	Status       string         `json:"status"`
+	Priority     string         `json:"priority"`
+	DueDate      *time.Time     `json:"dueDate,omitempty"`
+	GoalID       *int           `json:"goalId,omitempty"`
+	ProjectID    int            `json:"projectId"`
+	FlowID       *int           `json:"flowId,omitempty"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	Goal         *Goal          `json:"goal,omitempty"`
+	Project      *Project       `json:"project"`
+	Notes        []*Note        `json:"notes,omitempty"`
+	Tags         []*Tag         `json:"tags,omitempty"`
+	Flow         *Flow          `json:"flow,omitempty"`
+	FlowWarnings []*FlowWarning `json:"flowWarnings,omitempty"`
+}
+
+type TaskConnection struct {
+	Edges    []*TaskEdge `json:"edges"`
+	PageInfo *PageInfo   `json:"pageInfo"`
+}
+
+type TaskEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Task  `json:"node"`
+}
+
+type TimelineFlow struct {
+	Flow           *Flow        `json:"flow"`
+	Lane           int          `json:"lane"`
+	OverlapGroup   int          `json:"overlapGroup"`
+	CompletedTasks []*WeekCount `json:"completedTasks"`
 }
 
 type UpdateFlowInput struct {
@@ -184,13 +341,13 @@ type UpdateFlowInput struct {
 }
 
 type UpdateGoalInput struct {
-	Title     *string    `json:"title,omitempty"`
-	Description *string  `json:"description,omitempty"`
-	Priority  *string    `json:"priority,omitempty"`
-	DueDate   *time.Time `json:"dueDate,omitempty"`
-	Status    *string    `json:"status,omitempty"`
-	ProjectID *int       `json:"projectId,omitempty"`
-	FlowID    *int       `json:"flowId,omitempty"`
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Priority    *string    `json:"priority,omitempty"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	Status      *string    `json:"status,omitempty"`
+	ProjectID   *int       `json:"projectId,omitempty"`
+	FlowID      *int       `json:"flowId,omitempty"`
 }
 
 type UpdateNoteInput struct {
@@ -213,14 +370,14 @@ type UpdateTagInput struct {
 }
 
 type UpdateTaskInput struct {
-	Title     *string    `json:"title,omitempty"`
-	Description *string  `json:"description,omitempty"`
-	Status    *string    `json:"status,omitempty"`
-	Priority  *string    `json:"priority,omitempty"`
-	DueDate   *time.Time `json:"dueDate,omitempty"`
-	GoalID    *int       `json:"goalId,omitempty"`
-	ProjectID *int       `json:"projectId,omitempty"`
-	FlowID    *int       `json:"flowId,omitempty"`
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Status      *string    `json:"status,omitempty"`
+	Priority    *string    `json:"priority,omitempty"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	GoalID      *int       `json:"goalId,omitempty"`
+	ProjectID   *int       `json:"projectId,omitempty"`
+	FlowID      *int       `json:"flowId,omitempty"`
 }
 
 type UpdateWorkspaceInput struct {
@@ -228,14 +385,35 @@ type UpdateWorkspaceInput struct {
 	Description *string `json:"description,omitempty"`
 }
 
+type User struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type WeekCount struct {
+	Week  time.Time `json:"week"`
+	Count int       `json:"count"`
+}
+
 type Workspace struct {
-	ID          string     `json:"id"`
-	Name        string     `json:"name"`
-	Description *string    `json:"description,omitempty"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
-	Projects    []*Project `json:"projects,omitempty"`
-	Flows       []*Flow    `json:"flows,omitempty"`
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description *string            `json:"description,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+	Projects    []*Project         `json:"projects,omitempty"`
+	Flows       []*Flow            `json:"flows,omitempty"`
+	Members     []*WorkspaceMember `json:"members"`
+}
+
+type WorkspaceMember struct {
+	UserID    int           `json:"userId"`
+	Email     string        `json:"email"`
+	Name      string        `json:"name"`
+	Role      WorkspaceRole `json:"role"`
+	CreatedAt time.Time     `json:"createdAt"`
 }
 
 type WorkspaceStats struct {
@@ -245,3 +423,121 @@ type WorkspaceStats struct {
 	CompletedTasks int `json:"completedTasks"`
 	PendingTasks   int `json:"pendingTasks"`
 }
+
+type FlowRelationshipType string
+
+const (
+	FlowRelationshipTypeSupports      FlowRelationshipType = "SUPPORTS"
+	FlowRelationshipTypeConflictsWith FlowRelationshipType = "CONFLICTS_WITH"
+	FlowRelationshipTypePrecedes      FlowRelationshipType = "PRECEDES"
+	FlowRelationshipTypeOverlaps      FlowRelationshipType = "OVERLAPS"
+)
+
+var AllFlowRelationshipType = []FlowRelationshipType{
+	FlowRelationshipTypeSupports,
+	FlowRelationshipTypeConflictsWith,
+	FlowRelationshipTypePrecedes,
+	FlowRelationshipTypeOverlaps,
+}
+
+func (e FlowRelationshipType) IsValid() bool {
+	switch e {
+	case FlowRelationshipTypeSupports, FlowRelationshipTypeConflictsWith, FlowRelationshipTypePrecedes, FlowRelationshipTypeOverlaps:
+		return true
+	}
+	return false
+}
+
+func (e FlowRelationshipType) String() string {
+	return string(e)
+}
+
+func (e *FlowRelationshipType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FlowRelationshipType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FlowRelationshipType", str)
+	}
+	return nil
+}
+
+func (e FlowRelationshipType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *FlowRelationshipType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e FlowRelationshipType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleViewer    WorkspaceRole = "VIEWER"
+	WorkspaceRoleCommenter WorkspaceRole = "COMMENTER"
+	WorkspaceRoleEditor    WorkspaceRole = "EDITOR"
+	WorkspaceRoleOwner     WorkspaceRole = "OWNER"
+)
+
+var AllWorkspaceRole = []WorkspaceRole{
+	WorkspaceRoleViewer,
+	WorkspaceRoleCommenter,
+	WorkspaceRoleEditor,
+	WorkspaceRoleOwner,
+}
+
+func (e WorkspaceRole) IsValid() bool {
+	switch e {
+	case WorkspaceRoleViewer, WorkspaceRoleCommenter, WorkspaceRoleEditor, WorkspaceRoleOwner:
+		return true
+	}
+	return false
+}
+
+func (e WorkspaceRole) String() string {
+	return string(e)
+}
+
+func (e *WorkspaceRole) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WorkspaceRole(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WorkspaceRole", str)
+	}
+	return nil
+}
+
+func (e WorkspaceRole) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *WorkspaceRole) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e WorkspaceRole) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,69 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+
+	"go-goal/internal/store"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageArgs converts Relay connection arguments into a store.Page. Without
+// first or last the first defaultPageSize rows are returned; larger requests
+// are capped at maxPageSize.
+func pageArgs(first *int, after *string, last *int, before *string) (store.Page, error) {
+	if first != nil && last != nil {
+		return store.Page{}, errors.New("first and last cannot be used together")
+	}
+
+	page := store.Page{Limit: defaultPageSize}
+	switch {
+	case first != nil:
+		page.Limit = *first
+	case last != nil:
+		page.Limit = *last
+		page.Backward = true
+	}
+	if page.Limit < 0 {
+		return store.Page{}, errors.New("first and last must not be negative")
+	}
+	page.Limit = min(page.Limit, maxPageSize)
+
+	var err error
+	if after != nil {
+		if page.After, err = store.ParseCursor(*after); err != nil {
+			return store.Page{}, fmt.Errorf("%w %q", err, *after)
+		}
+	}
+	if before != nil {
+		if page.Before, err = store.ParseCursor(*before); err != nil {
+			return store.Page{}, fmt.Errorf("%w %q", err, *before)
+		}
+	}
+	return page, nil
+}
+
+// newPageInfo builds the PageInfo for a page whose edges carry cursors. more
+// reports whether the store found rows beyond the page in the direction of
+// travel; the opposite direction is assumed to continue when a cursor was
+// given for it.
+func newPageInfo(page store.Page, more bool, cursors []store.Cursor) *PageInfo {
+	info := &PageInfo{}
+	if page.Backward {
+		info.HasPreviousPage = more
+		info.HasNextPage = page.Before != nil
+	} else {
+		info.HasNextPage = more
+		info.HasPreviousPage = page.After != nil
+	}
+	if len(cursors) > 0 {
+		start, end := cursors[0].String(), cursors[len(cursors)-1].String()
+		info.StartCursor = &start
+		info.EndCursor = &end
+	}
+	return info
+}
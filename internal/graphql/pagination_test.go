@@ -0,0 +1,90 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-goal/internal/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(i int) *int { return &i }
+
+func TestPageArgs(t *testing.T) {
+	t.Run("should default to the first page", func(t *testing.T) {
+		page, err := pageArgs(nil, nil, nil, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, store.Page{Limit: defaultPageSize}, page)
+	})
+
+	t.Run("should cap the page size", func(t *testing.T) {
+		page, err := pageArgs(nil, nil, intPtr(500), nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, maxPageSize, page.Limit)
+		assert.True(t, page.Backward)
+	})
+
+	t.Run("should reject first combined with last", func(t *testing.T) {
+		_, err := pageArgs(intPtr(1), nil, intPtr(1), nil)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject malformed cursors", func(t *testing.T) {
+		bad := "not a cursor"
+		_, err := pageArgs(intPtr(1), &bad, nil, nil)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should decode store cursors", func(t *testing.T) {
+		after := store.Cursor{Sort: "-created_at", Key: "2025-03-04T05:06:07.000008Z", ID: 42}.String()
+
+		page, err := pageArgs(intPtr(10), &after, nil, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, &store.Cursor{Sort: "-created_at", Key: "2025-03-04T05:06:07.000008Z", ID: 42}, page.After)
+	})
+}
+
+func TestTasksConnection(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	resolver := &queryResolver{
+		Resolver: &Resolver{Store: store.New(db)},
+	}
+
+	t.Run("should return edges with cursors and page info", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "description", "goal_id", "project_id", "flow_id",
+			"status", "priority", "due_date", "created_at", "updated_at",
+		}).
+			AddRow(3, "Task 3", "", nil, 1, nil, "pending", 5, nil, time.Now(), time.Now()).
+			AddRow(2, "Task 2", "", nil, 1, nil, "pending", 3, nil, time.Now(), time.Now())
+
+		mock.ExpectQuery(`FROM tasks ORDER BY priority DESC, id DESC LIMIT 2`).
+			WillReturnRows(rows)
+
+		conn, err := resolver.TasksConnection(context.Background(), nil, nil, nil, intPtr(1), nil, nil, nil)
+
+		require.NoError(t, err)
+		require.Len(t, conn.Edges, 1)
+		assert.Equal(t, "Task 3", conn.Edges[0].Node.Title)
+		assert.True(t, conn.PageInfo.HasNextPage)
+		assert.False(t, conn.PageInfo.HasPreviousPage)
+		assert.Equal(t, conn.Edges[0].Cursor, *conn.PageInfo.EndCursor)
+
+		cursor, err := store.ParseCursor(conn.Edges[0].Cursor)
+		require.NoError(t, err)
+		assert.Equal(t, &store.Cursor{Sort: "-priority", Key: "5", ID: 3}, cursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
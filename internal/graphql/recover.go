@@ -0,0 +1,24 @@
+package graphql
+
+import (
+	"context"
+	"runtime/debug"
+
+	"go-goal/internal/logging"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Recover is installed as the gqlgen recover func. A panicking resolver is
+// logged with its stack and reported to the client as a GraphQL error on the
+// offending field instead of aborting the whole response.
+func Recover(ctx context.Context, p any) error {
+	logging.FromContext(ctx).Error("graphql: recovered from panic", "panic", p, "stack", string(debug.Stack()))
+
+	err := gqlerror.Errorf("internal server error")
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		err.Path = fc.Path()
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestRecover(t *testing.T) {
+	t.Run("should return a GraphQL error without a field context", func(t *testing.T) {
+		err := Recover(context.Background(), "not implemented")
+
+		var gqlErr *gqlerror.Error
+		require.ErrorAs(t, err, &gqlErr)
+		assert.Equal(t, "internal server error", gqlErr.Message)
+		assert.Nil(t, gqlErr.Path)
+	})
+
+	t.Run("should attach the path of the panicking field", func(t *testing.T) {
+		ctx := graphql.WithFieldContext(context.Background(), &graphql.FieldContext{
+			Field: graphql.CollectedField{Field: &ast.Field{Alias: "tasks"}},
+		})
+
+		err := Recover(ctx, "not implemented")
+
+		var gqlErr *gqlerror.Error
+		require.ErrorAs(t, err, &gqlErr)
+		assert.Equal(t, ast.Path{ast.PathName("tasks")}, gqlErr.Path)
+	})
+}
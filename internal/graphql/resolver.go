@@ -1,7 +1,106 @@
 package graphql
 
-import "database/sql"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go-goal/internal/conflicts"
+	"go-goal/internal/loader"
+	"go-goal/internal/logging"
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
 
 type Resolver struct {
-	DB *sql.DB
+	Store     *store.Store
+	Validator *validation.Validator
+	Conflicts *conflicts.Engine
+}
+
+// loaders returns the batching loaders for the current request. Resolvers
+// invoked outside loader.Middleware, such as in tests, get an unshared set.
+func (r *Resolver) loaders(ctx context.Context) *loader.Loaders {
+	if l := loader.For(ctx); l != nil {
+		return l
+	}
+	return loader.NewLoaders(r.Store)
+}
+
+// validator returns the configured Validator, or one reading through the
+// resolver's store when none was set.
+func (r *Resolver) validator() *validation.Validator {
+	if r.Validator != nil {
+		return r.Validator
+	}
+	return validation.New(r.Store)
+}
+
+// conflicts returns the configured conflicts engine, or one reading through
+// the resolver's store when none was set.
+func (r *Resolver) conflicts() *conflicts.Engine {
+	if r.Conflicts != nil {
+		return r.Conflicts
+	}
+	return conflicts.New(r.Store)
+}
+
+// flowWarnings evaluates the rules of active flows against s for the
+// response of the mutation that wrote it. A failure to evaluate them is
+// logged rather than failing the write that already succeeded.
+func (r *Resolver) flowWarnings(ctx context.Context, s conflicts.Subject) []*FlowWarning {
+	warnings, err := r.conflicts().Check(ctx, s)
+	if err != nil {
+		logging.FromContext(ctx).Error("flow rules failed", "entity_type", s.EntityType, "entity_id", s.ID, "error", err)
+		return nil
+	}
+	return toFlowWarnings(warnings)
+}
+
+// transitionFlow moves the flow with id to status to, as the lifecycle
+// mutations ask.
+func (r *Resolver) transitionFlow(ctx context.Context, id, to string, reason *string) (*Flow, error) {
+	flowID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flow ID: %w", err)
+	}
+
+	t := models.FlowTransition{FlowID: flowID, ToStatus: to}
+	if reason != nil {
+		t.Reason = *reason
+	}
+	if err := r.validator().FlowTransition.Validate(ctx, &t); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	f, err := r.Store.Flows.Transition(ctx, flowID, t.ToStatus, t.Reason)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("flow not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update flow status: %w", err)
+	}
+
+	return toFlow(f), nil
+}
+
+// invalidInput turns validation failures into a GraphQL error carrying the
+// same field errors the REST API reports, under the VALIDATION_FAILED code.
+// Other errors are returned unchanged.
+func invalidInput(err error) error {
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	return &gqlerror.Error{
+		Message: err.Error(),
+		Extensions: map[string]any{
+			"code":   "VALIDATION_FAILED",
+			"errors": []validation.FieldError(errs),
+		},
+	}
 }
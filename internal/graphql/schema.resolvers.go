@@ -6,38 +6,281 @@ package graphql
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
+	"go-goal/internal/auth"
+	"go-goal/internal/conflicts"
+	"go-goal/internal/loader"
 	"go-goal/internal/models"
+	"go-goal/internal/store"
+	"go-goal/internal/validation"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// Parent is the resolver for the parent field.
+func (r *flowResolver) Parent(ctx context.Context, obj *Flow) (*Flow, error) {
+	if obj.ParentID == nil {
+		return nil, nil
+	}
+
+	f, err := r.loaders(ctx).Flow.Load(ctx, *obj.ParentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch parent flow: %w", err)
+	}
+	if f == nil {
+		return nil, nil
+	}
+
+	return toFlow(f), nil
+}
+
+// Children is the resolver for the children field.
+func (r *flowResolver) Children(ctx context.Context, obj *Flow) ([]*Flow, error) {
+	flowID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flow ID: %w", err)
+	}
+
+	flows, err := r.loaders(ctx).FlowsByParent.Load(ctx, flowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch child flows: %w", err)
+	}
+
+	return toFlows(flows), nil
+}
+
+// Stats is the resolver for the stats field.
+func (r *flowResolver) Stats(ctx context.Context, obj *Flow) (*FlowStats, error) {
+	flowID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flow ID: %w", err)
+	}
+
+	stats, err := r.loaders(ctx).StatsByFlow.Load(ctx, flowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flow stats: %w", err)
+	}
+
+	return toFlowStats(&stats), nil
+}
+
+// Projects is the resolver for the projects field.
+func (r *flowResolver) Projects(ctx context.Context, obj *Flow) ([]*Project, error) {
+	flowID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flow ID: %w", err)
+	}
+
+	projects, err := r.loaders(ctx).ProjectsByFlow.Load(ctx, flowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
+	}
+
+	return toProjects(projects), nil
+}
+
+// Goals is the resolver for the goals field.
+func (r *flowResolver) Goals(ctx context.Context, obj *Flow) ([]*Goal, error) {
+	flowID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flow ID: %w", err)
+	}
+
+	goals, err := r.loaders(ctx).GoalsByFlow.Load(ctx, flowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch goals: %w", err)
+	}
+
+	return toGoals(goals), nil
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *flowResolver) Tasks(ctx context.Context, obj *Flow) ([]*Task, error) {
+	flowID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flow ID: %w", err)
+	}
+
+	tasks, err := r.loaders(ctx).TasksByFlow.Load(ctx, flowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tasks: %w", err)
+	}
+
+	return toTasks(tasks), nil
+}
+
+// Relationships is the resolver for the relationships field.
+func (r *flowResolver) Relationships(ctx context.Context, obj *Flow) ([]*FlowRelationship, error) {
+	flowID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flow ID: %w", err)
+	}
+
+	relationships, err := r.loaders(ctx).RelationshipsByFlow.Load(ctx, flowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flow relationships: %w", err)
+	}
+
+	return toFlowRelationships(relationships), nil
+}
+
+// Constraints is the resolver for the constraints field.
+func (r *flowResolver) Constraints(ctx context.Context, obj *Flow) (*FlowConstraints, error) {
+	flowID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flow ID: %w", err)
+	}
+
+	c, err := r.Store.Flows.GetConstraints(ctx, flowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flow constraints: %w", err)
+	}
+
+	return toFlowConstraints(c), nil
+}
+
+// Transitions is the resolver for the transitions field.
+func (r *flowResolver) Transitions(ctx context.Context, obj *Flow) ([]*FlowTransition, error) {
+	flowID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flow ID: %w", err)
+	}
+
+	transitions, err := r.Store.Flows.ListTransitions(ctx, flowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flow transitions: %w", err)
+	}
+
+	return toFlowTransitions(transitions), nil
+}
+
+// Flow is the resolver for the flow field.
+func (r *flowRelationshipResolver) Flow(ctx context.Context, obj *FlowRelationship) (*Flow, error) {
+	f, err := r.loaders(ctx).Flow.Load(ctx, obj.FlowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flow: %w", err)
+	}
+	if f == nil {
+		return nil, nil
+	}
+
+	return toFlow(f), nil
+}
+
+// RelatedFlow is the resolver for the relatedFlow field.
+func (r *flowRelationshipResolver) RelatedFlow(ctx context.Context, obj *FlowRelationship) (*Flow, error) {
+	f, err := r.loaders(ctx).Flow.Load(ctx, obj.RelatedFlowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch related flow: %w", err)
+	}
+	if f == nil {
+		return nil, nil
+	}
+
+	return toFlow(f), nil
+}
+
+// Project is the resolver for the project field.
+func (r *goalResolver) Project(ctx context.Context, obj *Goal) (*Project, error) {
+	p, err := r.loaders(ctx).Project.Load(ctx, obj.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+	if p == nil {
+		return nil, fmt.Errorf("project with ID %d not found for goal %s", obj.ProjectID, obj.ID)
+	}
+
+	return toProject(p), nil
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *goalResolver) Tasks(ctx context.Context, obj *Goal) ([]*Task, error) {
+	goalID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid goal ID: %w", err)
+	}
+
+	tasks, err := r.loaders(ctx).TasksByGoal.Load(ctx, goalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tasks: %w", err)
+	}
+
+	return toTasks(tasks), nil
+}
+
+// Notes is the resolver for the notes field.
+func (r *goalResolver) Notes(ctx context.Context, obj *Goal) ([]*Note, error) {
+	goalID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid goal ID: %w", err)
+	}
+
+	notes, err := r.loaders(ctx).NotesByEntity.Load(ctx, loader.Entity{Type: "goal", ID: goalID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notes: %w", err)
+	}
+
+	return toNotes(notes), nil
+}
+
+// Tags is the resolver for the tags field.
+func (r *goalResolver) Tags(ctx context.Context, obj *Goal) ([]*Tag, error) {
+	goalID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid goal ID: %w", err)
+	}
+
+	tags, err := r.loaders(ctx).TagsByEntity.Load(ctx, loader.Entity{Type: "goal", ID: goalID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+
+	return toTags(tags), nil
+}
+
+// Flow is the resolver for the flow field.
+func (r *goalResolver) Flow(ctx context.Context, obj *Goal) (*Flow, error) {
+	if obj.FlowID == nil {
+		return nil, nil
+	}
+
+	f, err := r.loaders(ctx).Flow.Load(ctx, *obj.FlowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flow: %w", err)
+	}
+	if f == nil {
+		return nil, nil
+	}
+
+	return toFlow(f), nil
+}
+
 // CreateProject is the resolver for the createProject field.
 func (r *mutationResolver) CreateProject(ctx context.Context, input CreateProjectInput) (*Project, error) {
-	var p models.Project
-	now := time.Now()
+	p := models.Project{
+		Title:       input.Title,
+		Status:      input.Status,
+		WorkspaceID: &input.WorkspaceID,
+		FlowID:      input.FlowID,
+	}
+	if input.Description != nil {
+		p.Description = *input.Description
+	}
 
-	err := r.DB.QueryRow(`
-		INSERT INTO projects (title, description, status, workspace_id, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4, $5, $6) 
-		RETURNING id, title, description, status, workspace_id, created_at, updated_at
-	`, input.Title, input.Description, input.Status, input.WorkspaceID, now, now).Scan(
-		&p.ID, &p.Title, &p.Description, &p.Status, &p.WorkspaceID, &p.CreatedAt, &p.UpdatedAt)
+	validation.Defaults(&p)
+	if err := r.validator().Project.Validate(ctx, &p); err != nil {
+		return nil, invalidInput(err)
+	}
 
-	if err != nil {
+	if err := r.Store.Projects.Create(ctx, &p); err != nil {
 		return nil, fmt.Errorf("failed to create project: %w", err)
 	}
 
-	return &Project{
-		ID:          strconv.Itoa(p.ID),
-		Title:       p.Title,
-		Description: &p.Description,
-		Status:      p.Status,
-		WorkspaceID: *p.WorkspaceID,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
-	}, nil
+	out := toProject(&p)
+	out.FlowWarnings = r.flowWarnings(ctx, conflicts.ProjectSubject(&p))
+	return out, nil
 }
 
 // UpdateProject is the resolver for the updateProject field.
@@ -47,55 +290,48 @@ func (r *mutationResolver) UpdateProject(ctx context.Context, id string, input U
 		return nil, fmt.Errorf("invalid project ID: %w", err)
 	}
 
-	// Build dynamic update query
-	query := "UPDATE projects SET updated_at = $1"
-	args := []interface{}{time.Now()}
-	argIndex := 2
+	p, err := r.Store.Projects.Get(ctx, projectID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("project not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+	before := *p
 
 	if input.Title != nil {
-		query += fmt.Sprintf(", title = $%d", argIndex)
-		args = append(args, *input.Title)
-		argIndex++
+		p.Title = *input.Title
 	}
 	if input.Description != nil {
-		query += fmt.Sprintf(", description = $%d", argIndex)
-		args = append(args, *input.Description)
-		argIndex++
+		p.Description = *input.Description
 	}
 	if input.Status != nil {
-		query += fmt.Sprintf(", status = $%d", argIndex)
-		args = append(args, *input.Status)
-		argIndex++
+		p.Status = *input.Status
 	}
 	if input.WorkspaceID != nil {
-		query += fmt.Sprintf(", workspace_id = $%d", argIndex)
-		args = append(args, *input.WorkspaceID)
-		argIndex++
+		p.WorkspaceID = input.WorkspaceID
+	}
+	if input.FlowID != nil {
+		p.FlowID = input.FlowID
 	}
 
-	query += fmt.Sprintf(" WHERE id = $%d RETURNING id, title, description, status, workspace_id, created_at, updated_at", argIndex)
-	args = append(args, projectID)
-
-	var p models.Project
-	err = r.DB.QueryRow(query, args...).Scan(
-		&p.ID, &p.Title, &p.Description, &p.Status, &p.WorkspaceID, &p.CreatedAt, &p.UpdatedAt)
+	if err := r.validator().Project.Validate(ctx, p); err != nil {
+		return nil, invalidInput(err)
+	}
 
-	if err == sql.ErrNoRows {
+	err = r.Store.Projects.Update(ctx, p)
+	if errors.Is(err, store.ErrNotFound) {
 		return nil, fmt.Errorf("project not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update project: %w", err)
 	}
 
-	return &Project{
-		ID:          strconv.Itoa(p.ID),
-		Title:       p.Title,
-		Description: &p.Description,
-		Status:      p.Status,
-		WorkspaceID: *p.WorkspaceID,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
-	}, nil
+	out := toProject(p)
+	if conflicts.ProjectMoved(&before, p) {
+		out.FlowWarnings = r.flowWarnings(ctx, conflicts.ProjectSubject(p))
+	}
+	return out, nil
 }
 
 // DeleteProject is the resolver for the deleteProject field.
@@ -105,46 +341,48 @@ func (r *mutationResolver) DeleteProject(ctx context.Context, id string) (bool,
 		return false, fmt.Errorf("invalid project ID: %w", err)
 	}
 
-	result, err := r.DB.Exec("DELETE FROM projects WHERE id = $1", projectID)
+	err = r.Store.Projects.Delete(ctx, projectID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("project not found")
+	}
 	if err != nil {
 		return false, fmt.Errorf("failed to delete project: %w", err)
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return false, fmt.Errorf("project not found")
-	}
-
 	return true, nil
 }
 
 // CreateGoal is the resolver for the createGoal field.
 func (r *mutationResolver) CreateGoal(ctx context.Context, input CreateGoalInput) (*Goal, error) {
-	var g models.Goal
-	now := time.Now()
+	priority, err := parsePriority(input.Priority)
+	if err != nil {
+		return nil, err
+	}
+
+	g := models.Goal{
+		Title:     input.Title,
+		ProjectID: &input.ProjectID,
+		FlowID:    input.FlowID,
+		Status:    input.Status,
+		Priority:  priority,
+		DueDate:   input.DueDate,
+	}
+	if input.Description != nil {
+		g.Description = *input.Description
+	}
 
-	err := r.DB.QueryRow(`
-		INSERT INTO goals (title, description, priority, due_date, status, project_id, flow_id, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
-		RETURNING id, title, description, priority, due_date, status, project_id, flow_id, created_at, updated_at
-	`, input.Title, input.Description, input.Priority, input.DueDate, input.Status, input.ProjectID, input.FlowID, now, now).Scan(
-		&g.ID, &g.Title, &g.Description, &g.Priority, &g.DueDate, &g.Status, &g.ProjectID, &g.FlowID, &g.CreatedAt, &g.UpdatedAt)
+	validation.Defaults(&g)
+	if err := r.validator().Goal.Validate(ctx, &g); err != nil {
+		return nil, invalidInput(err)
+	}
 
-	if err != nil {
+	if err := r.Store.Goals.Create(ctx, &g); err != nil {
 		return nil, fmt.Errorf("failed to create goal: %w", err)
 	}
 
-	return &Goal{
-		ID:          strconv.Itoa(g.ID),
-		Title:       g.Title,
-		Description: &g.Description,
-		Priority:    fmt.Sprintf("%d", g.Priority),
-		DueDate:     g.DueDate,
-		Status:      g.Status,
-		ProjectID:   *g.ProjectID,
-		CreatedAt:   g.CreatedAt,
-		UpdatedAt:   g.UpdatedAt,
-	}, nil
+	out := toGoal(&g)
+	out.FlowWarnings = r.flowWarnings(ctx, conflicts.GoalSubject(&g))
+	return out, nil
 }
 
 // UpdateGoal is the resolver for the updateGoal field.
@@ -154,67 +392,56 @@ func (r *mutationResolver) UpdateGoal(ctx context.Context, id string, input Upda
 		return nil, fmt.Errorf("invalid goal ID: %w", err)
 	}
 
-	// Build dynamic update query
-	query := "UPDATE goals SET updated_at = $1"
-	args := []interface{}{time.Now()}
-	argIndex := 2
+	g, err := r.Store.Goals.Get(ctx, goalID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("goal not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch goal: %w", err)
+	}
+	before := *g
 
 	if input.Title != nil {
-		query += fmt.Sprintf(", title = $%d", argIndex)
-		args = append(args, *input.Title)
-		argIndex++
+		g.Title = *input.Title
 	}
 	if input.Description != nil {
-		query += fmt.Sprintf(", description = $%d", argIndex)
-		args = append(args, *input.Description)
-		argIndex++
+		g.Description = *input.Description
 	}
 	if input.Priority != nil {
-		query += fmt.Sprintf(", priority = $%d", argIndex)
-		args = append(args, *input.Priority)
-		argIndex++
+		if g.Priority, err = parsePriority(*input.Priority); err != nil {
+			return nil, err
+		}
 	}
 	if input.Status != nil {
-		query += fmt.Sprintf(", status = $%d", argIndex)
-		args = append(args, *input.Status)
-		argIndex++
+		g.Status = *input.Status
 	}
 	if input.DueDate != nil {
-		query += fmt.Sprintf(", due_date = $%d", argIndex)
-		args = append(args, *input.DueDate)
-		argIndex++
+		g.DueDate = input.DueDate
 	}
 	if input.ProjectID != nil {
-		query += fmt.Sprintf(", project_id = $%d", argIndex)
-		args = append(args, *input.ProjectID)
-		argIndex++
+		g.ProjectID = input.ProjectID
+	}
+	if input.FlowID != nil {
+		g.FlowID = input.FlowID
 	}
 
-	query += fmt.Sprintf(" WHERE id = $%d RETURNING id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at", argIndex)
-	args = append(args, goalID)
-
-	var g models.Goal
-	err = r.DB.QueryRow(query, args...).Scan(
-		&g.ID, &g.Title, &g.Description, &g.Priority, &g.DueDate, &g.Status, &g.ProjectID, &g.FlowID, &g.CreatedAt, &g.UpdatedAt)
+	if err := r.validator().Goal.Validate(ctx, g); err != nil {
+		return nil, invalidInput(err)
+	}
 
-	if err == sql.ErrNoRows {
+	err = r.Store.Goals.Update(ctx, g)
+	if errors.Is(err, store.ErrNotFound) {
 		return nil, fmt.Errorf("goal not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update goal: %w", err)
 	}
 
-	return &Goal{
-		ID:          strconv.Itoa(g.ID),
-		Title:       g.Title,
-		Description: &g.Description,
-		Priority:    fmt.Sprintf("%d", g.Priority),
-		DueDate:     g.DueDate,
-		Status:      g.Status,
-		ProjectID:   *g.ProjectID,
-		CreatedAt:   g.CreatedAt,
-		UpdatedAt:   g.UpdatedAt,
-	}, nil
+	out := toGoal(g)
+	if conflicts.GoalMoved(&before, g) {
+		out.FlowWarnings = r.flowWarnings(ctx, conflicts.GoalSubject(g))
+	}
+	return out, nil
 }
 
 // DeleteGoal is the resolver for the deleteGoal field.
@@ -224,652 +451,1373 @@ func (r *mutationResolver) DeleteGoal(ctx context.Context, id string) (bool, err
 		return false, fmt.Errorf("invalid goal ID: %w", err)
 	}
 
-	result, err := r.DB.Exec("DELETE FROM goals WHERE id = $1", goalID)
+	err = r.Store.Goals.Delete(ctx, goalID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("goal not found")
+	}
 	if err != nil {
 		return false, fmt.Errorf("failed to delete goal: %w", err)
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return false, fmt.Errorf("goal not found")
-	}
-
 	return true, nil
 }
 
 // CreateTask is the resolver for the createTask field.
 func (r *mutationResolver) CreateTask(ctx context.Context, input CreateTaskInput) (*Task, error) {
-	panic(fmt.Errorf("not implemented: CreateTask - createTask"))
+	priority, err := parsePriority(input.Priority)
+	if err != nil {
+		return nil, err
+	}
+
+	t := models.Task{
+		Title:     input.Title,
+		GoalID:    input.GoalID,
+		ProjectID: &input.ProjectID,
+		FlowID:    input.FlowID,
+		Status:    input.Status,
+		Priority:  priority,
+		DueDate:   input.DueDate,
+	}
+	if input.Description != nil {
+		t.Description = *input.Description
+	}
+
+	validation.Defaults(&t)
+	if err := r.validator().Task.Validate(ctx, &t); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	if err := r.Store.Tasks.Create(ctx, &t); err != nil {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+
+	out := toTask(&t)
+	out.FlowWarnings = r.flowWarnings(ctx, conflicts.TaskSubject(&t))
+	return out, nil
 }
 
 // UpdateTask is the resolver for the updateTask field.
 func (r *mutationResolver) UpdateTask(ctx context.Context, id string, input UpdateTaskInput) (*Task, error) {
-	panic(fmt.Errorf("not implemented: UpdateTask - updateTask"))
+	taskID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task ID: %w", err)
+	}
+
+	t, err := r.Store.Tasks.Get(ctx, taskID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("task not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch task: %w", err)
+	}
+	before := *t
+
+	if input.Title != nil {
+		t.Title = *input.Title
+	}
+	if input.Description != nil {
+		t.Description = *input.Description
+	}
+	if input.Status != nil {
+		t.Status = *input.Status
+	}
+	if input.Priority != nil {
+		if t.Priority, err = parsePriority(*input.Priority); err != nil {
+			return nil, err
+		}
+	}
+	if input.DueDate != nil {
+		t.DueDate = input.DueDate
+	}
+	if input.GoalID != nil {
+		t.GoalID = input.GoalID
+	}
+	if input.ProjectID != nil {
+		t.ProjectID = input.ProjectID
+	}
+	if input.FlowID != nil {
+		t.FlowID = input.FlowID
+	}
+
+	if err := r.validator().Task.Validate(ctx, t); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	err = r.Store.Tasks.Update(ctx, t)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("task not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	out := toTask(t)
+	if conflicts.TaskMoved(&before, t) {
+		out.FlowWarnings = r.flowWarnings(ctx, conflicts.TaskSubject(t))
+	}
+	return out, nil
 }
 
 // DeleteTask is the resolver for the deleteTask field.
 func (r *mutationResolver) DeleteTask(ctx context.Context, id string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteTask - deleteTask"))
+	taskID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid task ID: %w", err)
+	}
+
+	err = r.Store.Tasks.Delete(ctx, taskID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("task not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete task: %w", err)
+	}
+
+	return true, nil
 }
 
 // CreateTag is the resolver for the createTag field.
 func (r *mutationResolver) CreateTag(ctx context.Context, input CreateTagInput) (*Tag, error) {
-	panic(fmt.Errorf("not implemented: CreateTag - createTag"))
-}
+	t := models.Tag{
+		Name:     input.Name,
+		Color:    input.Color,
+		ParentID: input.ParentID,
+	}
 
-// UpdateTag is the resolver for the updateTag field.
-func (r *mutationResolver) UpdateTag(ctx context.Context, id string, input UpdateTagInput) (*Tag, error) {
-	panic(fmt.Errorf("not implemented: UpdateTag - updateTag"))
+	validation.Defaults(&t)
+	if err := r.validator().Tag.Validate(ctx, &t); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	if err := r.Store.Tags.Create(ctx, &t); err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return toTag(&t), nil
+}
+
+// UpdateTag is the resolver for the updateTag field.
+func (r *mutationResolver) UpdateTag(ctx context.Context, id string, input UpdateTagInput) (*Tag, error) {
+	tagID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag ID: %w", err)
+	}
+
+	t, err := r.Store.Tags.Get(ctx, tagID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("tag not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tag: %w", err)
+	}
+
+	if input.Name != nil {
+		t.Name = *input.Name
+	}
+	if input.Color != nil {
+		t.Color = *input.Color
+	}
+	if input.ParentID != nil {
+		t.ParentID = input.ParentID
+	}
+
+	if err := r.validator().Tag.Validate(ctx, t); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	err = r.Store.Tags.Update(ctx, t)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("tag not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	return toTag(t), nil
 }
 
 // DeleteTag is the resolver for the deleteTag field.
 func (r *mutationResolver) DeleteTag(ctx context.Context, id string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteTag - deleteTag"))
+	tagID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid tag ID: %w", err)
+	}
+
+	err = r.Store.Tags.Delete(ctx, tagID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("tag not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	return true, nil
 }
 
 // CreateNote is the resolver for the createNote field.
 func (r *mutationResolver) CreateNote(ctx context.Context, input CreateNoteInput) (*Note, error) {
-	panic(fmt.Errorf("not implemented: CreateNote - createNote"))
+	n := models.Note{
+		Title:      input.Title,
+		Content:    input.Content,
+		EntityType: input.EntityType,
+		EntityID:   &input.EntityID,
+	}
+
+	validation.Defaults(&n)
+	if err := r.validator().Note.Validate(ctx, &n); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	if err := r.Store.Notes.Create(ctx, &n); err != nil {
+		return nil, fmt.Errorf("failed to create note: %w", err)
+	}
+
+	return toNote(&n), nil
 }
 
 // UpdateNote is the resolver for the updateNote field.
 func (r *mutationResolver) UpdateNote(ctx context.Context, id string, input UpdateNoteInput) (*Note, error) {
-	panic(fmt.Errorf("not implemented: UpdateNote - updateNote"))
+	noteID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	n, err := r.Store.Notes.Get(ctx, noteID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("note not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch note: %w", err)
+	}
+
+	if input.Title != nil {
+		n.Title = *input.Title
+	}
+	if input.Content != nil {
+		n.Content = *input.Content
+	}
+
+	if err := r.validator().Note.Validate(ctx, n); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	err = r.Store.Notes.Update(ctx, n)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("note not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update note: %w", err)
+	}
+
+	return toNote(n), nil
 }
 
 // DeleteNote is the resolver for the deleteNote field.
 func (r *mutationResolver) DeleteNote(ctx context.Context, id string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteNote - deleteNote"))
+	noteID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	err = r.Store.Notes.Delete(ctx, noteID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("note not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete note: %w", err)
+	}
+
+	return true, nil
 }
 
 // CreateWorkspace is the resolver for the createWorkspace field.
 func (r *mutationResolver) CreateWorkspace(ctx context.Context, input CreateWorkspaceInput) (*Workspace, error) {
-	panic(fmt.Errorf("not implemented: CreateWorkspace - createWorkspace"))
+	ws := models.Workspace{Name: input.Name}
+	if input.Description != nil {
+		ws.Description = *input.Description
+	}
+
+	validation.Defaults(&ws)
+	if err := r.validator().Workspace.Validate(ctx, &ws); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	if err := r.Store.Workspaces.Create(ctx, &ws); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	return toWorkspace(&ws), nil
 }
 
 // UpdateWorkspace is the resolver for the updateWorkspace field.
 func (r *mutationResolver) UpdateWorkspace(ctx context.Context, id string, input UpdateWorkspaceInput) (*Workspace, error) {
-	panic(fmt.Errorf("not implemented: UpdateWorkspace - updateWorkspace"))
+	workspaceID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace ID: %w", err)
+	}
+
+	ws, err := r.Store.Workspaces.Get(ctx, workspaceID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("workspace not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workspace: %w", err)
+	}
+
+	if input.Name != nil {
+		ws.Name = *input.Name
+	}
+	if input.Description != nil {
+		ws.Description = *input.Description
+	}
+
+	if err := r.validator().Workspace.Validate(ctx, ws); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	err = r.Store.Workspaces.Update(ctx, ws)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("workspace not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update workspace: %w", err)
+	}
+
+	return toWorkspace(ws), nil
 }
 
 // DeleteWorkspace is the resolver for the deleteWorkspace field.
 func (r *mutationResolver) DeleteWorkspace(ctx context.Context, id string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteWorkspace - deleteWorkspace"))
+	workspaceID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid workspace ID: %w", err)
+	}
+
+	err = r.Store.Workspaces.Delete(ctx, workspaceID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("workspace not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete workspace: %w", err)
+	}
+
+	return true, nil
+}
+
+// AddWorkspaceMember is the resolver for the addWorkspaceMember field.
+func (r *mutationResolver) AddWorkspaceMember(ctx context.Context, workspaceID int, email string, role WorkspaceRole) (*WorkspaceMember, error) {
+	u, err := r.Store.Users.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("no user has this email")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	m := models.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      u.ID,
+		Role:        fromWorkspaceRole(role),
+		Email:       u.Email,
+		Name:        u.Name,
+	}
+	if err := r.validator().Member.Validate(ctx, &m); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	err = r.Store.Members.Add(ctx, &m)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("workspace not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+
+	return toWorkspaceMember(&m), nil
+}
+
+// UpdateWorkspaceMember is the resolver for the updateWorkspaceMember field.
+func (r *mutationResolver) UpdateWorkspaceMember(ctx context.Context, workspaceID int, userID int, role WorkspaceRole) (*WorkspaceMember, error) {
+	u, err := r.Store.Users.Get(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("member not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	m := models.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Role:        fromWorkspaceRole(role),
+		Email:       u.Email,
+		Name:        u.Name,
+	}
+	if err := r.validator().Member.Validate(ctx, &m); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	err = r.Store.Members.Update(ctx, &m)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("member not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update member: %w", err)
+	}
+
+	return toWorkspaceMember(&m), nil
+}
+
+// RemoveWorkspaceMember is the resolver for the removeWorkspaceMember field.
+func (r *mutationResolver) RemoveWorkspaceMember(ctx context.Context, workspaceID int, userID int) (bool, error) {
+	err := r.Store.Members.Remove(ctx, workspaceID, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("member not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to remove member: %w", err)
+	}
+
+	return true, nil
 }
 
 // CreateFlow is the resolver for the createFlow field.
 func (r *mutationResolver) CreateFlow(ctx context.Context, input CreateFlowInput) (*Flow, error) {
-	panic(fmt.Errorf("not implemented: CreateFlow - createFlow"))
+	f := models.Flow{
+		Title:       input.Title,
+		Color:       input.Color,
+		Status:      input.Status,
+		StartDate:   input.StartDate,
+		EndDate:     input.EndDate,
+		ParentID:    input.ParentID,
+		WorkspaceID: input.WorkspaceID,
+	}
+	if input.Description != nil {
+		f.Description = *input.Description
+	}
+
+	validation.Defaults(&f)
+	if err := r.validator().Flow.Validate(ctx, &f); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	if err := r.Store.Flows.Create(ctx, &f); err != nil {
+		return nil, fmt.Errorf("failed to create flow: %w", err)
+	}
+
+	return toFlow(&f), nil
 }
 
 // UpdateFlow is the resolver for the updateFlow field.
 func (r *mutationResolver) UpdateFlow(ctx context.Context, id string, input UpdateFlowInput) (*Flow, error) {
-	panic(fmt.Errorf("not implemented: UpdateFlow - updateFlow"))
+	flowID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flow ID: %w", err)
+	}
+
+	f, err := r.Store.Flows.Get(ctx, flowID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("flow not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flow: %w", err)
+	}
+
+	if input.Title != nil {
+		f.Title = *input.Title
+	}
+	if input.Description != nil {
+		f.Description = *input.Description
+	}
+	if input.Color != nil {
+		f.Color = *input.Color
+	}
+	if input.Status != nil {
+		f.Status = *input.Status
+	}
+	if input.StartDate != nil {
+		f.StartDate = input.StartDate
+	}
+	if input.EndDate != nil {
+		f.EndDate = input.EndDate
+	}
+	if input.ParentID != nil {
+		f.ParentID = input.ParentID
+	}
+	if input.WorkspaceID != nil {
+		f.WorkspaceID = *input.WorkspaceID
+	}
+
+	if err := r.validator().Flow.Validate(ctx, f); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	err = r.Store.Flows.Update(ctx, f)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("flow not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update flow: %w", err)
+	}
+
+	return toFlow(f), nil
+}
+
+// DeleteFlow is the resolver for the deleteFlow field.
+func (r *mutationResolver) DeleteFlow(ctx context.Context, id string) (bool, error) {
+	flowID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid flow ID: %w", err)
+	}
+
+	err = r.Store.Flows.Delete(ctx, flowID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("flow not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete flow: %w", err)
+	}
+
+	return true, nil
 }
 
-// DeleteFlow is the resolver for the deleteFlow field.
-func (r *mutationResolver) DeleteFlow(ctx context.Context, id string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteFlow - deleteFlow"))
+// CreateFlowRelationship is the resolver for the createFlowRelationship field.
+func (r *mutationResolver) CreateFlowRelationship(ctx context.Context, flowID int, relatedFlowID int, typeArg FlowRelationshipType) (*FlowRelationship, error) {
+	rel := models.FlowRelationship{FlowID: flowID, RelatedFlowID: relatedFlowID, Type: fromFlowRelationshipType(typeArg)}
+	if err := r.validator().FlowRelationship.Validate(ctx, &rel); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	err := r.Store.Flows.CreateRelationship(ctx, &rel)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("flow not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create flow relationship: %w", err)
+	}
+
+	return toFlowRelationship(&rel), nil
+}
+
+// DeleteFlowRelationship is the resolver for the deleteFlowRelationship field.
+func (r *mutationResolver) DeleteFlowRelationship(ctx context.Context, flowID int, id string) (bool, error) {
+	relationshipID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid flow relationship ID: %w", err)
+	}
+
+	err = r.Store.Flows.DeleteRelationship(ctx, flowID, relationshipID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("flow relationship not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete flow relationship: %w", err)
+	}
+
+	return true, nil
+}
+
+// SetFlowConstraints is the resolver for the setFlowConstraints field.
+func (r *mutationResolver) SetFlowConstraints(ctx context.Context, flowID int, input FlowConstraintsInput) (*FlowConstraints, error) {
+	c := models.FlowConstraints{
+		FlowID:         flowID,
+		QuietStart:     input.QuietStart,
+		QuietEnd:       input.QuietEnd,
+		MaxWeeklyTasks: input.MaxWeeklyTasks,
+		ForbiddenTags:  input.ForbiddenTags,
+	}
+	if err := r.validator().FlowConstraints.Validate(ctx, &c); err != nil {
+		return nil, invalidInput(err)
+	}
+
+	err := r.Store.Flows.SetConstraints(ctx, &c)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("flow not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update flow constraints: %w", err)
+	}
+
+	return toFlowConstraints(&c), nil
+}
+
+// PauseFlow is the resolver for the pauseFlow field.
+func (r *mutationResolver) PauseFlow(ctx context.Context, id string, reason *string) (*Flow, error) {
+	return r.transitionFlow(ctx, id, "paused", reason)
+}
+
+// ResumeFlow is the resolver for the resumeFlow field.
+func (r *mutationResolver) ResumeFlow(ctx context.Context, id string, reason *string) (*Flow, error) {
+	return r.transitionFlow(ctx, id, "active", reason)
+}
+
+// ArchiveFlow is the resolver for the archiveFlow field.
+func (r *mutationResolver) ArchiveFlow(ctx context.Context, id string, reason *string) (*Flow, error) {
+	return r.transitionFlow(ctx, id, "archived", reason)
+}
+
+// CelebrateFlow is the resolver for the celebrateFlow field.
+func (r *mutationResolver) CelebrateFlow(ctx context.Context, id string, reason *string) (*Flow, error) {
+	return r.transitionFlow(ctx, id, "celebrated", reason)
+}
+
+// AssignTag is the resolver for the assignTag field.
+func (r *mutationResolver) AssignTag(ctx context.Context, entityType string, entityID int, tagID int) (bool, error) {
+	err := r.Store.Tags.Assign(ctx, entityType, entityID, tagID)
+	if errors.Is(err, store.ErrInvalidEntityType) {
+		return false, fmt.Errorf("invalid entity type %q", entityType)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to assign tag: %w", err)
+	}
+
+	return true, nil
+}
+
+// RemoveTag is the resolver for the removeTag field.
+func (r *mutationResolver) RemoveTag(ctx context.Context, entityType string, entityID int, tagID int) (bool, error) {
+	err := r.Store.Tags.Remove(ctx, entityType, entityID, tagID)
+	if errors.Is(err, store.ErrInvalidEntityType) {
+		return false, fmt.Errorf("invalid entity type %q", entityType)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("tag assignment not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to remove tag: %w", err)
+	}
+
+	return true, nil
+}
+
+// Tags is the resolver for the tags field.
+func (r *noteResolver) Tags(ctx context.Context, obj *Note) ([]*Tag, error) {
+	noteID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	tags, err := r.loaders(ctx).TagsByEntity.Load(ctx, loader.Entity{Type: "note", ID: noteID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+
+	return toTags(tags), nil
+}
+
+// Goals is the resolver for the goals field.
+func (r *projectResolver) Goals(ctx context.Context, obj *Project) ([]*Goal, error) {
+	projectID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project ID: %w", err)
+	}
+
+	goals, err := r.loaders(ctx).GoalsByProject.Load(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch goals: %w", err)
+	}
+
+	return toGoals(goals), nil
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *projectResolver) Tasks(ctx context.Context, obj *Project) ([]*Task, error) {
+	projectID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project ID: %w", err)
+	}
+
+	tasks, err := r.loaders(ctx).TasksByProject.Load(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tasks: %w", err)
+	}
+
+	return toTasks(tasks), nil
+}
+
+// Notes is the resolver for the notes field.
+func (r *projectResolver) Notes(ctx context.Context, obj *Project) ([]*Note, error) {
+	projectID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project ID: %w", err)
+	}
+
+	notes, err := r.loaders(ctx).NotesByEntity.Load(ctx, loader.Entity{Type: "project", ID: projectID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notes: %w", err)
+	}
+
+	return toNotes(notes), nil
+}
+
+// Tags is the resolver for the tags field.
+func (r *projectResolver) Tags(ctx context.Context, obj *Project) ([]*Tag, error) {
+	projectID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project ID: %w", err)
+	}
+
+	tags, err := r.loaders(ctx).TagsByEntity.Load(ctx, loader.Entity{Type: "project", ID: projectID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+
+	return toTags(tags), nil
+}
+
+// Flow is the resolver for the flow field.
+func (r *projectResolver) Flow(ctx context.Context, obj *Project) (*Flow, error) {
+	if obj.FlowID == nil {
+		return nil, nil
+	}
+
+	f, err := r.loaders(ctx).Flow.Load(ctx, *obj.FlowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flow: %w", err)
+	}
+	if f == nil {
+		return nil, nil
+	}
+
+	return toFlow(f), nil
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*User, error) {
+	u, err := auth.RequireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toUser(u), nil
+}
+
+// Projects is the resolver for the projects field.
+func (r *queryResolver) Projects(ctx context.Context, workspaceID *int) ([]*Project, error) {
+	projects, err := r.Store.Projects.List(ctx, store.ProjectFilter{WorkspaceID: workspaceID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
+	}
+
+	return toProjects(projects), nil
+}
+
+// Project is the resolver for the project field.
+func (r *queryResolver) Project(ctx context.Context, id string) (*Project, error) {
+	projectID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project ID: %w", err)
+	}
+
+	p, err := r.Store.Projects.Get(ctx, projectID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("project not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+
+	return toProject(p), nil
+}
+
+// Goals is the resolver for the goals field.
+func (r *queryResolver) Goals(ctx context.Context, projectID *int) ([]*Goal, error) {
+	goals, err := r.Store.Goals.List(ctx, store.GoalFilter{ProjectID: projectID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query goals: %w", err)
+	}
+
+	return toGoals(goals), nil
+}
+
+// Goal is the resolver for the goal field.
+func (r *queryResolver) Goal(ctx context.Context, id string) (*Goal, error) {
+	goalID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid goal ID: %w", err)
+	}
+
+	g, err := r.Store.Goals.Get(ctx, goalID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil // GraphQL standard: return nil for not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query goal: %w", err)
+	}
+
+	return toGoal(g), nil
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *queryResolver) Tasks(ctx context.Context, projectID *int, goalID *int, status *string) ([]*Task, error) {
+	tasks, err := r.Store.Tasks.List(ctx, store.TaskFilter{ProjectID: projectID, GoalID: goalID, Status: status})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+
+	return toTasks(tasks), nil
+}
+
+// Task is the resolver for the task field.
+func (r *queryResolver) Task(ctx context.Context, id string) (*Task, error) {
+	taskID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task ID: %w", err)
+	}
+
+	t, err := r.Store.Tasks.Get(ctx, taskID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query task: %w", err)
+	}
+
+	return toTask(t), nil
+}
+
+// Tags is the resolver for the tags field.
+func (r *queryResolver) Tags(ctx context.Context, parentID *int) ([]*Tag, error) {
+	tags, err := r.Store.Tags.List(ctx, store.TagFilter{ParentID: parentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+
+	return toTags(tags), nil
+}
+
+// Tag is the resolver for the tag field.
+func (r *queryResolver) Tag(ctx context.Context, id string) (*Tag, error) {
+	tagID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag ID: %w", err)
+	}
+
+	t, err := r.Store.Tags.Get(ctx, tagID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag: %w", err)
+	}
+
+	return toTag(t), nil
+}
+
+// Notes is the resolver for the notes field.
+func (r *queryResolver) Notes(ctx context.Context, entityType *string, entityID *int) ([]*Note, error) {
+	notes, err := r.Store.Notes.List(ctx, store.NoteFilter{EntityType: entityType, EntityID: entityID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+
+	return toNotes(notes), nil
+}
+
+// Note is the resolver for the note field.
+func (r *queryResolver) Note(ctx context.Context, id string) (*Note, error) {
+	noteID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid note ID: %w", err)
+	}
+
+	n, err := r.Store.Notes.Get(ctx, noteID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query note: %w", err)
+	}
+
+	return toNote(n), nil
 }
 
-// AssignTag is the resolver for the assignTag field.
-func (r *mutationResolver) AssignTag(ctx context.Context, entityType string, entityID int, tagID int) (bool, error) {
-	panic(fmt.Errorf("not implemented: AssignTag - assignTag"))
-}
+// Workspaces is the resolver for the workspaces field.
+func (r *queryResolver) Workspaces(ctx context.Context) ([]*Workspace, error) {
+	workspaces, err := r.Store.Workspaces.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspaces: %w", err)
+	}
 
-// RemoveTag is the resolver for the removeTag field.
-func (r *mutationResolver) RemoveTag(ctx context.Context, entityType string, entityID int, tagID int) (bool, error) {
-	panic(fmt.Errorf("not implemented: RemoveTag - removeTag"))
+	return toWorkspaces(workspaces), nil
 }
 
-// Projects is the resolver for the projects field.
-func (r *queryResolver) Projects(ctx context.Context, workspaceID *int) ([]*Project, error) {
-	var query string
-	var args []interface{}
-
-	if workspaceID != nil {
-		query = `SELECT id, title, description, status, workspace_id, created_at, updated_at 
-				 FROM projects WHERE workspace_id = $1 ORDER BY created_at DESC`
-		args = append(args, *workspaceID)
-	} else {
-		query = `SELECT id, title, description, status, workspace_id, created_at, updated_at 
-				 FROM projects ORDER BY created_at DESC`
+// Workspace is the resolver for the workspace field.
+func (r *queryResolver) Workspace(ctx context.Context, id string) (*Workspace, error) {
+	workspaceID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace ID: %w", err)
 	}
 
-	rows, err := r.DB.Query(query, args...)
+	ws, err := r.Store.Workspaces.Get(ctx, workspaceID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch projects: %w", err)
+		return nil, fmt.Errorf("failed to query workspace: %w", err)
 	}
-	defer rows.Close()
 
-	var projects []*Project
-	for rows.Next() {
-		var p models.Project
-		err := rows.Scan(&p.ID, &p.Title, &p.Description, &p.Status, &p.WorkspaceID, &p.CreatedAt, &p.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan project: %w", err)
-		}
-		projects = append(projects, &Project{
-			ID:          strconv.Itoa(p.ID),
-			Title:       p.Title,
-			Description: &p.Description,
-			Status:      p.Status,
-			WorkspaceID: *p.WorkspaceID,
-			CreatedAt:   p.CreatedAt,
-			UpdatedAt:   p.UpdatedAt,
-		})
+	return toWorkspace(ws), nil
+}
+
+// Flows is the resolver for the flows field.
+func (r *queryResolver) Flows(ctx context.Context, workspaceID *int) ([]*Flow, error) {
+	flows, err := r.Store.Flows.List(ctx, store.FlowFilter{WorkspaceID: workspaceID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query flows: %w", err)
 	}
 
-	return projects, nil
+	return toFlows(flows), nil
 }
 
-// Project is the resolver for the project field.
-func (r *queryResolver) Project(ctx context.Context, id string) (*Project, error) {
-	projectID, err := strconv.Atoi(id)
+// Flow is the resolver for the flow field.
+func (r *queryResolver) Flow(ctx context.Context, id string) (*Flow, error) {
+	flowID, err := strconv.Atoi(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid project ID: %w", err)
+		return nil, fmt.Errorf("invalid flow ID: %w", err)
 	}
 
-	var p models.Project
-	err = r.DB.QueryRow(`
-		SELECT id, title, description, status, workspace_id, created_at, updated_at 
-		FROM projects WHERE id = $1
-	`, projectID).Scan(&p.ID, &p.Title, &p.Description, &p.Status, &p.WorkspaceID, &p.CreatedAt, &p.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("project not found")
+	f, err := r.Store.Flows.Get(ctx, flowID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch project: %w", err)
+		return nil, fmt.Errorf("failed to query flow: %w", err)
 	}
 
-	return &Project{
-		ID:          strconv.Itoa(p.ID),
-		Title:       p.Title,
-		Description: &p.Description,
-		Status:      p.Status,
-		WorkspaceID: *p.WorkspaceID,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
-	}, nil
+	return toFlow(f), nil
 }
 
-// Goals is the resolver for the goals field.
-func (r *queryResolver) Goals(ctx context.Context, projectID *int) ([]*Goal, error) {
-	var goals []*Goal
-	var query string
-	var args []interface{}
+// FlowConflicts is the resolver for the flowConflicts field.
+func (r *queryResolver) FlowConflicts(ctx context.Context, workspaceID *int) ([]*FlowWarning, error) {
+	warnings, err := r.conflicts().Report(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate flow conflicts: %w", err)
+	}
+
+	return toFlowWarnings(warnings), nil
+}
 
-	if projectID != nil {
-		query = `SELECT id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at 
-				 FROM goals WHERE ($1 IS NULL OR project_id = $1) ORDER BY priority DESC, due_date ASC`
-		args = append(args, projectID)
-	} else {
-		query = `SELECT id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at 
-				 FROM goals ORDER BY priority DESC, due_date ASC`
+// FlowTimeline is the resolver for the flowTimeline field.
+func (r *queryResolver) FlowTimeline(ctx context.Context, from time.Time, to time.Time, workspaceID *int) (*FlowTimeline, error) {
+	tr := models.TimelineRange{From: &from, To: &to, WorkspaceID: workspaceID}
+	if err := r.validator().TimelineRange.Validate(ctx, &tr); err != nil {
+		return nil, invalidInput(err)
 	}
 
-	rows, err := r.DB.Query(query, args...)
+	timeline, err := r.Store.Flows.Timeline(ctx, tr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query goals: %w", err)
+		return nil, fmt.Errorf("failed to fetch flow timeline: %w", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var g models.Goal
-		err := rows.Scan(&g.ID, &g.Title, &g.Description, &g.Priority, &g.DueDate, &g.Status, &g.ProjectID, &g.FlowID, &g.CreatedAt, &g.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan goal: %w", err)
-		}
+	return toFlowTimeline(timeline), nil
+}
 
-		goal := &Goal{
-			ID:          strconv.Itoa(g.ID),
-			Title:       g.Title,
-			Description: &g.Description,
-			Priority:    fmt.Sprintf("%d", g.Priority),
-			DueDate:     g.DueDate,
-			Status:      g.Status,
-			CreatedAt:   g.CreatedAt,
-			UpdatedAt:   g.UpdatedAt,
-		}
+// Dashboard is the resolver for the dashboard field.
+func (r *queryResolver) Dashboard(ctx context.Context, workspaceID *int) (*Dashboard, error) {
+	tasks, err := r.Store.Tasks.ListToday(ctx, workspaceID, 10)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch today's tasks: %w", err)
+	}
 
-		if g.ProjectID != nil {
-			goal.ProjectID = *g.ProjectID
-		}
-		if g.FlowID != nil {
-			goal.FlowID = g.FlowID
-		}
+	projects, err := r.Store.Projects.ListRecent(ctx, workspaceID, 5)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recent projects: %w", err)
+	}
 
-		goals = append(goals, goal)
+	goals, err := r.Store.Goals.ListUpcoming(ctx, workspaceID, 5)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch upcoming goals: %w", err)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate goals: %w", err)
+	stats, err := r.Store.Workspaces.Stats(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workspace stats: %w", err)
 	}
 
-	return goals, nil
+	return &Dashboard{
+		TodayTasks:     toTasks(tasks),
+		RecentProjects: toProjects(projects),
+		UpcomingGoals:  toGoals(goals),
+		WorkspaceStats: &WorkspaceStats{
+			TotalProjects:  stats.TotalProjects,
+			TotalGoals:     stats.TotalGoals,
+			TotalTasks:     stats.TotalTasks,
+			CompletedTasks: stats.CompletedTasks,
+			PendingTasks:   stats.PendingTasks,
+		},
+	}, nil
 }
 
-// Goal is the resolver for the goal field.
-func (r *queryResolver) Goal(ctx context.Context, id string) (*Goal, error) {
-	goalID, err := strconv.Atoi(id)
+// AuditTrail is the resolver for the auditTrail field.
+func (r *queryResolver) AuditTrail(ctx context.Context, entityType string, entityID int, first *int) ([]*AuditEvent, error) {
+	page, err := pageArgs(first, nil, nil, nil)
 	if err != nil {
-		return nil, fmt.Errorf("invalid goal ID: %w", err)
+		return nil, err
 	}
 
-	var g models.Goal
-	query := `SELECT id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at 
-			  FROM goals WHERE id = $1`
-
-	err = r.DB.QueryRow(query, goalID).Scan(
-		&g.ID, &g.Title, &g.Description, &g.Priority, &g.DueDate, &g.Status, &g.ProjectID, &g.FlowID, &g.CreatedAt, &g.UpdatedAt)
-
+	result, err := r.Store.Audit.ListPage(ctx, store.AuditFilter{EntityType: &entityType, EntityID: &entityID}, page)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // GraphQL standard: return nil for not found
-		}
-		return nil, fmt.Errorf("failed to query goal: %w", err)
+		return nil, fmt.Errorf("failed to fetch audit trail: %w", err)
 	}
 
-	goal := &Goal{
-		ID:          strconv.Itoa(g.ID),
-		Title:       g.Title,
-		Description: &g.Description,
-		Priority:    fmt.Sprintf("%d", g.Priority),
-		DueDate:     g.DueDate,
-		Status:      g.Status,
-		CreatedAt:   g.CreatedAt,
-		UpdatedAt:   g.UpdatedAt,
+	events := make([]*AuditEvent, len(result.Items))
+	for i := range result.Items {
+		events[i] = toAuditEvent(&result.Items[i])
 	}
+	return events, nil
+}
 
-	if g.ProjectID != nil {
-		goal.ProjectID = *g.ProjectID
+// ProjectsConnection is the resolver for the projectsConnection field.
+func (r *queryResolver) ProjectsConnection(ctx context.Context, workspaceID *int, first *int, after *string, last *int, before *string) (*ProjectConnection, error) {
+	page, err := pageArgs(first, after, last, before)
+	if err != nil {
+		return nil, err
 	}
-	if g.FlowID != nil {
-		goal.FlowID = g.FlowID
+
+	result, err := r.Store.Projects.ListPage(ctx, store.ProjectFilter{WorkspaceID: workspaceID}, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
 	}
 
-	return goal, nil
-}
+	edges := make([]*ProjectEdge, len(result.Items))
+	for i := range result.Items {
+		edges[i] = &ProjectEdge{Cursor: result.Cursors[i].String(), Node: toProject(&result.Items[i])}
+	}
 
-// Tasks is the resolver for the tasks field.
-func (r *queryResolver) Tasks(ctx context.Context, projectID *int, goalID *int, status *string) ([]*Task, error) {
-	panic(fmt.Errorf("not implemented: Tasks - tasks"))
+	return &ProjectConnection{Edges: edges, PageInfo: newPageInfo(page, result.More, result.Cursors)}, nil
 }
 
-// Task is the resolver for the task field.
-func (r *queryResolver) Task(ctx context.Context, id string) (*Task, error) {
-	panic(fmt.Errorf("not implemented: Task - task"))
-}
+// GoalsConnection is the resolver for the goalsConnection field.
+func (r *queryResolver) GoalsConnection(ctx context.Context, projectID *int, first *int, after *string, last *int, before *string) (*GoalConnection, error) {
+	page, err := pageArgs(first, after, last, before)
+	if err != nil {
+		return nil, err
+	}
 
-// Tags is the resolver for the tags field.
-func (r *queryResolver) Tags(ctx context.Context, parentID *int) ([]*Tag, error) {
-	panic(fmt.Errorf("not implemented: Tags - tags"))
-}
+	result, err := r.Store.Goals.ListPage(ctx, store.GoalFilter{ProjectID: projectID}, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch goals: %w", err)
+	}
 
-// Tag is the resolver for the tag field.
-func (r *queryResolver) Tag(ctx context.Context, id string) (*Tag, error) {
-	panic(fmt.Errorf("not implemented: Tag - tag"))
-}
+	edges := make([]*GoalEdge, len(result.Items))
+	for i := range result.Items {
+		edges[i] = &GoalEdge{Cursor: result.Cursors[i].String(), Node: toGoal(&result.Items[i])}
+	}
 
-// Notes is the resolver for the notes field.
-func (r *queryResolver) Notes(ctx context.Context, entityType *string, entityID *int) ([]*Note, error) {
-	panic(fmt.Errorf("not implemented: Notes - notes"))
+	return &GoalConnection{Edges: edges, PageInfo: newPageInfo(page, result.More, result.Cursors)}, nil
 }
 
-// Note is the resolver for the note field.
-func (r *queryResolver) Note(ctx context.Context, id string) (*Note, error) {
-	panic(fmt.Errorf("not implemented: Note - note"))
-}
+// TasksConnection is the resolver for the tasksConnection field.
+func (r *queryResolver) TasksConnection(ctx context.Context, projectID *int, goalID *int, status *string, first *int, after *string, last *int, before *string) (*TaskConnection, error) {
+	page, err := pageArgs(first, after, last, before)
+	if err != nil {
+		return nil, err
+	}
 
-// Workspaces is the resolver for the workspaces field.
-func (r *queryResolver) Workspaces(ctx context.Context) ([]*Workspace, error) {
-	panic(fmt.Errorf("not implemented: Workspaces - workspaces"))
-}
+	result, err := r.Store.Tasks.ListPage(ctx, store.TaskFilter{ProjectID: projectID, GoalID: goalID, Status: status}, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tasks: %w", err)
+	}
 
-// Workspace is the resolver for the workspace field.
-func (r *queryResolver) Workspace(ctx context.Context, id string) (*Workspace, error) {
-	panic(fmt.Errorf("not implemented: Workspace - workspace"))
-}
+	edges := make([]*TaskEdge, len(result.Items))
+	for i := range result.Items {
+		edges[i] = &TaskEdge{Cursor: result.Cursors[i].String(), Node: toTask(&result.Items[i])}
+	}
 
-// Flows is the resolver for the flows field.
-func (r *queryResolver) Flows(ctx context.Context, workspaceID *int) ([]*Flow, error) {
-	panic(fmt.Errorf("not implemented: Flows - flows"))
+	return &TaskConnection{Edges: edges, PageInfo: newPageInfo(page, result.More, result.Cursors)}, nil
 }
 
-// Flow is the resolver for the flow field.
-func (r *queryResolver) Flow(ctx context.Context, id string) (*Flow, error) {
-	panic(fmt.Errorf("not implemented: Flow - flow"))
-}
+// NotesConnection is the resolver for the notesConnection field.
+func (r *queryResolver) NotesConnection(ctx context.Context, entityType *string, entityID *int, first *int, after *string, last *int, before *string) (*NoteConnection, error) {
+	page, err := pageArgs(first, after, last, before)
+	if err != nil {
+		return nil, err
+	}
 
-// Dashboard is the resolver for the dashboard field.
-func (r *queryResolver) Dashboard(ctx context.Context, workspaceID *int) (*Dashboard, error) {
-	// Get today's tasks
-	todayTasks := []*Task{}
-	tasksQuery := `SELECT id, title, description, status, priority, due_date, goal_id, project_id, context_id, created_at, updated_at 
-				  FROM tasks WHERE DATE(due_date) = CURRENT_DATE OR status = 'in_progress' ORDER BY priority DESC LIMIT 10`
-	tasksRows, err := r.DB.Query(tasksQuery)
-	if err == nil {
-		defer tasksRows.Close()
-		for tasksRows.Next() {
-			var t models.Task
-			tasksRows.Scan(&t.ID, &t.Title, &t.Description, &t.Status, &t.Priority, &t.DueDate, &t.GoalID, &t.ProjectID, &t.FlowID, &t.CreatedAt, &t.UpdatedAt)
-			todayTasks = append(todayTasks, &Task{
-				ID:          strconv.Itoa(t.ID),
-				Title:       t.Title,
-				Description: &t.Description,
-				Status:      t.Status,
-				Priority:    fmt.Sprintf("%d", t.Priority),
-				DueDate:     t.DueDate,
-				GoalID:      t.GoalID,
-				ProjectID:   *t.ProjectID,
-				CreatedAt:   t.CreatedAt,
-				UpdatedAt:   t.UpdatedAt,
-			})
-		}
+	result, err := r.Store.Notes.ListPage(ctx, store.NoteFilter{EntityType: entityType, EntityID: entityID}, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notes: %w", err)
 	}
 
-	// Get recent projects
-	recentProjects := []*Project{}
-	projectsQuery := `SELECT id, title, description, status, workspace_id, created_at, updated_at 
-					  FROM projects ORDER BY updated_at DESC LIMIT 5`
-	projectsRows, err := r.DB.Query(projectsQuery)
-	if err == nil {
-		defer projectsRows.Close()
-		for projectsRows.Next() {
-			var p models.Project
-			projectsRows.Scan(&p.ID, &p.Title, &p.Description, &p.Status, &p.WorkspaceID, &p.CreatedAt, &p.UpdatedAt)
-			recentProjects = append(recentProjects, &Project{
-				ID:          strconv.Itoa(p.ID),
-				Title:       p.Title,
-				Description: &p.Description,
-				Status:      p.Status,
-				WorkspaceID: *p.WorkspaceID,
-				CreatedAt:   p.CreatedAt,
-				UpdatedAt:   p.UpdatedAt,
-			})
-		}
+	edges := make([]*NoteEdge, len(result.Items))
+	for i := range result.Items {
+		edges[i] = &NoteEdge{Cursor: result.Cursors[i].String(), Node: toNote(&result.Items[i])}
 	}
 
-	// Get upcoming goals
-	upcomingGoals := []*Goal{}
-	goalsQuery := `SELECT id, title, description, priority, due_date, status, project_id, context_id, created_at, updated_at 
-				   FROM goals WHERE due_date > CURRENT_DATE ORDER BY due_date ASC LIMIT 5`
-	goalsRows, err := r.DB.Query(goalsQuery)
-	if err == nil {
-		defer goalsRows.Close()
-		for goalsRows.Next() {
-			var g models.Goal
-			goalsRows.Scan(&g.ID, &g.Title, &g.Description, &g.Priority, &g.DueDate, &g.Status, &g.ProjectID, &g.FlowID, &g.CreatedAt, &g.UpdatedAt)
-			upcomingGoals = append(upcomingGoals, &Goal{
-				ID:          strconv.Itoa(g.ID),
-				Title:       g.Title,
-				Description: &g.Description,
-				Priority:    fmt.Sprintf("%d", g.Priority),
-				DueDate:     g.DueDate,
-				Status:      g.Status,
-				ProjectID:   *g.ProjectID,
-				CreatedAt:   g.CreatedAt,
-				UpdatedAt:   g.UpdatedAt,
-			})
-		}
+	return &NoteConnection{Edges: edges, PageInfo: newPageInfo(page, result.More, result.Cursors)}, nil
+}
+
+// FlowsConnection is the resolver for the flowsConnection field.
+func (r *queryResolver) FlowsConnection(ctx context.Context, workspaceID *int, first *int, after *string, last *int, before *string) (*FlowConnection, error) {
+	page, err := pageArgs(first, after, last, before)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get workspace stats
-	var totalProjects, totalGoals, totalTasks, completedTasks, pendingTasks int
-	r.DB.QueryRow("SELECT COUNT(*) FROM projects").Scan(&totalProjects)
-	r.DB.QueryRow("SELECT COUNT(*) FROM goals").Scan(&totalGoals)
-	r.DB.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&totalTasks)
-	r.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE status = 'completed'").Scan(&completedTasks)
-	r.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE status IN ('pending', 'in_progress')").Scan(&pendingTasks)
+	result, err := r.Store.Flows.ListPage(ctx, store.FlowFilter{WorkspaceID: workspaceID}, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flows: %w", err)
+	}
 
-	workspaceStats := &WorkspaceStats{
-		TotalProjects:  totalProjects,
-		TotalGoals:     totalGoals,
-		TotalTasks:     totalTasks,
-		CompletedTasks: completedTasks,
-		PendingTasks:   pendingTasks,
+	edges := make([]*FlowEdge, len(result.Items))
+	for i := range result.Items {
+		edges[i] = &FlowEdge{Cursor: result.Cursors[i].String(), Node: toFlow(&result.Items[i])}
 	}
 
-	return &Dashboard{
-		TodayTasks:     todayTasks,
-		RecentProjects: recentProjects,
-		UpcomingGoals:  upcomingGoals,
-		WorkspaceStats: workspaceStats,
-	}, nil
+	return &FlowConnection{Edges: edges, PageInfo: newPageInfo(page, result.More, result.Cursors)}, nil
 }
 
-// Mutation returns MutationResolver implementation.
-func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
-
-// Query returns QueryResolver implementation.
-func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+// Parent is the resolver for the parent field.
+func (r *tagResolver) Parent(ctx context.Context, obj *Tag) (*Tag, error) {
+	if obj.ParentID == nil {
+		return nil, nil
+	}
 
+	t, err := r.loaders(ctx).Tag.Load(ctx, *obj.ParentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch parent tag: %w", err)
+	}
+	if t == nil {
+		return nil, nil
+	}
 
-// Project field resolver for Goal
-func (r *goalResolver) Project(ctx context.Context, obj *Goal) (*Project, error) {
-	var p models.Project
-	err := r.DB.QueryRow(`
-		SELECT id, title, description, status, workspace_id, created_at, updated_at 
-		FROM projects WHERE id = $1
-	`, obj.ProjectID).Scan(&p.ID, &p.Title, &p.Description, &p.Status, &p.WorkspaceID, &p.CreatedAt, &p.UpdatedAt)
+	return toTag(t), nil
+}
 
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("project with ID %d not found for goal %s", obj.ProjectID, obj.ID)
+// Children is the resolver for the children field.
+func (r *tagResolver) Children(ctx context.Context, obj *Tag) ([]*Tag, error) {
+	tagID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag ID: %w", err)
 	}
+
+	tags, err := r.Store.Tags.List(ctx, store.TagFilter{ParentID: &tagID})
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch project: %w", err)
+		return nil, fmt.Errorf("failed to fetch child tags: %w", err)
 	}
 
-	return &Project{
-		ID:          strconv.Itoa(p.ID),
-		Title:       p.Title,
-		Description: &p.Description,
-		Status:      p.Status,
-		WorkspaceID: *p.WorkspaceID,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
-	}, nil
+	return toTags(tags), nil
 }
 
-// Flow field resolver for Goal
-func (r *goalResolver) Flow(ctx context.Context, obj *Goal) (*Flow, error) {
-	if obj.FlowID == nil {
-		return nil, nil
+// Projects is the resolver for the projects field.
+func (r *tagResolver) Projects(ctx context.Context, obj *Tag) ([]*Project, error) {
+	tagID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag ID: %w", err)
 	}
 
-	var f models.Flow
-	err := r.DB.QueryRow(`
-		SELECT id, title, description, color, status, start_date, end_date, parent_id, workspace_id, created_at, updated_at 
-		FROM flows WHERE id = $1
-	`, *obj.FlowID).Scan(&f.ID, &f.Title, &f.Description, &f.Color, &f.Status, &f.StartDate, &f.EndDate, &f.ParentID, &f.WorkspaceID, &f.CreatedAt, &f.UpdatedAt)
+	projects, err := r.Store.Projects.List(ctx, store.ProjectFilter{TagID: &tagID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
+	}
 
-	if err == sql.ErrNoRows {
-		return nil, nil
+	return toProjects(projects), nil
+}
+
+// Goals is the resolver for the goals field.
+func (r *tagResolver) Goals(ctx context.Context, obj *Tag) ([]*Goal, error) {
+	tagID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag ID: %w", err)
 	}
+
+	goals, err := r.Store.Goals.List(ctx, store.GoalFilter{TagID: &tagID})
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch flow: %w", err)
+		return nil, fmt.Errorf("failed to fetch goals: %w", err)
 	}
 
-	flow := &Flow{
-		ID:          strconv.Itoa(f.ID),
-		Title:       f.Title,
-		Description: &f.Description,
-		Color:       f.Color,
-		Status:      f.Status,
-		WorkspaceID: f.WorkspaceID,
-		CreatedAt:   f.CreatedAt,
-		UpdatedAt:   f.UpdatedAt,
+	return toGoals(goals), nil
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *tagResolver) Tasks(ctx context.Context, obj *Tag) ([]*Task, error) {
+	tagID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag ID: %w", err)
 	}
 
-	if f.StartDate != nil {
-		flow.StartDate = f.StartDate
+	tasks, err := r.Store.Tasks.List(ctx, store.TaskFilter{TagID: &tagID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tasks: %w", err)
 	}
-	if f.EndDate != nil {
-		flow.EndDate = f.EndDate
+
+	return toTasks(tasks), nil
+}
+
+// Notes is the resolver for the notes field.
+func (r *tagResolver) Notes(ctx context.Context, obj *Tag) ([]*Note, error) {
+	tagID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag ID: %w", err)
 	}
-	if f.ParentID != nil {
-		flow.ParentID = f.ParentID
+
+	notes, err := r.Store.Notes.List(ctx, store.NoteFilter{TagID: &tagID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notes: %w", err)
 	}
 
-	return flow, nil
+	return toNotes(notes), nil
 }
 
-// Tasks field resolver for Goal
-func (r *goalResolver) Tasks(ctx context.Context, obj *Goal) ([]*Task, error) {
-	goalID, err := strconv.Atoi(obj.ID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid goal ID: %w", err)
+// Goal is the resolver for the goal field.
+func (r *taskResolver) Goal(ctx context.Context, obj *Task) (*Goal, error) {
+	if obj.GoalID == nil {
+		return nil, nil
 	}
 
-	rows, err := r.DB.Query(`
-		SELECT id, title, description, status, priority, due_date, goal_id, project_id, context_id, created_at, updated_at 
-		FROM tasks WHERE goal_id = $1 ORDER BY priority DESC, due_date ASC
-	`, goalID)
+	g, err := r.loaders(ctx).Goal.Load(ctx, *obj.GoalID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch tasks: %w", err)
+		return nil, fmt.Errorf("failed to fetch goal: %w", err)
+	}
+	if g == nil {
+		return nil, nil
 	}
-	defer rows.Close()
 
-	var tasks []*Task
-	for rows.Next() {
-		var t models.Task
-		err := rows.Scan(&t.ID, &t.Title, &t.Description, &t.Status, &t.Priority, &t.DueDate, &t.GoalID, &t.ProjectID, &t.FlowID, &t.CreatedAt, &t.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan task: %w", err)
-		}
+	return toGoal(g), nil
+}
 
-		task := &Task{
-			ID:          strconv.Itoa(t.ID),
-			Title:       t.Title,
-			Description: &t.Description,
-			Status:      t.Status,
-			Priority:    fmt.Sprintf("%d", t.Priority),
-			DueDate:     t.DueDate,
-			CreatedAt:   t.CreatedAt,
-			UpdatedAt:   t.UpdatedAt,
-		}
+// Project is the resolver for the project field.
+func (r *taskResolver) Project(ctx context.Context, obj *Task) (*Project, error) {
+	p, err := r.loaders(ctx).Project.Load(ctx, obj.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+	if p == nil {
+		return nil, fmt.Errorf("project with ID %d not found for task %s", obj.ProjectID, obj.ID)
+	}
 
-		if t.GoalID != nil {
-			task.GoalID = t.GoalID
-		}
-		if t.ProjectID != nil {
-			task.ProjectID = *t.ProjectID
-		}
-		if t.FlowID != nil {
-			task.FlowID = t.FlowID
-		}
+	return toProject(p), nil
+}
 
-		tasks = append(tasks, task)
+// Notes is the resolver for the notes field.
+func (r *taskResolver) Notes(ctx context.Context, obj *Task) ([]*Note, error) {
+	taskID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task ID: %w", err)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate tasks: %w", err)
+	notes, err := r.loaders(ctx).NotesByEntity.Load(ctx, loader.Entity{Type: "task", ID: taskID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notes: %w", err)
 	}
 
-	return tasks, nil
+	return toNotes(notes), nil
 }
 
-// Tags field resolver for Goal
-func (r *goalResolver) Tags(ctx context.Context, obj *Goal) ([]*Tag, error) {
-	goalID, err := strconv.Atoi(obj.ID)
+// Tags is the resolver for the tags field.
+func (r *taskResolver) Tags(ctx context.Context, obj *Task) ([]*Tag, error) {
+	taskID, err := strconv.Atoi(obj.ID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid goal ID: %w", err)
+		return nil, fmt.Errorf("invalid task ID: %w", err)
 	}
 
-	rows, err := r.DB.Query(`
-		SELECT t.id, t.name, t.color, t.parent_id, t.created_at 
-		FROM tags t 
-		JOIN goal_tags gt ON t.id = gt.tag_id 
-		WHERE gt.goal_id = $1 ORDER BY t.name
-	`, goalID)
+	tags, err := r.loaders(ctx).TagsByEntity.Load(ctx, loader.Entity{Type: "task", ID: taskID})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch tags: %w", err)
 	}
-	defer rows.Close()
 
-	var tags []*Tag
-	for rows.Next() {
-		var t models.Tag
-		err := rows.Scan(&t.ID, &t.Name, &t.Color, &t.ParentID, &t.CreatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan tag: %w", err)
-		}
+	return toTags(tags), nil
+}
 
-		tag := &Tag{
-			ID:        strconv.Itoa(t.ID),
-			Name:      t.Name,
-			Color:     t.Color,
-			CreatedAt: t.CreatedAt,
-		}
+// Flow is the resolver for the flow field.
+func (r *taskResolver) Flow(ctx context.Context, obj *Task) (*Flow, error) {
+	if obj.FlowID == nil {
+		return nil, nil
+	}
 
-		if t.ParentID != nil {
-			tag.ParentID = t.ParentID
-		}
+	f, err := r.loaders(ctx).Flow.Load(ctx, *obj.FlowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flow: %w", err)
+	}
+	if f == nil {
+		return nil, nil
+	}
+
+	return toFlow(f), nil
+}
 
-		tags = append(tags, tag)
+// Projects is the resolver for the projects field.
+func (r *workspaceResolver) Projects(ctx context.Context, obj *Workspace) ([]*Project, error) {
+	workspaceID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace ID: %w", err)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate tags: %w", err)
+	projects, err := r.Store.Projects.List(ctx, store.ProjectFilter{WorkspaceID: &workspaceID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
 	}
 
-	return tags, nil
+	return toProjects(projects), nil
 }
 
-// Notes field resolver for Goal
-func (r *goalResolver) Notes(ctx context.Context, obj *Goal) ([]*Note, error) {
-	goalID, err := strconv.Atoi(obj.ID)
+// Flows is the resolver for the flows field.
+func (r *workspaceResolver) Flows(ctx context.Context, obj *Workspace) ([]*Flow, error) {
+	workspaceID, err := strconv.Atoi(obj.ID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid goal ID: %w", err)
+		return nil, fmt.Errorf("invalid workspace ID: %w", err)
 	}
 
-	rows, err := r.DB.Query(`
-		SELECT id, title, content, entity_type, entity_id, created_at, updated_at 
-		FROM notes WHERE entity_type = $1 AND entity_id = $2 ORDER BY created_at DESC
-	`, "goal", goalID)
+	flows, err := r.Store.Flows.List(ctx, store.FlowFilter{WorkspaceID: &workspaceID})
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch notes: %w", err)
+		return nil, fmt.Errorf("failed to fetch flows: %w", err)
 	}
-	defer rows.Close()
 
-	var notes []*Note
-	for rows.Next() {
-		var n models.Note
-		err := rows.Scan(&n.ID, &n.Title, &n.Content, &n.EntityType, &n.EntityID, &n.CreatedAt, &n.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan note: %w", err)
-		}
+	return toFlows(flows), nil
+}
 
-		notes = append(notes, &Note{
-			ID:         strconv.Itoa(n.ID),
-			Title:      n.Title,
-			Content:    n.Content,
-			EntityType: n.EntityType,
-			EntityID:   *n.EntityID,
-			CreatedAt:  n.CreatedAt,
-			UpdatedAt:  n.UpdatedAt,
-		})
+// Members is the resolver for the members field.
+func (r *workspaceResolver) Members(ctx context.Context, obj *Workspace) ([]*WorkspaceMember, error) {
+	workspaceID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace ID: %w", err)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate notes: %w", err)
+	members, err := r.Store.Members.List(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query members: %w", err)
 	}
 
-	return notes, nil
+	return toWorkspaceMembers(members), nil
 }
 
-type mutationResolver struct{ *Resolver }
-type queryResolver struct{ *Resolver }
-type projectResolver struct{ *Resolver }
+// Flow returns FlowResolver implementation.
+func (r *Resolver) Flow() FlowResolver { return &flowResolver{r} }
+
+// FlowRelationship returns FlowRelationshipResolver implementation.
+func (r *Resolver) FlowRelationship() FlowRelationshipResolver { return &flowRelationshipResolver{r} }
+
+// Goal returns GoalResolver implementation.
+func (r *Resolver) Goal() GoalResolver { return &goalResolver{r} }
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Note returns NoteResolver implementation.
+func (r *Resolver) Note() NoteResolver { return &noteResolver{r} }
+
+// Project returns ProjectResolver implementation.
+func (r *Resolver) Project() ProjectResolver { return &projectResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Tag returns TagResolver implementation.
+func (r *Resolver) Tag() TagResolver { return &tagResolver{r} }
+
+// Task returns TaskResolver implementation.
+func (r *Resolver) Task() TaskResolver { return &taskResolver{r} }
+
+// Workspace returns WorkspaceResolver implementation.
+func (r *Resolver) Workspace() WorkspaceResolver { return &workspaceResolver{r} }
+
+type flowResolver struct{ *Resolver }
+type flowRelationshipResolver struct{ *Resolver }
 type goalResolver struct{ *Resolver }
-type taskResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
 type noteResolver struct{ *Resolver }
+type projectResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
 type tagResolver struct{ *Resolver }
-
-// !!! WARNING !!!
-// The code below was going to be deleted when updating resolvers. It has been copied here so you have
-// one last chance to move it out of harms way if you want. There are two reasons this happens:
-//  - When renaming or deleting a resolver the old code will be put in here. You can safely delete
-//    it when you're done.
-//  - You have helper methods in this file. Move them out to keep these resolver files clean.
+type taskResolver struct{ *Resolver }
+type workspaceResolver struct{ *Resolver }
@@ -0,0 +1,47 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"go-goal/internal/auth"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestScopeCheck(t *testing.T) {
+	run := func(op ast.Operation, scopes ...string) *graphql.Response {
+		ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+			Operation: &ast.OperationDefinition{Operation: op},
+		})
+		if scopes != nil {
+			ctx = auth.WithScopes(ctx, scopes)
+		}
+		return ScopeCheck{}.InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+			return graphql.OneShot(&graphql.Response{})
+		})(ctx)
+	}
+
+	t.Run("should let sessions run any operation", func(t *testing.T) {
+		assert.Empty(t, run(ast.Mutation).Errors)
+	})
+
+	t.Run("should let read tokens query", func(t *testing.T) {
+		assert.Empty(t, run(ast.Query, auth.ScopeRead).Errors)
+	})
+
+	t.Run("should reject mutations from read tokens", func(t *testing.T) {
+		resp := run(ast.Mutation, auth.ScopeRead)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "INSUFFICIENT_SCOPE", resp.Errors[0].Extensions["code"])
+		assert.Equal(t, auth.ScopeWrite, resp.Errors[0].Extensions["scope"])
+	})
+
+	t.Run("should let write tokens mutate", func(t *testing.T) {
+		assert.Empty(t, run(ast.Mutation, auth.ScopeWrite).Errors)
+	})
+}
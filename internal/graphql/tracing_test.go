@@ -0,0 +1,44 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	field := func(isResolver bool) context.Context {
+		return graphql.WithFieldContext(context.Background(), &graphql.FieldContext{
+			Object:     "Dashboard",
+			Field:      graphql.CollectedField{Field: &ast.Field{Name: "recentTasks", Alias: "recentTasks"}},
+			IsResolver: isResolver,
+		})
+	}
+
+	_, err := Tracer{}.InterceptField(field(true), func(ctx context.Context) (any, error) {
+		return nil, errors.New("boom")
+	})
+	require.Error(t, err)
+	Tracer{}.InterceptField(field(false), func(ctx context.Context) (any, error) {
+		return "title", nil
+	})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "Dashboard.recentTasks", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
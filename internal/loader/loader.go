@@ -0,0 +1,117 @@
+// Package loader batches and caches the lookups made by GraphQL field
+// resolvers. A fresh set of loaders is attached to every request by
+// Middleware, so a query such as goals { tasks { tags } } issues one query
+// per level instead of one per parent object.
+package loader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultWait is how long a loader collects keys before running a batch.
+const DefaultWait = 2 * time.Millisecond
+
+// DefaultMaxBatch caps the number of keys sent to a single fetch.
+const DefaultMaxBatch = 500
+
+// FetchFunc loads the values for a batch of keys. Keys missing from the
+// returned map resolve to the zero value of V.
+type FetchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader coalesces concurrent Load calls into batched FetchFunc calls and
+// caches every result for the lifetime of the loader.
+type Loader[K comparable, V any] struct {
+	fetch    FetchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	cache   map[K]*result[V]
+	pending *batch[K, V]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	keys    []K
+	results []*result[V]
+}
+
+// New returns a Loader using DefaultWait and DefaultMaxBatch.
+func New[K comparable, V any](fetch FetchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     DefaultWait,
+		maxBatch: DefaultMaxBatch,
+		cache:    map[K]*result[V]{},
+	}
+}
+
+// Load returns the value for key, waiting for the batch it joins to run.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	r, ok := l.cache[key]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.cache[key] = r
+		l.enqueue(ctx, key, r)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// enqueue adds key to the pending batch, starting a new one if needed. The
+// caller must hold l.mu.
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K, r *result[V]) {
+	if l.pending == nil {
+		b := &batch[K, V]{}
+		l.pending = b
+		time.AfterFunc(l.wait, func() {
+			l.mu.Lock()
+			if l.pending != b {
+				l.mu.Unlock()
+				return
+			}
+			l.pending = nil
+			l.mu.Unlock()
+			l.run(ctx, b)
+		})
+	}
+
+	b := l.pending
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, r)
+	if len(b.keys) >= l.maxBatch {
+		l.pending = nil
+		go l.run(ctx, b)
+	}
+}
+
+// run fetches a batch with the context of the Load that started it. Every
+// caller shares the request context, so the batch is abandoned, along with
+// its query, once the client disconnects or the request times out.
+func (l *Loader[K, V]) run(ctx context.Context, b *batch[K, V]) {
+	values, err := l.fetch(ctx, b.keys)
+	for i, key := range b.keys {
+		r := b.results[i]
+		if err != nil {
+			r.err = err
+		} else {
+			r.value = values[key]
+		}
+		close(r.done)
+	}
+}
@@ -0,0 +1,112 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoader(t *testing.T) {
+	t.Run("should batch concurrent loads into one fetch", func(t *testing.T) {
+		var mu sync.Mutex
+		var batches [][]int
+		l := New(func(ctx context.Context, keys []int) (map[int]string, error) {
+			mu.Lock()
+			batches = append(batches, append([]int(nil), keys...))
+			mu.Unlock()
+			return map[int]string{1: "one", 2: "two"}, nil
+		})
+		l.wait = 50 * time.Millisecond
+
+		results := make([]string, 3)
+		var wg sync.WaitGroup
+		for i, key := range []int{1, 2, 3} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = l.Load(context.Background(), key)
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, batches, 1)
+		sort.Ints(batches[0])
+		assert.Equal(t, []int{1, 2, 3}, batches[0])
+		assert.Equal(t, []string{"one", "two", ""}, results)
+	})
+
+	t.Run("should serve repeated keys from the cache", func(t *testing.T) {
+		calls := 0
+		l := New(func(ctx context.Context, keys []int) (map[int]int, error) {
+			calls++
+			return map[int]int{keys[0]: keys[0] * 10}, nil
+		})
+
+		first, err := l.Load(context.Background(), 4)
+		assert.NoError(t, err)
+		second, err := l.Load(context.Background(), 4)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 40, first)
+		assert.Equal(t, 40, second)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should split batches larger than maxBatch", func(t *testing.T) {
+		var mu sync.Mutex
+		sizes := []int{}
+		l := New(func(ctx context.Context, keys []int) (map[int]int, error) {
+			mu.Lock()
+			sizes = append(sizes, len(keys))
+			mu.Unlock()
+			return nil, nil
+		})
+		l.wait = 50 * time.Millisecond
+		l.maxBatch = 2
+
+		var wg sync.WaitGroup
+		for key := range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				l.Load(context.Background(), key)
+			}()
+		}
+		wg.Wait()
+
+		sort.Ints(sizes)
+		assert.Equal(t, []int{1, 2, 2}, sizes)
+	})
+
+	t.Run("should return the fetch error for every key in the batch", func(t *testing.T) {
+		boom := errors.New("boom")
+		l := New(func(ctx context.Context, keys []int) (map[int]int, error) {
+			return nil, boom
+		})
+
+		_, err := l.Load(context.Background(), 1)
+
+		assert.ErrorIs(t, err, boom)
+	})
+
+	t.Run("should cancel the fetch with the request", func(t *testing.T) {
+		fetched := make(chan error, 1)
+		l := New(func(ctx context.Context, keys []int) (map[int]int, error) {
+			<-ctx.Done()
+			fetched <- ctx.Err()
+			return nil, ctx.Err()
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		_, err := l.Load(ctx, 1)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, <-fetched, context.Canceled)
+	})
+}
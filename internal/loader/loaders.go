@@ -0,0 +1,191 @@
+package loader
+
+import (
+	"context"
+	"net/http"
+
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+)
+
+// Entity identifies a taggable object for the tag and note loaders.
+type Entity struct {
+	Type string
+	ID   int
+}
+
+// Loaders holds one loader per lookup made by the GraphQL field resolvers.
+// Single-object loaders resolve missing rows to nil; list loaders resolve
+// parents without children to an empty slice.
+type Loaders struct {
+	Project *Loader[int, *models.Project]
+	Goal    *Loader[int, *models.Goal]
+	Task    *Loader[int, *models.Task]
+	Tag     *Loader[int, *models.Tag]
+	Note    *Loader[int, *models.Note]
+	Flow    *Loader[int, *models.Flow]
+
+	GoalsByProject *Loader[int, []models.Goal]
+	GoalsByFlow    *Loader[int, []models.Goal]
+	TasksByProject *Loader[int, []models.Task]
+	TasksByGoal    *Loader[int, []models.Task]
+	TasksByFlow    *Loader[int, []models.Task]
+	ProjectsByFlow *Loader[int, []models.Project]
+	FlowsByParent  *Loader[int, []models.Flow]
+	// RelationshipsByFlow resolves each flow to its relationships in
+	// either direction.
+	RelationshipsByFlow *Loader[int, []models.FlowRelationship]
+	// StatsByFlow resolves each flow to the stats of its subtree.
+	StatsByFlow *Loader[int, models.FlowStats]
+
+	TagsByEntity  *Loader[Entity, []models.Tag]
+	NotesByEntity *Loader[Entity, []models.Note]
+}
+
+// NewLoaders returns an empty set of loaders backed by s.
+func NewLoaders(s *store.Store) *Loaders {
+	return &Loaders{
+		Project: New(byID(func(ctx context.Context, ids []int) ([]models.Project, error) {
+			return s.Projects.List(ctx, store.ProjectFilter{IDs: ids})
+		}, func(p *models.Project) int { return p.ID })),
+		Goal: New(byID(func(ctx context.Context, ids []int) ([]models.Goal, error) {
+			return s.Goals.List(ctx, store.GoalFilter{IDs: ids})
+		}, func(g *models.Goal) int { return g.ID })),
+		Task: New(byID(func(ctx context.Context, ids []int) ([]models.Task, error) {
+			return s.Tasks.List(ctx, store.TaskFilter{IDs: ids})
+		}, func(t *models.Task) int { return t.ID })),
+		Tag: New(byID(func(ctx context.Context, ids []int) ([]models.Tag, error) {
+			return s.Tags.List(ctx, store.TagFilter{IDs: ids})
+		}, func(t *models.Tag) int { return t.ID })),
+		Note: New(byID(func(ctx context.Context, ids []int) ([]models.Note, error) {
+			return s.Notes.List(ctx, store.NoteFilter{IDs: ids})
+		}, func(n *models.Note) int { return n.ID })),
+		Flow: New(byID(func(ctx context.Context, ids []int) ([]models.Flow, error) {
+			return s.Flows.List(ctx, store.FlowFilter{IDs: ids})
+		}, func(f *models.Flow) int { return f.ID })),
+
+		GoalsByProject: New(groupBy(func(ctx context.Context, ids []int) ([]models.Goal, error) {
+			return s.Goals.List(ctx, store.GoalFilter{ProjectIDs: ids})
+		}, func(g *models.Goal) *int { return g.ProjectID })),
+		GoalsByFlow: New(groupBy(func(ctx context.Context, ids []int) ([]models.Goal, error) {
+			return s.Goals.List(ctx, store.GoalFilter{FlowIDs: ids})
+		}, func(g *models.Goal) *int { return g.FlowID })),
+		TasksByProject: New(groupBy(func(ctx context.Context, ids []int) ([]models.Task, error) {
+			return s.Tasks.List(ctx, store.TaskFilter{ProjectIDs: ids})
+		}, func(t *models.Task) *int { return t.ProjectID })),
+		TasksByGoal: New(groupBy(func(ctx context.Context, ids []int) ([]models.Task, error) {
+			return s.Tasks.List(ctx, store.TaskFilter{GoalIDs: ids})
+		}, func(t *models.Task) *int { return t.GoalID })),
+		TasksByFlow: New(groupBy(func(ctx context.Context, ids []int) ([]models.Task, error) {
+			return s.Tasks.List(ctx, store.TaskFilter{FlowIDs: ids})
+		}, func(t *models.Task) *int { return t.FlowID })),
+		ProjectsByFlow: New(groupBy(func(ctx context.Context, ids []int) ([]models.Project, error) {
+			return s.Projects.List(ctx, store.ProjectFilter{FlowIDs: ids})
+		}, func(p *models.Project) *int { return p.FlowID })),
+		FlowsByParent: New(groupBy(func(ctx context.Context, ids []int) ([]models.Flow, error) {
+			return s.Flows.List(ctx, store.FlowFilter{ParentIDs: ids})
+		}, func(f *models.Flow) *int { return f.ParentID })),
+		RelationshipsByFlow: New(func(ctx context.Context, ids []int) (map[int][]models.FlowRelationship, error) {
+			relationships, err := s.Flows.ListRelationships(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byFlow := make(map[int][]models.FlowRelationship, len(ids))
+			for _, r := range relationships {
+				byFlow[r.FlowID] = append(byFlow[r.FlowID], r)
+				byFlow[r.RelatedFlowID] = append(byFlow[r.RelatedFlowID], r)
+			}
+			return byFlow, nil
+		}),
+		StatsByFlow: New(s.Flows.ListStats),
+
+		TagsByEntity: New(byEntity(func(ctx context.Context, entityType string, ids []int) (map[int][]models.Tag, error) {
+			return s.Tags.ListForEntities(ctx, entityType, ids)
+		})),
+		NotesByEntity: New(byEntity(func(ctx context.Context, entityType string, ids []int) (map[int][]models.Note, error) {
+			notes, err := s.Notes.List(ctx, store.NoteFilter{EntityType: &entityType, EntityIDs: ids})
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int][]models.Note, len(ids))
+			for _, n := range notes {
+				if n.EntityID != nil {
+					byID[*n.EntityID] = append(byID[*n.EntityID], n)
+				}
+			}
+			return byID, nil
+		})),
+	}
+}
+
+// byID adapts a multi-row query into a fetch keyed by primary key.
+func byID[V any](list func(context.Context, []int) ([]V, error), id func(*V) int) FetchFunc[int, *V] {
+	return func(ctx context.Context, ids []int) (map[int]*V, error) {
+		rows, err := list(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[int]*V, len(rows))
+		for i := range rows {
+			values[id(&rows[i])] = &rows[i]
+		}
+		return values, nil
+	}
+}
+
+// groupBy adapts a multi-row query into a fetch keyed by a foreign key. Rows
+// keep the order returned by the store.
+func groupBy[V any](list func(context.Context, []int) ([]V, error), parent func(*V) *int) FetchFunc[int, []V] {
+	return func(ctx context.Context, ids []int) (map[int][]V, error) {
+		rows, err := list(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[int][]V, len(ids))
+		for _, row := range rows {
+			if key := parent(&row); key != nil {
+				values[*key] = append(values[*key], row)
+			}
+		}
+		return values, nil
+	}
+}
+
+// byEntity splits a batch of entities by type and runs one query per type.
+func byEntity[V any](list func(context.Context, string, []int) (map[int][]V, error)) FetchFunc[Entity, []V] {
+	return func(ctx context.Context, keys []Entity) (map[Entity][]V, error) {
+		idsByType := map[string][]int{}
+		for _, key := range keys {
+			idsByType[key.Type] = append(idsByType[key.Type], key.ID)
+		}
+
+		values := make(map[Entity][]V, len(keys))
+		for entityType, ids := range idsByType {
+			rows, err := list(ctx, entityType, ids)
+			if err != nil {
+				return nil, err
+			}
+			for id, row := range rows {
+				values[Entity{Type: entityType, ID: id}] = row
+			}
+		}
+		return values, nil
+	}
+}
+
+type contextKey struct{}
+
+// Middleware attaches a fresh set of loaders to every request so results are
+// never shared between requests.
+func Middleware(s *store.Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), contextKey{}, NewLoaders(s))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// For returns the loaders attached by Middleware, or nil outside a request.
+func For(ctx context.Context) *Loaders {
+	l, _ := ctx.Value(contextKey{}).(*Loaders)
+	return l
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("should filter below the configured level", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := New(&buf, "warn", "json")
+		require.NoError(t, err)
+
+		logger.Info("quiet")
+		logger.Warn("loud")
+
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		assert.Equal(t, "loud", line["msg"])
+	})
+
+	t.Run("should reject unknown settings", func(t *testing.T) {
+		_, err := New(&bytes.Buffer{}, "verbose", "json")
+		assert.EqualError(t, err, `unknown log level "verbose"`)
+
+		_, err = New(&bytes.Buffer{}, "info", "xml")
+		assert.EqualError(t, err, `unknown log format "xml"`)
+	})
+}
+
+func TestWithRequest(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", "text")
+	require.NoError(t, err)
+
+	ctx := WithRequest(context.Background(), logger, "abc123")
+	FromContext(ctx).Info("hello")
+
+	assert.Equal(t, "abc123", RequestID(ctx))
+	assert.Contains(t, buf.String(), "request_id=abc123")
+	assert.Empty(t, RequestID(context.Background()))
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -79,4 +80,184 @@ type Flow struct {
 	WorkspaceID int        `json:"workspace_id" db:"workspace_id"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
-}
\ No newline at end of file
+}
+
+// FlowStats counts the projects, goals and tasks of a flow and of all the
+// flows nested under it. The percentages are of completed goals and tasks,
+// rounded to one decimal, and 0 when there are none.
+type FlowStats struct {
+	TotalProjects         int     `json:"total_projects"`
+	TotalGoals            int     `json:"total_goals"`
+	TotalTasks            int     `json:"total_tasks"`
+	CompletedTasks        int     `json:"completed_tasks"`
+	PendingTasks          int     `json:"pending_tasks"`
+	ActiveProjects        int     `json:"active_projects"`
+	CompletedGoals        int     `json:"completed_goals"`
+	GoalCompletionPercent float64 `json:"goal_completion_percent"`
+	TaskCompletionPercent float64 `json:"task_completion_percent"`
+}
+
+// FlowNode is a flow in the flow tree, with the stats rolled up from its
+// subtree and its child flows.
+type FlowNode struct {
+	Flow
+	Stats    FlowStats  `json:"stats"`
+	Children []FlowNode `json:"children"`
+}
+
+// TimelineRange bounds a flow timeline. From and To are inclusive dates.
+type TimelineRange struct {
+	From        *time.Time `json:"from"`
+	To          *time.Time `json:"to"`
+	WorkspaceID *int       `json:"workspace_id"`
+}
+
+// FlowTimeline lays out the dated flows active at some point between From
+// and To. Weeks start on Monday; the first and last may extend past the
+// range.
+type FlowTimeline struct {
+	From  time.Time      `json:"from"`
+	To    time.Time      `json:"to"`
+	Weeks []time.Time    `json:"weeks"`
+	Flows []TimelineFlow `json:"flows"`
+}
+
+// TimelineFlow is a flow placed on a timeline. Flows in the same lane never
+// overlap; flows sharing an overlap group are linked by a chain of
+// overlapping flows. CompletedTasks holds one count per week of the
+// timeline, of the flow's tasks completed in that week within the range.
+type TimelineFlow struct {
+	Flow
+	Lane           int         `json:"lane"`
+	OverlapGroup   int         `json:"overlap_group"`
+	CompletedTasks []WeekCount `json:"completed_tasks"`
+}
+
+type WeekCount struct {
+	Week  time.Time `json:"week"`
+	Count int       `json:"count"`
+}
+
+type WorkspaceStats struct {
+	TotalProjects  int `json:"total_projects"`
+	TotalGoals     int `json:"total_goals"`
+	TotalTasks     int `json:"total_tasks"`
+	CompletedTasks int `json:"completed_tasks"`
+	PendingTasks   int `json:"pending_tasks"`
+}
+
+type User struct {
+	ID           int       `json:"id" db:"id"`
+	Email        string    `json:"email" db:"email"`
+	Name         string    `json:"name" db:"name"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	Admin        bool      `json:"admin" db:"is_admin"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Credentials is the payload of the registration and login endpoints. Name
+// is only used when registering.
+type Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name,omitempty"`
+}
+
+type Session struct {
+	TokenHash []byte    `json:"-" db:"token_hash"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// APIToken is a personal access token. The token itself is only returned
+// when it is created; afterwards Prefix identifies it.
+type APIToken struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	TokenHash  []byte     `json:"-" db:"token_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// WorkspaceMember grants a user a role in a workspace. Email and Name are
+// read from the user for listings.
+type WorkspaceMember struct {
+	WorkspaceID int       `json:"workspace_id" db:"workspace_id"`
+	UserID      int       `json:"user_id" db:"user_id"`
+	Role        string    `json:"role" db:"role"`
+	Email       string    `json:"email" db:"email"`
+	Name        string    `json:"name" db:"name"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// AuditEvent records one write to an entity. Before and After hold JSON
+// objects of the fields that changed; Before is null for creates and After
+// for deletes. PreviousWorkspaceID is the workspace an update moved the
+// entity out of, if any.
+type AuditEvent struct {
+	ID                  int64           `json:"id" db:"id"`
+	ActorID             *int            `json:"actor_id" db:"actor_id"`
+	ActorEmail          string          `json:"actor_email,omitempty" db:"actor_email"`
+	WorkspaceID         *int            `json:"workspace_id" db:"workspace_id"`
+	PreviousWorkspaceID *int            `json:"previous_workspace_id,omitempty" db:"previous_workspace_id"`
+	EntityType          string          `json:"entity_type" db:"entity_type"`
+	EntityID            int             `json:"entity_id" db:"entity_id"`
+	Action              string          `json:"action" db:"action"`
+	Before              json.RawMessage `json:"before" db:"before"`
+	After               json.RawMessage `json:"after" db:"after"`
+	RequestID           string          `json:"request_id,omitempty" db:"request_id"`
+	CreatedAt           time.Time       `json:"created_at" db:"created_at"`
+}
+
+// FlowRelationship links two flows. Supports and precedes read from FlowID
+// to RelatedFlowID; conflicts_with and overlaps hold both ways.
+type FlowRelationship struct {
+	ID            int       `json:"id" db:"id"`
+	FlowID        int       `json:"flow_id" db:"flow_id"`
+	RelatedFlowID int       `json:"related_flow_id" db:"related_flow_id"`
+	Type          string    `json:"type" db:"relationship_type"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// FlowConstraints are the rules a flow imposes while it is active.
+// QuietStart and QuietEnd are HH:MM clock times bounding the hours in which
+// tasks and goals of conflicting flows should not be due; the window may
+// wrap past midnight. MaxWeeklyTasks and ForbiddenTags apply to the flow's
+// own entities.
+type FlowConstraints struct {
+	FlowID         int      `json:"flow_id" db:"flow_id"`
+	QuietStart     *string  `json:"quiet_start" db:"quiet_start"`
+	QuietEnd       *string  `json:"quiet_end" db:"quiet_end"`
+	MaxWeeklyTasks *int     `json:"max_weekly_tasks" db:"max_weekly_tasks"`
+	ForbiddenTags  []string `json:"forbidden_tags" db:"forbidden_tags"`
+}
+
+// FlowWarning reports a rule of an active flow that an entity breaks, or a
+// pair of active flows declared in conflict. Rule is one of conflicts_with,
+// quiet_hours, max_weekly_tasks and forbidden_tag.
+type FlowWarning struct {
+	Rule          string `json:"rule"`
+	FlowID        int    `json:"flow_id"`
+	FlowTitle     string `json:"flow_title"`
+	RelatedFlowID *int   `json:"related_flow_id,omitempty"`
+	EntityType    string `json:"entity_type,omitempty"`
+	EntityID      int    `json:"entity_id,omitempty"`
+	Message       string `json:"message"`
+}
+
+// FlowTransition records a change of a flow's status.
+type FlowTransition struct {
+	ID         int       `json:"id" db:"id"`
+	FlowID     int       `json:"flow_id" db:"flow_id"`
+	FromStatus string    `json:"from_status" db:"from_status"`
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	Reason     string    `json:"reason,omitempty" db:"reason"`
+	ActorID    *int      `json:"actor_id" db:"actor_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,227 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-goal/internal/auth"
+)
+
+// ErrForbidden is returned when the signed-in user lacks the workspace role
+// a write needs.
+var ErrForbidden = errors.New("forbidden")
+
+// Workspace access is enforced here rather than in the handlers and
+// resolvers, so no query can forget it: when the context carries a user
+// (see auth.WithUser) reads only return rows of workspaces the user is a
+// member of, and writes check the user's role. Contexts without a user,
+// such as those of the CLI commands, are unrestricted.
+//
+// The expressions below give the workspace of a row of each table. Goals,
+// tasks and notes inherit it from the entities they belong to.
+const (
+	projectWorkspace = `projects.workspace_id`
+	flowWorkspace    = `flows.workspace_id`
+	goalWorkspace    = `COALESCE(
+		(SELECT workspace_id FROM projects WHERE id = goals.project_id),
+		(SELECT workspace_id FROM flows WHERE id = goals.flow_id))`
+	taskWorkspace = `COALESCE(
+		(SELECT ` + goalWorkspace + ` FROM goals WHERE id = tasks.goal_id),
+		(SELECT workspace_id FROM projects WHERE id = tasks.project_id),
+		(SELECT workspace_id FROM flows WHERE id = tasks.flow_id))`
+	noteWorkspace = `CASE notes.entity_type
+		WHEN 'workspace' THEN notes.entity_id
+		WHEN 'project' THEN (SELECT workspace_id FROM projects WHERE id = notes.entity_id)
+		WHEN 'flow' THEN (SELECT workspace_id FROM flows WHERE id = notes.entity_id)
+		WHEN 'goal' THEN (SELECT ` + goalWorkspace + ` FROM goals WHERE id = notes.entity_id)
+		WHEN 'task' THEN (SELECT ` + taskWorkspace + ` FROM tasks WHERE id = notes.entity_id)
+	END`
+)
+
+// The placement queries select the workspace a goal or task would belong to
+// given its parent IDs: goalPlacement takes the project and flow IDs,
+// taskPlacement the goal, project and flow IDs. They read the first parent
+// set only; writes check with placement that all parents agree.
+const (
+	goalPlacement = `SELECT COALESCE(
+		(SELECT workspace_id FROM projects WHERE id = $2),
+		(SELECT workspace_id FROM flows WHERE id = $3))`
+	taskPlacement = `SELECT COALESCE(
+		(SELECT ` + goalWorkspace + ` FROM goals WHERE id = $2),
+		(SELECT workspace_id FROM projects WHERE id = $3),
+		(SELECT workspace_id FROM flows WHERE id = $4))`
+)
+
+// memberOf returns a condition matching rows whose workspace, given by the
+// SQL expression workspace, has the user bound to its %d verb as a member.
+func memberOf(workspace string) string {
+	return workspace + ` IN (SELECT workspace_id FROM workspace_members WHERE user_id = $%d)`
+}
+
+// restrict limits where to rows of the workspaces the user of ctx is a
+// member of.
+func restrict(ctx context.Context, where *conditions, workspace string) {
+	if u := auth.UserFrom(ctx); u != nil {
+		where.add(memberOf(workspace), u.ID)
+	}
+}
+
+// restrictNotes is restrict for notes. Notes attached to no entity belong
+// to no workspace and are shared by all users.
+func restrictNotes(ctx context.Context, where *conditions) {
+	if u := auth.UserFrom(ctx); u != nil {
+		where.add("(COALESCE(notes.entity_type, '') = '' OR "+memberOf(noteWorkspace)+")", u.ID)
+	}
+}
+
+// byID returns the condition selecting the row with id, if the user of ctx
+// may see it.
+func byID(ctx context.Context, id int, workspace string) conditions {
+	var where conditions
+	where.add("id = $%d", id)
+	restrict(ctx, &where, workspace)
+	return where
+}
+
+// entityWorkspace returns a query selecting the workspace of the entity of
+// entityType whose ID is bound to $2.
+func entityWorkspace(entityType string) (string, error) {
+	switch entityType {
+	case "workspace":
+		return `SELECT id FROM workspaces WHERE id = $2`, nil
+	case "project":
+		return `SELECT workspace_id FROM projects WHERE id = $2`, nil
+	case "flow":
+		return `SELECT workspace_id FROM flows WHERE id = $2`, nil
+	case "goal":
+		return `SELECT ` + goalWorkspace + ` FROM goals WHERE id = $2`, nil
+	case "task":
+		return `SELECT ` + taskWorkspace + ` FROM tasks WHERE id = $2`, nil
+	case "note":
+		return `SELECT ` + noteWorkspace + ` FROM notes WHERE id = $2`, nil
+	}
+	return "", ErrInvalidEntityType
+}
+
+// workspaceRole returns the workspace selected by the scalar query
+// workspace, whose arguments are numbered from $2, and the role of the user
+// of ctx there. workspaceID is nil when the query selects no workspace; role
+// is empty for non-members.
+func workspaceRole(ctx context.Context, db querier, workspace string, args ...any) (workspaceID *int, role string, err error) {
+	var r sql.NullString
+	err = db.QueryRowContext(ctx, `
+		SELECT w.id, m.role
+		FROM (SELECT (`+workspace+`) AS id) w
+		LEFT JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = $1
+	`, append([]any{auth.UserFrom(ctx).ID}, args...)...).Scan(&workspaceID, &r)
+	return workspaceID, r.String, err
+}
+
+// parent is a reference from a row to a row it belongs to: the referencing
+// column, the entity type of the parent and its ID, if set.
+type parent struct {
+	column     string
+	entityType string
+	id         *int
+}
+
+// placement returns the workspace of the parents of a row, or nil when none
+// is set. Every parent set must be visible to the user of ctx and lie in the
+// same workspace as the others; otherwise placement fails with an invalid
+// reference to its column, so that a write can neither link workspaces nor
+// probe for the IDs of others.
+func placement(ctx context.Context, db querier, parents ...parent) (*int, error) {
+	if auth.UserFrom(ctx) == nil {
+		return nil, nil
+	}
+	var workspaceID *int
+	for _, p := range parents {
+		if p.id == nil {
+			continue
+		}
+		query, err := entityWorkspace(p.entityType)
+		if err != nil {
+			return nil, err
+		}
+		ws, role, err := workspaceRole(ctx, db, query, *p.id)
+		switch {
+		case err != nil:
+			return nil, err
+		case role == "":
+			return nil, &ConstraintError{Kind: ErrInvalidReference, Column: p.column, Detail: p.entityType + " not found"}
+		case workspaceID != nil && *ws != *workspaceID:
+			return nil, &ConstraintError{Kind: ErrInvalidReference, Column: p.column, Detail: p.entityType + " belongs to another workspace"}
+		}
+		workspaceID = ws
+	}
+	return workspaceID, nil
+}
+
+// authorize returns ErrForbidden unless the user of ctx holds at least role
+// in the workspace selected by workspace. It guards placing a row into a
+// workspace; a row that would belong to none is forbidden too.
+func authorize(ctx context.Context, db querier, role, workspace string, args ...any) error {
+	if auth.UserFrom(ctx) == nil {
+		return nil
+	}
+	_, have, err := workspaceRole(ctx, db, workspace, args...)
+	if err != nil {
+		return err
+	}
+	if !auth.HasRole(have, role) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// requireAdmin returns ErrForbidden unless the user of ctx is an admin. It
+// guards the rows all workspaces share, such as tags.
+func requireAdmin(ctx context.Context) error {
+	if u := auth.UserFrom(ctx); u != nil && !u.Admin {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// authorizeRow is authorize for an existing row. Rows the user cannot see,
+// including missing ones, are reported as ErrNotFound just as reads do.
+func authorizeRow(ctx context.Context, db querier, role, workspace string, args ...any) error {
+	if auth.UserFrom(ctx) == nil {
+		return nil
+	}
+	_, have, err := workspaceRole(ctx, db, workspace, args...)
+	switch {
+	case err != nil:
+		return err
+	case have == "":
+		return ErrNotFound
+	case !auth.HasRole(have, role):
+		return ErrForbidden
+	}
+	return nil
+}
+
+// authorizeEntity is authorizeRow for the entity of entityType with id.
+// Notes attached to no entity are shared, so anyone may change them.
+func authorizeEntity(ctx context.Context, db querier, role, entityType string, id int) error {
+	if auth.UserFrom(ctx) == nil {
+		return nil
+	}
+	workspace, err := entityWorkspace(entityType)
+	if err != nil {
+		return err
+	}
+	workspaceID, have, err := workspaceRole(ctx, db, workspace, id)
+	switch {
+	case err != nil:
+		return err
+	case workspaceID == nil && entityType == "note":
+		return nil
+	case have == "":
+		return ErrNotFound
+	case !auth.HasRole(have, role):
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/logging"
+	"go-goal/internal/models"
+)
+
+// Audit actions. Tagging is recorded against the tagged entity.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionTag    = "tag"
+	ActionUntag  = "untag"
+)
+
+// Like access control, auditing is keyed on the user of the context: every
+// write made for a signed-in user, which covers all writes through the REST
+// and GraphQL APIs, is recorded in audit_events by the store making it, in
+// the transaction of the write.
+// Writes of contexts without a user, such as those of the CLI commands, are
+// not recorded.
+
+// AuditFilter narrows the result of AuditStore.ListPage. Nil fields are
+// ignored.
+type AuditFilter struct {
+	EntityType  *string
+	EntityID    *int
+	ActorID     *int
+	WorkspaceID *int
+	Action      *string
+}
+
+type AuditStore interface {
+	// ListPage returns one keyset page of the events matching filter,
+	// ordered by page.Sort, which defaults to "-created_at". Events of
+	// workspaces the user of ctx is not a member of are left out; an update
+	// that moved an entity between workspaces belongs to both.
+	ListPage(ctx context.Context, filter AuditFilter, page Page) (PageResult[models.AuditEvent], error)
+	// Count returns the number of events matching filter.
+	Count(ctx context.Context, filter AuditFilter) (int, error)
+}
+
+const auditColumns = `id, actor_id, COALESCE((SELECT email FROM users WHERE id = audit_events.actor_id), ''), workspace_id,
+	previous_workspace_id, entity_type, entity_id, action, before, after, COALESCE(request_id, ''), created_at`
+
+// auditSorts are the fields ListPage can order by.
+var auditSorts = sortKeys[models.AuditEvent]{
+	"created_at": {expr: "created_at", cast: "timestamp", key: func(e *models.AuditEvent) string { return timeKey(&e.CreatedAt) }},
+}
+
+type auditStore struct {
+	db *sql.DB
+}
+
+func scanAuditEvent(s scanner) (models.AuditEvent, error) {
+	var e models.AuditEvent
+	var before, after []byte
+	err := s.Scan(&e.ID, &e.ActorID, &e.ActorEmail, &e.WorkspaceID, &e.PreviousWorkspaceID, &e.EntityType, &e.EntityID, &e.Action, &before, &after, &e.RequestID, &e.CreatedAt)
+	if before != nil {
+		e.Before = before
+	}
+	if after != nil {
+		e.After = after
+	}
+	return e, err
+}
+
+// conditions returns the WHERE clauses of f, limited to events of no
+// workspace and of the workspaces of the user of ctx. An event matches both
+// its workspace and the one the entity was moved out of.
+func (f AuditFilter) conditions(ctx context.Context) conditions {
+	var where conditions
+	if f.EntityType != nil {
+		where.add("entity_type = $%d", *f.EntityType)
+	}
+	if f.EntityID != nil {
+		where.add("entity_id = $%d", *f.EntityID)
+	}
+	if f.ActorID != nil {
+		where.add("actor_id = $%d", *f.ActorID)
+	}
+	if f.WorkspaceID != nil {
+		where.add("(workspace_id = $%[1]d OR previous_workspace_id = $%[1]d)", *f.WorkspaceID)
+	}
+	if f.Action != nil {
+		where.add("action = $%d", *f.Action)
+	}
+
+	if u := auth.UserFrom(ctx); u != nil {
+		where.add(`(audit_events.workspace_id IS NULL OR EXISTS (
+			SELECT 1 FROM workspace_members WHERE user_id = $%d
+			AND workspace_id IN (audit_events.workspace_id, audit_events.previous_workspace_id)))`, u.ID)
+	}
+
+	return where
+}
+
+func (s *auditStore) ListPage(ctx context.Context, filter AuditFilter, page Page) (PageResult[models.AuditEvent], error) {
+	keys, err := auditSorts.resolve(page.Sort, "-created_at")
+	if err != nil {
+		return PageResult[models.AuditEvent]{}, err
+	}
+	where := filter.conditions(ctx)
+	order, err := keys.paginate(&where, page)
+	if err != nil {
+		return PageResult[models.AuditEvent]{}, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+auditColumns+` FROM audit_events`+where.String()+order, where.args...)
+	if err != nil {
+		return PageResult[models.AuditEvent]{}, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return PageResult[models.AuditEvent]{}, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return PageResult[models.AuditEvent]{}, err
+	}
+	return keys.finish(events, page, func(e *models.AuditEvent) int { return int(e.ID) }), nil
+}
+
+func (s *auditStore) Count(ctx context.Context, filter AuditFilter) (int, error) {
+	where := filter.conditions(ctx)
+
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_events`+where.String(), where.args...).Scan(&n)
+	return n, err
+}
+
+// snapshot returns the row selected by query as it is before a write in
+// tx, for record, or nil when writes of ctx are not audited. query should
+// lock the row FOR UPDATE so that no other write slips in between.
+func snapshot[T any](ctx context.Context, tx *sql.Tx, scan func(scanner) (T, error), query string, args ...any) (*T, error) {
+	if auth.UserFrom(ctx) == nil {
+		return nil, nil
+	}
+	row, err := scan(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &row, nil
+}
+
+// auditedMember is the part of a workspace membership the audit log
+// records.
+type auditedMember struct {
+	WorkspaceID int    `json:"workspace_id"`
+	UserID      int    `json:"user_id"`
+	Role        string `json:"role"`
+}
+
+// auditedTag is the after or before of a tag or untag event.
+type auditedTag struct {
+	TagID int `json:"tag_id"`
+}
+
+// record appends an event for a create, update or delete of the row given
+// as after, before or both to tx, the transaction of the write, if writes
+// of ctx are audited. Updates that changed nothing are not recorded, and
+// updates that moved the row to another workspace record the one it left.
+func record(ctx context.Context, tx *sql.Tx, action string, before, after any) error {
+	if auth.UserFrom(ctx) == nil {
+		return nil
+	}
+	row := after
+	if action == ActionDelete {
+		row = before
+	}
+	entityType, entityID, workspaceID, err := describe(ctx, tx, row)
+	if err != nil {
+		return err
+	}
+
+	var old, updated []byte
+	switch action {
+	case ActionCreate:
+		updated, err = fields(after)
+	case ActionDelete:
+		old, err = fields(before)
+	default:
+		old, updated, err = diff(before, after)
+		if old == nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+	var previousID *int
+	if action == ActionUpdate {
+		if previousID, err = previousWorkspace(ctx, tx, before, workspaceID); err != nil {
+			return err
+		}
+	}
+	return insertEvent(ctx, tx, action, entityType, entityID, workspaceID, previousID, old, updated)
+}
+
+// previousWorkspace returns the workspace of before, the row as it was
+// before an update, if it is not workspaceID, the workspace of the row
+// after the update, and nil otherwise.
+func previousWorkspace(ctx context.Context, db querier, before any, workspaceID *int) (*int, error) {
+	_, _, previousID, err := describe(ctx, db, before)
+	if err != nil || previousID == nil || (workspaceID != nil && *previousID == *workspaceID) {
+		return nil, err
+	}
+	return previousID, nil
+}
+
+// recordTagging appends a tag or untag event for the entity of entityType
+// with entityID to tx, if writes of ctx are audited.
+func recordTagging(ctx context.Context, tx *sql.Tx, action, entityType string, entityID, tagID int) error {
+	if auth.UserFrom(ctx) == nil {
+		return nil
+	}
+	workspaceID, err := entityWorkspaceID(ctx, tx, entityType, entityID)
+	if err != nil {
+		return err
+	}
+	tag, err := json.Marshal(auditedTag{TagID: tagID})
+	if err != nil {
+		return err
+	}
+	var old, updated []byte
+	if action == ActionTag {
+		updated = tag
+	} else {
+		old = tag
+	}
+	return insertEvent(ctx, tx, action, entityType, entityID, workspaceID, nil, old, updated)
+}
+
+func insertEvent(ctx context.Context, tx *sql.Tx, action, entityType string, entityID int, workspaceID, previousWorkspaceID *int, before, after []byte) error {
+	var requestID *string
+	if id := logging.RequestID(ctx); id != "" {
+		requestID = &id
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_events (actor_id, workspace_id, previous_workspace_id, entity_type, entity_id, action, before, after, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, auth.UserFrom(ctx).ID, workspaceID, previousWorkspaceID, entityType, entityID, action, jsonb(before), jsonb(after), requestID)
+	return err
+}
+
+// jsonb passes a JSON document as a query argument, or NULL when empty.
+func jsonb(doc []byte) any {
+	if doc == nil {
+		return nil
+	}
+	return string(doc)
+}
+
+// describe returns the entity type, ID and workspace of a row passed to
+// record.
+func describe(ctx context.Context, db querier, row any) (entityType string, id int, workspaceID *int, err error) {
+	switch r := row.(type) {
+	case *models.Project:
+		return "project", r.ID, r.WorkspaceID, nil
+	case *models.Flow:
+		return "flow", r.ID, &r.WorkspaceID, nil
+	case *models.FlowRelationship:
+		workspaceID, _, err = workspaceRole(ctx, db, `SELECT workspace_id FROM flows WHERE id = $2`, r.FlowID)
+		return "flow_relationship", r.ID, workspaceID, err
+	case *models.FlowConstraints:
+		workspaceID, _, err = workspaceRole(ctx, db, `SELECT workspace_id FROM flows WHERE id = $2`, r.FlowID)
+		return "flow_constraints", r.FlowID, workspaceID, err
+	case *models.Workspace:
+		return "workspace", r.ID, &r.ID, nil
+	case *models.Tag:
+		return "tag", r.ID, nil, nil
+	case *auditedMember:
+		return "member", r.UserID, &r.WorkspaceID, nil
+	case *models.Goal:
+		workspaceID, _, err = workspaceRole(ctx, db, goalPlacement, r.ProjectID, r.FlowID)
+		return "goal", r.ID, workspaceID, err
+	case *models.Task:
+		workspaceID, _, err = workspaceRole(ctx, db, taskPlacement, r.GoalID, r.ProjectID, r.FlowID)
+		return "task", r.ID, workspaceID, err
+	case *models.Note:
+		if r.EntityType != "" && r.EntityID != nil {
+			workspaceID, err = entityWorkspaceID(ctx, db, r.EntityType, *r.EntityID)
+		}
+		return "note", r.ID, workspaceID, err
+	}
+	return "", 0, nil, fmt.Errorf("store: cannot audit %T", row)
+}
+
+// entityWorkspaceID returns the workspace of the entity of entityType with
+// id, or nil if it belongs to none.
+func entityWorkspaceID(ctx context.Context, db querier, entityType string, id int) (*int, error) {
+	workspace, err := entityWorkspace(entityType)
+	if err != nil {
+		return nil, err
+	}
+	workspaceID, _, err := workspaceRole(ctx, db, workspace, id)
+	return workspaceID, err
+}
+
+// fields returns row as a JSON object without its timestamps, which the
+// event records itself.
+func fields(row any) ([]byte, error) {
+	m, err := jsonFields(row)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+// diff returns the fields that differ between before and after, or nils if
+// none do.
+func diff(before, after any) ([]byte, []byte, error) {
+	old, err := jsonFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	updated, err := jsonFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range updated {
+		if bytes.Equal(v, old[k]) {
+			delete(old, k)
+			delete(updated, k)
+		}
+	}
+	if len(updated) == 0 {
+		return nil, nil, nil
+	}
+	o, err := json.Marshal(old)
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := json.Marshal(updated)
+	return o, u, err
+}
+
+func jsonFields(row any) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	delete(m, "created_at")
+	delete(m, "updated_at")
+	return m, nil
+}
@@ -0,0 +1,911 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrInvalidTransition is returned when a flow cannot move from its status
+// to the one asked for.
+var ErrInvalidTransition = errors.New("invalid flow status transition")
+
+// ErrCycle is returned, wrapped in a ConstraintError, when a write would make
+// a flow its own ancestor or have it precede itself.
+var ErrCycle = errors.New("cycle")
+
+// flowGraphLockID is the key of the Postgres advisory lock held until commit
+// by every write checking the flow hierarchy or the precedes relationships
+// for cycles. Locking the flows an edge joins is not enough on its own: two
+// writes can close a cycle through flows neither of them touches.
+const flowGraphLockID = 7_430_552_191
+
+// flowTransitions lists the statuses a flow may move to from each status.
+// Archived flows are final.
+var flowTransitions = map[string][]string{
+	"active":      {"dormant", "paused", "conflicting", "completed", "celebrated", "cancelled"},
+	"dormant":     {"active", "paused", "completed", "cancelled"},
+	"paused":      {"active", "dormant", "completed", "cancelled"},
+	"conflicting": {"active", "paused", "cancelled"},
+	"completed":   {"active", "celebrated", "archived"},
+	"celebrated":  {"archived"},
+	"cancelled":   {"active", "archived"},
+	"archived":    {},
+}
+
+// checkTransition reports whether a flow may move from one status to
+// another.
+func checkTransition(from, to string) error {
+	if from == to {
+		return fmt.Errorf("%w: the flow is already %s", ErrInvalidTransition, to)
+	}
+	if !slices.Contains(flowTransitions[from], to) {
+		return fmt.Errorf("%w: a flow cannot go from %s to %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// FlowFilter narrows the result of FlowStore.List. Nil fields are ignored.
+type FlowFilter struct {
+	WorkspaceID *int
+	ParentID    *int
+	Status      *string
+	Search      *string
+	// IDs and ParentIDs match any of the listed values; loaders use them to
+	// fetch many rows in one query.
+	IDs       []int
+	ParentIDs []int
+	// StartsBefore and EndsAfter match flows whose span overlaps a period:
+	// flows with a start_date before StartsBefore and an end_date, if any,
+	// on or after EndsAfter.
+	StartsBefore *time.Time
+	EndsAfter    *time.Time
+}
+
+type FlowStore interface {
+	List(ctx context.Context, filter FlowFilter) ([]models.Flow, error)
+	// ListPage returns one keyset page of List ordered by page.Sort, which
+	// defaults to "-created_at".
+	ListPage(ctx context.Context, filter FlowFilter, page Page) (PageResult[models.Flow], error)
+	// Count returns the number of rows matching filter.
+	Count(ctx context.Context, filter FlowFilter) (int, error)
+	Get(ctx context.Context, id int) (*models.Flow, error)
+	// Create and Update need a parent flow, if any, the user of ctx can
+	// see.
+	Create(ctx context.Context, f *models.Flow) error
+	// Update fails with ErrInvalidTransition when the status changes in a
+	// way the flow lifecycle does not allow, and records allowed changes
+	// in the transition history. It fails with ErrCycle when the parent is
+	// the flow itself or one of its descendants, visible or not.
+	Update(ctx context.Context, f *models.Flow) error
+	Delete(ctx context.Context, id int) error
+	// Transition moves a flow to status to, recording reason in its
+	// transition history, and returns the updated flow. It needs the
+	// editor role.
+	Transition(ctx context.Context, id int, to, reason string) (*models.Flow, error)
+	// ListTransitions returns the status changes of a flow, oldest first.
+	ListTransitions(ctx context.Context, flowID int) ([]models.FlowTransition, error)
+	// Stats counts the projects, goals and tasks attached to a flow and to
+	// the flows nested under it.
+	Stats(ctx context.Context, id int) (*models.FlowStats, error)
+	// ListStats returns Stats for each of the listed flows the user of ctx
+	// can see, keyed by flow ID, in one query.
+	ListStats(ctx context.Context, flowIDs []int) (map[int]models.FlowStats, error)
+	// Tree returns the flows of a workspace, or of all workspaces the user
+	// can see when workspaceID is nil, nested under their parents with
+	// rolled-up stats.
+	Tree(ctx context.Context, workspaceID *int) ([]models.FlowNode, error)
+	// Timeline lays out the dated flows overlapping r in lanes and overlap
+	// groups, with weekly counts of their completed tasks. Tasks count as
+	// completed when they were last updated. r needs From and To.
+	Timeline(ctx context.Context, r models.TimelineRange) (*models.FlowTimeline, error)
+	// ListRelationships returns the relationships of the listed flows in
+	// either direction, oldest first, leaving out those with a flow the
+	// user of ctx cannot see.
+	ListRelationships(ctx context.Context, flowIDs []int) ([]models.FlowRelationship, error)
+	// CreateRelationship needs the editor role on the flow and a related
+	// flow the user can see. It fails with ErrCycle when a precedes
+	// relationship would lead back to the flow.
+	CreateRelationship(ctx context.Context, r *models.FlowRelationship) error
+	// DeleteRelationship removes a relationship of flowID in either
+	// direction.
+	DeleteRelationship(ctx context.Context, flowID, id int) error
+	// GetConstraints returns the constraints of a flow; a flow without any
+	// has empty ones.
+	GetConstraints(ctx context.Context, flowID int) (*models.FlowConstraints, error)
+	// ListConstraints returns the constraints set on the listed flows,
+	// keyed by flow ID.
+	ListConstraints(ctx context.Context, flowIDs []int) (map[int]models.FlowConstraints, error)
+	// SetConstraints replaces the constraints of a flow. It needs the
+	// editor role.
+	SetConstraints(ctx context.Context, c *models.FlowConstraints) error
+}
+
+const flowTransitionColumns = `id, flow_id, from_status, to_status, COALESCE(reason, ''), actor_id, created_at`
+
+const flowRelationshipColumns = `id, flow_id, related_flow_id, relationship_type, created_at`
+
+const flowConstraintColumns = `to_char(c.quiet_start, 'HH24:MI'), to_char(c.quiet_end, 'HH24:MI'), c.max_weekly_tasks, COALESCE(c.forbidden_tags, '{}')`
+
+const flowColumns = `id, title, COALESCE(description, ''), COALESCE(color, ''), COALESCE(status, ''), start_date, end_date, parent_id, workspace_id, created_at, updated_at`
+
+// flowSorts are the fields ListPage can order by.
+var flowSorts = sortKeys[models.Flow]{
+	"start_date": {expr: "COALESCE(start_date, 'infinity')", cast: "timestamp", key: func(f *models.Flow) string { return timeKey(f.StartDate) }},
+	"end_date":   {expr: "COALESCE(end_date, 'infinity')", cast: "timestamp", key: func(f *models.Flow) string { return timeKey(f.EndDate) }},
+	"created_at": {expr: "created_at", cast: "timestamp", key: func(f *models.Flow) string { return timeKey(&f.CreatedAt) }},
+	"updated_at": {expr: "updated_at", cast: "timestamp", key: func(f *models.Flow) string { return timeKey(&f.UpdatedAt) }},
+	"title":      {expr: "title", cast: "text", key: func(f *models.Flow) string { return f.Title }},
+}
+
+type flowStore struct {
+	db *sql.DB
+}
+
+func scanFlowRelationship(s scanner) (models.FlowRelationship, error) {
+	var r models.FlowRelationship
+	err := s.Scan(&r.ID, &r.FlowID, &r.RelatedFlowID, &r.Type, &r.CreatedAt)
+	return r, err
+}
+
+func scanFlowConstraints(s scanner) (models.FlowConstraints, error) {
+	var c models.FlowConstraints
+	err := s.Scan(&c.FlowID, &c.QuietStart, &c.QuietEnd, &c.MaxWeeklyTasks, pq.Array(&c.ForbiddenTags))
+	return c, err
+}
+
+func scanFlowTransition(s scanner) (models.FlowTransition, error) {
+	var t models.FlowTransition
+	err := s.Scan(&t.ID, &t.FlowID, &t.FromStatus, &t.ToStatus, &t.Reason, &t.ActorID, &t.CreatedAt)
+	return t, err
+}
+
+func scanFlow(s scanner) (models.Flow, error) {
+	var f models.Flow
+	err := s.Scan(&f.ID, &f.Title, &f.Description, &f.Color, &f.Status, &f.StartDate, &f.EndDate, &f.ParentID, &f.WorkspaceID, &f.CreatedAt, &f.UpdatedAt)
+	return f, err
+}
+
+// conditions returns the WHERE clauses of f, limited to the workspaces of
+// the user of ctx.
+func (f FlowFilter) conditions(ctx context.Context) conditions {
+	var where conditions
+	if f.WorkspaceID != nil {
+		where.add("workspace_id = $%d", *f.WorkspaceID)
+	}
+	if f.ParentID != nil {
+		where.add("parent_id = $%d", *f.ParentID)
+	}
+	if f.IDs != nil {
+		where.add("id = ANY($%d)", pq.Array(f.IDs))
+	}
+	if f.ParentIDs != nil {
+		where.add("parent_id = ANY($%d)", pq.Array(f.ParentIDs))
+	}
+	if f.Status != nil {
+		where.add("status = $%d", *f.Status)
+	}
+	if f.Search != nil {
+		where.add("(title ILIKE $%[1]d OR description ILIKE $%[1]d)", likePattern(*f.Search))
+	}
+	if f.StartsBefore != nil {
+		where.add("start_date < $%d", *f.StartsBefore)
+	}
+	if f.EndsAfter != nil {
+		where.add("(end_date IS NULL OR end_date >= $%d)", *f.EndsAfter)
+	}
+
+	restrict(ctx, &where, flowWorkspace)
+
+	return where
+}
+
+func (s *flowStore) List(ctx context.Context, filter FlowFilter) ([]models.Flow, error) {
+	where := filter.conditions(ctx)
+	return s.query(ctx, `SELECT `+flowColumns+` FROM flows`+where.String()+` ORDER BY created_at DESC`, where.args...)
+}
+
+func (s *flowStore) ListPage(ctx context.Context, filter FlowFilter, page Page) (PageResult[models.Flow], error) {
+	keys, err := flowSorts.resolve(page.Sort, "-created_at")
+	if err != nil {
+		return PageResult[models.Flow]{}, err
+	}
+	where := filter.conditions(ctx)
+	order, err := keys.paginate(&where, page)
+	if err != nil {
+		return PageResult[models.Flow]{}, err
+	}
+
+	flows, err := s.query(ctx, `SELECT `+flowColumns+` FROM flows`+where.String()+order, where.args...)
+	if err != nil {
+		return PageResult[models.Flow]{}, err
+	}
+	return keys.finish(flows, page, func(f *models.Flow) int { return f.ID }), nil
+}
+
+func (s *flowStore) Count(ctx context.Context, filter FlowFilter) (int, error) {
+	where := filter.conditions(ctx)
+
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM flows`+where.String(), where.args...).Scan(&n)
+	return n, err
+}
+
+func (s *flowStore) query(ctx context.Context, query string, args ...any) ([]models.Flow, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	flows := []models.Flow{}
+	for rows.Next() {
+		f, err := scanFlow(rows)
+		if err != nil {
+			return nil, err
+		}
+		flows = append(flows, f)
+	}
+	return flows, rows.Err()
+}
+
+func (s *flowStore) Get(ctx context.Context, id int) (*models.Flow, error) {
+	where := byID(ctx, id, flowWorkspace)
+	f, err := scanFlow(s.db.QueryRowContext(ctx, `SELECT `+flowColumns+` FROM flows`+where.String(), where.args...))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &f, nil
+}
+
+func (s *flowStore) Create(ctx context.Context, f *models.Flow) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorize(ctx, tx, auth.RoleEditor, `SELECT $2::integer`, f.WorkspaceID); err != nil {
+			return err
+		}
+		if f.ParentID != nil {
+			if err := authorizeFlowRef(ctx, tx, "parent_id", *f.ParentID); err != nil {
+				return err
+			}
+		}
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO flows (title, description, color, status, start_date, end_date, parent_id, workspace_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, created_at, updated_at
+		`, f.Title, f.Description, f.Color, f.Status, f.StartDate, f.EndDate, f.ParentID, f.WorkspaceID).Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt)
+		if err != nil {
+			return dbError(err)
+		}
+		return record(ctx, tx, ActionCreate, nil, f)
+	})
+}
+
+func (s *flowStore) Update(ctx context.Context, f *models.Flow) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT workspace_id FROM flows WHERE id = $2`, f.ID); err != nil {
+			return err
+		}
+		if err := authorize(ctx, tx, auth.RoleEditor, `SELECT $2::integer`, f.WorkspaceID); err != nil {
+			return err
+		}
+		if f.ParentID != nil {
+			if err := authorizeFlowRef(ctx, tx, "parent_id", *f.ParentID); err != nil {
+				return err
+			}
+		}
+		from, err := lockStatus(ctx, tx, f.ID)
+		if err != nil {
+			return err
+		}
+		if f.ParentID != nil {
+			if err := checkParent(ctx, tx, f.ID, *f.ParentID); err != nil {
+				return err
+			}
+		}
+		before, err := snapshot(ctx, tx, scanFlow, `SELECT `+flowColumns+` FROM flows WHERE id = $1`, f.ID)
+		if err != nil {
+			return err
+		}
+		if from != f.Status {
+			if err := checkTransition(from, f.Status); err != nil {
+				return err
+			}
+		}
+		err = tx.QueryRowContext(ctx, `
+			UPDATE flows
+			SET title = $2, description = $3, color = $4, status = $5, start_date = $6, end_date = $7, parent_id = $8, workspace_id = $9
+			WHERE id = $1
+			RETURNING created_at, updated_at
+		`, f.ID, f.Title, f.Description, f.Color, f.Status, f.StartDate, f.EndDate, f.ParentID, f.WorkspaceID).Scan(&f.CreatedAt, &f.UpdatedAt)
+		if err != nil {
+			return notFound(err)
+		}
+		if from != f.Status {
+			if err := insertTransition(ctx, tx, f.ID, from, f.Status, ""); err != nil {
+				return err
+			}
+		}
+		return record(ctx, tx, ActionUpdate, before, f)
+	})
+}
+
+func (s *flowStore) Transition(ctx context.Context, id int, to, reason string) (*models.Flow, error) {
+	var f models.Flow
+	err := inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT workspace_id FROM flows WHERE id = $2`, id); err != nil {
+			return err
+		}
+		from, err := lockStatus(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanFlow, `SELECT `+flowColumns+` FROM flows WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if err := checkTransition(from, to); err != nil {
+			return err
+		}
+
+		f, err = scanFlow(tx.QueryRowContext(ctx, `UPDATE flows SET status = $2 WHERE id = $1 RETURNING `+flowColumns, id, to))
+		if err != nil {
+			return notFound(err)
+		}
+		if err := insertTransition(ctx, tx, id, from, to, reason); err != nil {
+			return err
+		}
+		return record(ctx, tx, ActionUpdate, before, &f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (s *flowStore) ListTransitions(ctx context.Context, flowID int) ([]models.FlowTransition, error) {
+	var where conditions
+	where.add("flow_id = $%d", flowID)
+	restrict(ctx, &where, "(SELECT workspace_id FROM flows WHERE id = flow_transitions.flow_id)")
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+flowTransitionColumns+` FROM flow_transitions`+where.String()+` ORDER BY created_at, id`, where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []models.FlowTransition{}
+	for rows.Next() {
+		t, err := scanFlowTransition(rows)
+		if err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+	return transitions, rows.Err()
+}
+
+// authorizeFlowRef checks that the user of ctx can see the flow with id a
+// write refers to in column. A flow they cannot see is reported like a
+// missing one, as the foreign key would.
+func authorizeFlowRef(ctx context.Context, db querier, column string, id int) error {
+	err := authorizeRow(ctx, db, auth.RoleViewer, `SELECT workspace_id FROM flows WHERE id = $2`, id)
+	if errors.Is(err, ErrNotFound) {
+		return &ConstraintError{Kind: ErrInvalidReference, Column: column, Detail: "flow not found"}
+	}
+	return err
+}
+
+// lockFlowGraph locks the flows with ids, in ID order so that writes locking
+// the same flows cannot deadlock, and then the flow graph as a whole.
+func lockFlowGraph(ctx context.Context, tx *sql.Tx, ids ...int) error {
+	_, err := tx.ExecContext(ctx, `SELECT id FROM flows WHERE id = ANY($1) ORDER BY id FOR UPDATE`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, flowGraphLockID)
+	return err
+}
+
+// checkParent fails with ErrCycle when parentID is the flow with id or one
+// of its descendants. It walks up from parentID through every flow, not
+// only those the user can see, and holds the flow graph lock until tx ends.
+func checkParent(ctx context.Context, tx *sql.Tx, id, parentID int) error {
+	if parentID == id {
+		return &ConstraintError{Kind: ErrCycle, Column: "parent_id", Detail: "must not refer to itself"}
+	}
+	if err := lockFlowGraph(ctx, tx, id, parentID); err != nil {
+		return err
+	}
+	var cycle bool
+	err := tx.QueryRowContext(ctx, `
+		WITH RECURSIVE ancestors(id) AS (
+			SELECT $1::integer
+			UNION
+			SELECT flows.parent_id FROM flows JOIN ancestors ON flows.id = ancestors.id
+			WHERE flows.parent_id IS NOT NULL
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)
+	`, parentID, id).Scan(&cycle)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return &ConstraintError{Kind: ErrCycle, Column: "parent_id", Detail: "must not be a descendant"}
+	}
+	return nil
+}
+
+// checkPrecedes fails with ErrCycle when the flow relatedID already
+// precedes the flow with id, directly or through other flows, visible or
+// not. It holds the flow graph lock until tx ends.
+func checkPrecedes(ctx context.Context, tx *sql.Tx, id, relatedID int) error {
+	if err := lockFlowGraph(ctx, tx, id, relatedID); err != nil {
+		return err
+	}
+	var cycle bool
+	err := tx.QueryRowContext(ctx, `
+		WITH RECURSIVE successors(id) AS (
+			SELECT $1::integer
+			UNION
+			SELECT r.related_flow_id FROM flow_relationships r JOIN successors ON r.flow_id = successors.id
+			WHERE r.relationship_type = 'precedes'
+		)
+		SELECT EXISTS (SELECT 1 FROM successors WHERE id = $2)
+	`, relatedID, id).Scan(&cycle)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return &ConstraintError{Kind: ErrCycle, Column: "related_flow_id", Detail: "must not create a cycle"}
+	}
+	return nil
+}
+
+// lockStatus returns the current status of a flow and locks the flow for
+// the rest of tx, so that no other transition can interleave between the
+// lifecycle check and the update.
+func lockStatus(ctx context.Context, tx *sql.Tx, id int) (string, error) {
+	var status string
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM flows WHERE id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+		return "", notFound(err)
+	}
+	return status, nil
+}
+
+// insertTransition appends a status change to the history of a flow,
+// attributed to the user of ctx if any.
+func insertTransition(ctx context.Context, db querier, flowID int, from, to, reason string) error {
+	var actorID *int
+	if u := auth.UserFrom(ctx); u != nil {
+		actorID = &u.ID
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO flow_transitions (flow_id, from_status, to_status, reason, actor_id)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+	`, flowID, from, to, reason, actorID)
+	return dbError(err)
+}
+
+func (s *flowStore) Delete(ctx context.Context, id int) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT workspace_id FROM flows WHERE id = $2`, id); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanFlow, `SELECT `+flowColumns+` FROM flows WHERE id = $1 FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if err := execDelete(tx.ExecContext(ctx, "DELETE FROM flows WHERE id = $1", id)); err != nil {
+			return err
+		}
+		return record(ctx, tx, ActionDelete, before, nil)
+	})
+}
+
+func (s *flowStore) Stats(ctx context.Context, id int) (*models.FlowStats, error) {
+	if err := authorizeRow(ctx, s.db, auth.RoleViewer, `SELECT workspace_id FROM flows WHERE id = $2`, id); err != nil {
+		return nil, err
+	}
+	stats, err := s.ListStats(ctx, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	st := stats[id]
+	return &st, nil
+}
+
+// flowStatsQuery rolls the counts of each flow in the subtree table up to
+// its root. subtree pairs every listed flow, as root, with itself and each
+// of its descendants; UNION rather than UNION ALL stops at any parent_id
+// cycle. The numbered verbs limit the flows, projects, goals and tasks
+// counted to the workspaces of the user, at every level of the hierarchy.
+const flowStatsQuery = `
+	WITH RECURSIVE subtree(root, id) AS (
+		SELECT id, id FROM flows WHERE id = ANY($1)%[1]s
+		UNION
+		SELECT subtree.root, flows.id FROM flows JOIN subtree ON flows.parent_id = subtree.id%[1]s
+	), counts AS (
+		SELECT f.id,
+			(SELECT COUNT(*) FROM projects WHERE flow_id = f.id%[2]s) AS projects,
+			(SELECT COUNT(*) FROM projects WHERE flow_id = f.id AND status = 'active'%[2]s) AS active_projects,
+			(SELECT COUNT(*) FROM goals WHERE flow_id = f.id%[3]s) AS goals,
+			(SELECT COUNT(*) FROM goals WHERE flow_id = f.id AND status = 'completed'%[3]s) AS completed_goals,
+			(SELECT COUNT(*) FROM tasks WHERE flow_id = f.id%[4]s) AS tasks,
+			(SELECT COUNT(*) FROM tasks WHERE flow_id = f.id AND status = 'completed'%[4]s) AS completed_tasks,
+			(SELECT COUNT(*) FROM tasks WHERE flow_id = f.id AND status = 'pending'%[4]s) AS pending_tasks
+		FROM flows f
+		WHERE f.id IN (SELECT id FROM subtree)
+	)
+	SELECT subtree.root,
+		SUM(counts.projects), SUM(counts.active_projects),
+		SUM(counts.goals), SUM(counts.completed_goals),
+		SUM(counts.tasks), SUM(counts.completed_tasks), SUM(counts.pending_tasks)
+	FROM subtree
+	JOIN counts ON counts.id = subtree.id
+	GROUP BY subtree.root`
+
+func (s *flowStore) ListStats(ctx context.Context, flowIDs []int) (map[int]models.FlowStats, error) {
+	args := []any{pq.Array(flowIDs)}
+	visible := func(workspace string) string { return "" }
+	if u := auth.UserFrom(ctx); u != nil {
+		args = append(args, u.ID)
+		visible = func(workspace string) string {
+			return " AND " + fmt.Sprintf(memberOf(workspace), len(args))
+		}
+	}
+	query := fmt.Sprintf(flowStatsQuery,
+		visible(flowWorkspace), visible(projectWorkspace), visible(goalWorkspace), visible(taskWorkspace))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[int]models.FlowStats, len(flowIDs))
+	for rows.Next() {
+		var id int
+		var st models.FlowStats
+		err := rows.Scan(&id, &st.TotalProjects, &st.ActiveProjects, &st.TotalGoals, &st.CompletedGoals,
+			&st.TotalTasks, &st.CompletedTasks, &st.PendingTasks)
+		if err != nil {
+			return nil, err
+		}
+		st.GoalCompletionPercent = percent(st.CompletedGoals, st.TotalGoals)
+		st.TaskCompletionPercent = percent(st.CompletedTasks, st.TotalTasks)
+		stats[id] = st
+	}
+	return stats, rows.Err()
+}
+
+func (s *flowStore) Tree(ctx context.Context, workspaceID *int) ([]models.FlowNode, error) {
+	flows, err := s.List(ctx, FlowFilter{WorkspaceID: workspaceID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(flows))
+	for i, f := range flows {
+		ids[i] = f.ID
+	}
+	stats, err := s.ListStats(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Flows whose parent is not in the list, because it is in another
+	// workspace or hidden from the user, are shown as roots.
+	listed := make(map[int]bool, len(flows))
+	for _, f := range flows {
+		listed[f.ID] = true
+	}
+	children := map[int][]models.Flow{}
+	var roots []models.Flow
+	for _, f := range flows {
+		if f.ParentID != nil && listed[*f.ParentID] {
+			children[*f.ParentID] = append(children[*f.ParentID], f)
+		} else {
+			roots = append(roots, f)
+		}
+	}
+
+	var build func(flows []models.Flow, seen map[int]bool) []models.FlowNode
+	build = func(flows []models.Flow, seen map[int]bool) []models.FlowNode {
+		nodes := make([]models.FlowNode, 0, len(flows))
+		for _, f := range flows {
+			if seen[f.ID] {
+				continue
+			}
+			seen[f.ID] = true
+			nodes = append(nodes, models.FlowNode{Flow: f, Stats: stats[f.ID], Children: build(children[f.ID], seen)})
+		}
+		return nodes
+	}
+	seen := map[int]bool{}
+	nodes := build(roots, seen)
+	// Flows only reachable through a parent_id cycle have no root; they
+	// are shown as roots rather than left out.
+	for _, f := range flows {
+		if !seen[f.ID] {
+			nodes = append(nodes, build([]models.Flow{f}, seen)...)
+		}
+	}
+	return nodes, nil
+}
+
+func (s *flowStore) Timeline(ctx context.Context, r models.TimelineRange) (*models.FlowTimeline, error) {
+	from, to := day(*r.From), day(*r.To)
+	end := to.AddDate(0, 0, 1)
+	flows, err := s.List(ctx, FlowFilter{WorkspaceID: r.WorkspaceID, StartsBefore: &end, EndsAfter: &from})
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &models.FlowTimeline{From: from, To: to, Weeks: []time.Time{}, Flows: make([]models.TimelineFlow, len(flows))}
+	for week := weekOf(from); !week.After(to); week = week.AddDate(0, 0, 7) {
+		timeline.Weeks = append(timeline.Weeks, week)
+	}
+
+	if len(flows) == 0 {
+		return timeline, nil
+	}
+
+	// Spans are clipped to the range, open-ended flows running to its end.
+	type span struct{ start, end time.Time }
+	spans := make([]span, len(flows))
+	for i, f := range flows {
+		spans[i] = span{start: day(*f.StartDate), end: to}
+		if f.EndDate != nil && day(*f.EndDate).Before(to) {
+			spans[i].end = day(*f.EndDate)
+		}
+		if spans[i].start.Before(from) {
+			spans[i].start = from
+		}
+	}
+	order := make([]int, len(flows))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if c := spans[a].start.Compare(spans[b].start); c != 0 {
+			return c
+		}
+		if c := spans[a].end.Compare(spans[b].end); c != 0 {
+			return c
+		}
+		return flows[a].ID - flows[b].ID
+	})
+
+	// Each flow takes the first lane free by its start. A flow starting
+	// after every flow of the current group has ended opens a new group.
+	var laneEnds []time.Time
+	group, groupEnd := -1, time.Time{}
+	for n, i := range order {
+		lane := slices.IndexFunc(laneEnds, func(end time.Time) bool { return end.Before(spans[i].start) })
+		if lane < 0 {
+			lane = len(laneEnds)
+			laneEnds = append(laneEnds, time.Time{})
+		}
+		laneEnds[lane] = spans[i].end
+		if n == 0 || spans[i].start.After(groupEnd) {
+			group++
+			groupEnd = spans[i].end
+		} else if spans[i].end.After(groupEnd) {
+			groupEnd = spans[i].end
+		}
+		timeline.Flows[n] = models.TimelineFlow{Flow: flows[i], Lane: lane, OverlapGroup: group}
+	}
+
+	ids := make([]int, len(flows))
+	for i, f := range flows {
+		ids[i] = f.ID
+	}
+	completed, err := s.completedByWeek(ctx, ids, from, end)
+	if err != nil {
+		return nil, err
+	}
+	for i := range timeline.Flows {
+		counts := make([]models.WeekCount, len(timeline.Weeks))
+		for j, week := range timeline.Weeks {
+			counts[j] = models.WeekCount{Week: week, Count: completed[timeline.Flows[i].ID][week]}
+		}
+		timeline.Flows[i].CompletedTasks = counts
+	}
+	return timeline, nil
+}
+
+// completedByWeek counts the completed tasks of the listed flows last
+// updated from from up to end, by flow and Monday of the week. Tasks of
+// workspaces the user is not a member of are left out, even when attached
+// to a flow they can see.
+func (s *flowStore) completedByWeek(ctx context.Context, flowIDs []int, from, end time.Time) (map[int]map[time.Time]int, error) {
+	var where conditions
+	where.add("flow_id = ANY($%d)", pq.Array(flowIDs))
+	where.add("status = $%d", "completed")
+	where.add("updated_at >= $%d", from)
+	where.add("updated_at < $%d", end)
+	restrict(ctx, &where, taskWorkspace)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT flow_id, date_trunc('week', updated_at), COUNT(*)
+		FROM tasks`+where.String()+`
+		GROUP BY 1, 2
+	`, where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[int]map[time.Time]int{}
+	for rows.Next() {
+		var flowID, n int
+		var week time.Time
+		if err := rows.Scan(&flowID, &week, &n); err != nil {
+			return nil, err
+		}
+		if counts[flowID] == nil {
+			counts[flowID] = map[time.Time]int{}
+		}
+		counts[flowID][day(week)] = n
+	}
+	return counts, rows.Err()
+}
+
+// day returns the date of t as midnight UTC.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// weekOf returns the Monday starting the week of t, as midnight UTC.
+func weekOf(t time.Time) time.Time {
+	d := day(t)
+	return d.AddDate(0, 0, -(int(d.Weekday())+6)%7)
+}
+
+// percent returns part as a percentage of total rounded to one decimal, or
+// 0 when total is 0.
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
+
+func (s *flowStore) ListRelationships(ctx context.Context, flowIDs []int) ([]models.FlowRelationship, error) {
+	var where conditions
+	where.add("(flow_id = ANY($%[1]d) OR related_flow_id = ANY($%[1]d))", pq.Array(flowIDs))
+	restrict(ctx, &where, "(SELECT workspace_id FROM flows WHERE id = flow_relationships.flow_id)")
+	restrict(ctx, &where, "(SELECT workspace_id FROM flows WHERE id = flow_relationships.related_flow_id)")
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+flowRelationshipColumns+` FROM flow_relationships`+where.String()+` ORDER BY created_at, id`, where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relationships := []models.FlowRelationship{}
+	for rows.Next() {
+		r, err := scanFlowRelationship(rows)
+		if err != nil {
+			return nil, err
+		}
+		relationships = append(relationships, r)
+	}
+	return relationships, rows.Err()
+}
+
+func (s *flowStore) CreateRelationship(ctx context.Context, r *models.FlowRelationship) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT workspace_id FROM flows WHERE id = $2`, r.FlowID); err != nil {
+			return err
+		}
+		if err := authorizeFlowRef(ctx, tx, "related_flow_id", r.RelatedFlowID); err != nil {
+			return err
+		}
+		if r.Type == "precedes" {
+			if err := checkPrecedes(ctx, tx, r.FlowID, r.RelatedFlowID); err != nil {
+				return err
+			}
+		}
+
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO flow_relationships (flow_id, related_flow_id, relationship_type)
+			VALUES ($1, $2, $3)
+			RETURNING id, created_at
+		`, r.FlowID, r.RelatedFlowID, r.Type).Scan(&r.ID, &r.CreatedAt)
+		if err != nil {
+			return dbError(err)
+		}
+		return record(ctx, tx, ActionCreate, nil, r)
+	})
+}
+
+func (s *flowStore) DeleteRelationship(ctx context.Context, flowID, id int) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT workspace_id FROM flows WHERE id = $2`, flowID); err != nil {
+			return err
+		}
+		r, err := scanFlowRelationship(tx.QueryRowContext(ctx, `
+			DELETE FROM flow_relationships
+			WHERE id = $1 AND $2 IN (flow_id, related_flow_id)
+			RETURNING `+flowRelationshipColumns, id, flowID))
+		if err != nil {
+			return notFound(err)
+		}
+		return record(ctx, tx, ActionDelete, &r, nil)
+	})
+}
+
+func (s *flowStore) GetConstraints(ctx context.Context, flowID int) (*models.FlowConstraints, error) {
+	where := byID(ctx, flowID, flowWorkspace)
+	c, err := scanFlowConstraints(s.db.QueryRowContext(ctx, `
+		SELECT flows.id, `+flowConstraintColumns+`
+		FROM flows
+		LEFT JOIN flow_constraints c ON c.flow_id = flows.id`+where.String(), where.args...))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &c, nil
+}
+
+func (s *flowStore) ListConstraints(ctx context.Context, flowIDs []int) (map[int]models.FlowConstraints, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.flow_id, `+flowConstraintColumns+`
+		FROM flow_constraints c
+		WHERE c.flow_id = ANY($1)
+	`, pq.Array(flowIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	constraints := make(map[int]models.FlowConstraints, len(flowIDs))
+	for rows.Next() {
+		c, err := scanFlowConstraints(rows)
+		if err != nil {
+			return nil, err
+		}
+		constraints[c.FlowID] = c
+	}
+	return constraints, rows.Err()
+}
+
+func (s *flowStore) SetConstraints(ctx context.Context, c *models.FlowConstraints) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT workspace_id FROM flows WHERE id = $2`, c.FlowID); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanFlowConstraints, `
+			SELECT flows.id, `+flowConstraintColumns+`
+			FROM flows
+			LEFT JOIN flow_constraints c ON c.flow_id = flows.id
+			WHERE flows.id = $1
+			FOR UPDATE OF flows`, c.FlowID)
+		if err != nil {
+			return err
+		}
+		if c.ForbiddenTags == nil {
+			c.ForbiddenTags = []string{}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO flow_constraints (flow_id, quiet_start, quiet_end, max_weekly_tasks, forbidden_tags)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (flow_id) DO UPDATE
+			SET quiet_start = EXCLUDED.quiet_start, quiet_end = EXCLUDED.quiet_end,
+				max_weekly_tasks = EXCLUDED.max_weekly_tasks, forbidden_tags = EXCLUDED.forbidden_tags
+		`, c.FlowID, c.QuietStart, c.QuietEnd, c.MaxWeeklyTasks, pq.Array(c.ForbiddenTags))
+		if err != nil {
+			return dbError(err)
+		}
+		return record(ctx, tx, ActionUpdate, before, c)
+	})
+}
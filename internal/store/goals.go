@@ -0,0 +1,249 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// GoalFilter narrows the result of GoalStore.List. Nil fields are ignored.
+type GoalFilter struct {
+	ProjectID *int
+	FlowID    *int
+	TagID     *int
+	Status    *string
+	Priority  *int
+	TagName   *string
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	Search    *string
+	// IDs, ProjectIDs and FlowIDs match any of the listed values; loaders
+	// use them to fetch many rows in one query.
+	IDs        []int
+	ProjectIDs []int
+	FlowIDs    []int
+}
+
+type GoalStore interface {
+	List(ctx context.Context, filter GoalFilter) ([]models.Goal, error)
+	// ListPage returns one keyset page of List ordered by page.Sort, which
+	// defaults to "-priority".
+	ListPage(ctx context.Context, filter GoalFilter, page Page) (PageResult[models.Goal], error)
+	// Count returns the number of rows matching filter.
+	Count(ctx context.Context, filter GoalFilter) (int, error)
+	// ListUpcoming returns goals due after today, soonest first, of the
+	// workspace with workspaceID if it is not nil.
+	ListUpcoming(ctx context.Context, workspaceID *int, limit int) ([]models.Goal, error)
+	Get(ctx context.Context, id int) (*models.Goal, error)
+	// Create and Update need the project and flow, if both are set, to share
+	// a workspace.
+	Create(ctx context.Context, g *models.Goal) error
+	Update(ctx context.Context, g *models.Goal) error
+	Delete(ctx context.Context, id int) error
+}
+
+const goalColumns = `id, title, COALESCE(description, ''), project_id, flow_id, COALESCE(status, ''), priority, due_date, created_at, updated_at`
+
+// goalSorts are the fields ListPage can order by.
+var goalSorts = sortKeys[models.Goal]{
+	"priority":   {expr: "priority", cast: "integer", key: func(g *models.Goal) string { return strconv.Itoa(g.Priority) }},
+	"due_date":   {expr: "COALESCE(due_date, 'infinity')", cast: "timestamp", key: func(g *models.Goal) string { return timeKey(g.DueDate) }},
+	"created_at": {expr: "created_at", cast: "timestamp", key: func(g *models.Goal) string { return timeKey(&g.CreatedAt) }},
+	"updated_at": {expr: "updated_at", cast: "timestamp", key: func(g *models.Goal) string { return timeKey(&g.UpdatedAt) }},
+	"title":      {expr: "title", cast: "text", key: func(g *models.Goal) string { return g.Title }},
+}
+
+type goalStore struct {
+	db *sql.DB
+}
+
+func scanGoal(s scanner) (models.Goal, error) {
+	var g models.Goal
+	err := s.Scan(&g.ID, &g.Title, &g.Description, &g.ProjectID, &g.FlowID, &g.Status, &g.Priority, &g.DueDate, &g.CreatedAt, &g.UpdatedAt)
+	return g, err
+}
+
+// conditions returns the WHERE clauses of f, limited to the workspaces of
+// the user of ctx.
+func (f GoalFilter) conditions(ctx context.Context) conditions {
+	var where conditions
+	if f.ProjectID != nil {
+		where.add("project_id = $%d", *f.ProjectID)
+	}
+	if f.FlowID != nil {
+		where.add("flow_id = $%d", *f.FlowID)
+	}
+	if f.TagID != nil {
+		where.add("id IN (SELECT goal_id FROM goal_tags WHERE tag_id = $%d)", *f.TagID)
+	}
+	if f.IDs != nil {
+		where.add("id = ANY($%d)", pq.Array(f.IDs))
+	}
+	if f.ProjectIDs != nil {
+		where.add("project_id = ANY($%d)", pq.Array(f.ProjectIDs))
+	}
+	if f.FlowIDs != nil {
+		where.add("flow_id = ANY($%d)", pq.Array(f.FlowIDs))
+	}
+	if f.Status != nil {
+		where.add("status = $%d", *f.Status)
+	}
+	if f.Priority != nil {
+		where.add("priority = $%d", *f.Priority)
+	}
+	if f.TagName != nil {
+		where.add("id IN (SELECT et.goal_id FROM goal_tags et JOIN tags t ON t.id = et.tag_id WHERE t.name = $%d)", *f.TagName)
+	}
+	if f.DueBefore != nil {
+		where.add("due_date < $%d", *f.DueBefore)
+	}
+	if f.DueAfter != nil {
+		where.add("due_date > $%d", *f.DueAfter)
+	}
+	if f.Search != nil {
+		where.add("(title ILIKE $%[1]d OR description ILIKE $%[1]d)", likePattern(*f.Search))
+	}
+
+	restrict(ctx, &where, goalWorkspace)
+
+	return where
+}
+
+func (s *goalStore) List(ctx context.Context, filter GoalFilter) ([]models.Goal, error) {
+	where := filter.conditions(ctx)
+	return s.query(ctx, `SELECT `+goalColumns+` FROM goals`+where.String()+` ORDER BY priority DESC, due_date ASC`, where.args...)
+}
+
+func (s *goalStore) ListPage(ctx context.Context, filter GoalFilter, page Page) (PageResult[models.Goal], error) {
+	keys, err := goalSorts.resolve(page.Sort, "-priority")
+	if err != nil {
+		return PageResult[models.Goal]{}, err
+	}
+	where := filter.conditions(ctx)
+	order, err := keys.paginate(&where, page)
+	if err != nil {
+		return PageResult[models.Goal]{}, err
+	}
+
+	goals, err := s.query(ctx, `SELECT `+goalColumns+` FROM goals`+where.String()+order, where.args...)
+	if err != nil {
+		return PageResult[models.Goal]{}, err
+	}
+	return keys.finish(goals, page, func(g *models.Goal) int { return g.ID }), nil
+}
+
+func (s *goalStore) Count(ctx context.Context, filter GoalFilter) (int, error) {
+	where := filter.conditions(ctx)
+
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM goals`+where.String(), where.args...).Scan(&n)
+	return n, err
+}
+
+func (s *goalStore) ListUpcoming(ctx context.Context, workspaceID *int, limit int) ([]models.Goal, error) {
+	where := conditions{clauses: []string{"due_date > CURRENT_DATE"}}
+	if workspaceID != nil {
+		where.add(goalWorkspace+" = $%d", *workspaceID)
+	}
+	restrict(ctx, &where, goalWorkspace)
+	return s.query(ctx, `SELECT `+goalColumns+` FROM goals`+where.String()+` ORDER BY due_date ASC LIMIT `+strconv.Itoa(limit), where.args...)
+}
+
+func (s *goalStore) query(ctx context.Context, query string, args ...any) ([]models.Goal, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := []models.Goal{}
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, g)
+	}
+	return goals, rows.Err()
+}
+
+func (s *goalStore) Get(ctx context.Context, id int) (*models.Goal, error) {
+	where := byID(ctx, id, goalWorkspace)
+	g, err := scanGoal(s.db.QueryRowContext(ctx, `SELECT `+goalColumns+` FROM goals`+where.String(), where.args...))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &g, nil
+}
+
+func (s *goalStore) Create(ctx context.Context, g *models.Goal) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		workspaceID, err := placement(ctx, tx, parent{"project_id", "project", g.ProjectID}, parent{"flow_id", "flow", g.FlowID})
+		if err != nil {
+			return err
+		}
+		if err := authorize(ctx, tx, auth.RoleEditor, `SELECT $2::integer`, workspaceID); err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO goals (title, description, project_id, flow_id, status, priority, due_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at, updated_at
+		`, g.Title, g.Description, g.ProjectID, g.FlowID, g.Status, g.Priority, g.DueDate).Scan(&g.ID, &g.CreatedAt, &g.UpdatedAt)
+		if err != nil {
+			return dbError(err)
+		}
+		return record(ctx, tx, ActionCreate, nil, g)
+	})
+}
+
+func (s *goalStore) Update(ctx context.Context, g *models.Goal) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT `+goalWorkspace+` FROM goals WHERE id = $2`, g.ID); err != nil {
+			return err
+		}
+		workspaceID, err := placement(ctx, tx, parent{"project_id", "project", g.ProjectID}, parent{"flow_id", "flow", g.FlowID})
+		if err != nil {
+			return err
+		}
+		if err := authorize(ctx, tx, auth.RoleEditor, `SELECT $2::integer`, workspaceID); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanGoal, `SELECT `+goalColumns+` FROM goals WHERE id = $1 FOR UPDATE`, g.ID)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx, `
+			UPDATE goals
+			SET title = $2, description = $3, project_id = $4, flow_id = $5, status = $6, priority = $7, due_date = $8
+			WHERE id = $1
+			RETURNING created_at, updated_at
+		`, g.ID, g.Title, g.Description, g.ProjectID, g.FlowID, g.Status, g.Priority, g.DueDate).Scan(&g.CreatedAt, &g.UpdatedAt)
+		if err != nil {
+			return notFound(err)
+		}
+		return record(ctx, tx, ActionUpdate, before, g)
+	})
+}
+
+func (s *goalStore) Delete(ctx context.Context, id int) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT `+goalWorkspace+` FROM goals WHERE id = $2`, id); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanGoal, `SELECT `+goalColumns+` FROM goals WHERE id = $1 FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if err := execDelete(tx.ExecContext(ctx, "DELETE FROM goals WHERE id = $1", id)); err != nil {
+			return err
+		}
+		return record(ctx, tx, ActionDelete, before, nil)
+	})
+}
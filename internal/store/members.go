@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+)
+
+// ErrLastOwner is returned when a change would leave a workspace without an
+// owner.
+var ErrLastOwner = errors.New("a workspace needs at least one owner")
+
+type MemberStore interface {
+	// List returns the members of a workspace, oldest first. It needs the
+	// viewer role.
+	List(ctx context.Context, workspaceID int) ([]models.WorkspaceMember, error)
+	// Role returns the role of userID in workspaceID, or ErrNotFound for
+	// non-members.
+	Role(ctx context.Context, workspaceID, userID int) (string, error)
+	// Add, Update and Remove need the owner role, except that members may
+	// always remove themselves.
+	Add(ctx context.Context, m *models.WorkspaceMember) error
+	Update(ctx context.Context, m *models.WorkspaceMember) error
+	Remove(ctx context.Context, workspaceID, userID int) error
+}
+
+const memberColumns = `m.workspace_id, m.user_id, m.role, u.email, COALESCE(u.name, ''), m.created_at`
+
+type memberStore struct {
+	db *sql.DB
+}
+
+func scanMember(s scanner) (models.WorkspaceMember, error) {
+	var m models.WorkspaceMember
+	err := s.Scan(&m.WorkspaceID, &m.UserID, &m.Role, &m.Email, &m.Name, &m.CreatedAt)
+	return m, err
+}
+
+func (s *memberStore) List(ctx context.Context, workspaceID int) ([]models.WorkspaceMember, error) {
+	if err := authorizeRow(ctx, s.db, auth.RoleViewer, workspaceRow, workspaceID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+memberColumns+`
+		FROM workspace_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = $1
+		ORDER BY m.created_at, m.user_id
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.WorkspaceMember{}
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (s *memberStore) Role(ctx context.Context, workspaceID, userID int) (string, error) {
+	var role string
+	err := s.db.QueryRowContext(ctx, `SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`, workspaceID, userID).Scan(&role)
+	if err != nil {
+		return "", notFound(err)
+	}
+	return role, nil
+}
+
+func (s *memberStore) Add(ctx context.Context, m *models.WorkspaceMember) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleOwner, workspaceRow, m.WorkspaceID); err != nil {
+			return err
+		}
+
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO workspace_members (workspace_id, user_id, role)
+			VALUES ($1, $2, $3)
+			RETURNING created_at
+		`, m.WorkspaceID, m.UserID, m.Role).Scan(&m.CreatedAt)
+		if err != nil {
+			return dbError(err)
+		}
+		return record(ctx, tx, ActionCreate, nil, &auditedMember{m.WorkspaceID, m.UserID, m.Role})
+	})
+}
+
+func (s *memberStore) Update(ctx context.Context, m *models.WorkspaceMember) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleOwner, workspaceRow, m.WorkspaceID); err != nil {
+			return err
+		}
+		if m.Role != auth.RoleOwner {
+			if err := keepOwner(ctx, tx, m.WorkspaceID, m.UserID); err != nil {
+				return err
+			}
+		}
+		before, err := memberSnapshot(ctx, tx, m.WorkspaceID, m.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			UPDATE workspace_members
+			SET role = $3
+			WHERE workspace_id = $1 AND user_id = $2
+			RETURNING created_at
+		`, m.WorkspaceID, m.UserID, m.Role).Scan(&m.CreatedAt)
+		if err != nil {
+			return notFound(err)
+		}
+		return record(ctx, tx, ActionUpdate, before, &auditedMember{m.WorkspaceID, m.UserID, m.Role})
+	})
+}
+
+func (s *memberStore) Remove(ctx context.Context, workspaceID, userID int) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if u := auth.UserFrom(ctx); u == nil || u.ID != userID {
+			if err := authorizeRow(ctx, tx, auth.RoleOwner, workspaceRow, workspaceID); err != nil {
+				return err
+			}
+		}
+		if err := keepOwner(ctx, tx, workspaceID, userID); err != nil {
+			return err
+		}
+		before, err := memberSnapshot(ctx, tx, workspaceID, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := execDelete(tx.ExecContext(ctx, "DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2", workspaceID, userID)); err != nil {
+			return err
+		}
+		return record(ctx, tx, ActionDelete, before, nil)
+	})
+}
+
+// memberSnapshot returns the membership of userID in workspaceID, locked
+// for the rest of tx, for record, or nil when writes of ctx are not
+// audited.
+func memberSnapshot(ctx context.Context, tx *sql.Tx, workspaceID, userID int) (*auditedMember, error) {
+	return snapshot(ctx, tx, func(s scanner) (auditedMember, error) {
+		m := auditedMember{WorkspaceID: workspaceID, UserID: userID}
+		err := s.Scan(&m.Role)
+		return m, err
+	}, `SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2 FOR UPDATE`, workspaceID, userID)
+}
+
+// keepOwner returns ErrLastOwner if userID is the only owner of the
+// workspace. It locks the owner memberships for the rest of tx, so two
+// owners demoting or removing each other at once cannot both pass.
+func keepOwner(ctx context.Context, tx *sql.Tx, workspaceID, userID int) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT user_id
+		FROM workspace_members
+		WHERE workspace_id = $1 AND role = 'owner'
+		FOR UPDATE
+	`, workspaceID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var owners []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		owners = append(owners, id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(owners) == 1 && owners[0] == userID {
+		return ErrLastOwner
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// NoteFilter narrows the result of NoteStore.List. Nil fields are ignored.
+type NoteFilter struct {
+	EntityType *string
+	EntityID   *int
+	TagID      *int
+	TagName    *string
+	Search     *string
+	// IDs and EntityIDs match any of the listed values; loaders use them to
+	// fetch many rows in one query.
+	IDs       []int
+	EntityIDs []int
+}
+
+type NoteStore interface {
+	List(ctx context.Context, filter NoteFilter) ([]models.Note, error)
+	// ListPage returns one keyset page of List ordered by page.Sort, which
+	// defaults to "-created_at".
+	ListPage(ctx context.Context, filter NoteFilter, page Page) (PageResult[models.Note], error)
+	// Count returns the number of rows matching filter.
+	Count(ctx context.Context, filter NoteFilter) (int, error)
+	Get(ctx context.Context, id int) (*models.Note, error)
+	Create(ctx context.Context, n *models.Note) error
+	Update(ctx context.Context, n *models.Note) error
+	Delete(ctx context.Context, id int) error
+}
+
+const noteColumns = `id, title, COALESCE(content, ''), COALESCE(entity_type, ''), entity_id, created_at, updated_at`
+
+// noteSorts are the fields ListPage can order by.
+var noteSorts = sortKeys[models.Note]{
+	"created_at": {expr: "created_at", cast: "timestamp", key: func(n *models.Note) string { return timeKey(&n.CreatedAt) }},
+	"updated_at": {expr: "updated_at", cast: "timestamp", key: func(n *models.Note) string { return timeKey(&n.UpdatedAt) }},
+	"title":      {expr: "title", cast: "text", key: func(n *models.Note) string { return n.Title }},
+}
+
+type noteStore struct {
+	db *sql.DB
+}
+
+func scanNote(s scanner) (models.Note, error) {
+	var n models.Note
+	err := s.Scan(&n.ID, &n.Title, &n.Content, &n.EntityType, &n.EntityID, &n.CreatedAt, &n.UpdatedAt)
+	return n, err
+}
+
+// conditions returns the WHERE clauses of f, limited to the workspaces of
+// the user of ctx.
+func (f NoteFilter) conditions(ctx context.Context) conditions {
+	var where conditions
+	if f.EntityType != nil {
+		where.add("entity_type = $%d", *f.EntityType)
+	}
+	if f.EntityID != nil {
+		where.add("entity_id = $%d", *f.EntityID)
+	}
+	if f.TagID != nil {
+		where.add("id IN (SELECT note_id FROM note_tags WHERE tag_id = $%d)", *f.TagID)
+	}
+	if f.IDs != nil {
+		where.add("id = ANY($%d)", pq.Array(f.IDs))
+	}
+	if f.EntityIDs != nil {
+		where.add("entity_id = ANY($%d)", pq.Array(f.EntityIDs))
+	}
+	if f.TagName != nil {
+		where.add("id IN (SELECT et.note_id FROM note_tags et JOIN tags t ON t.id = et.tag_id WHERE t.name = $%d)", *f.TagName)
+	}
+	if f.Search != nil {
+		where.add("(title ILIKE $%[1]d OR content ILIKE $%[1]d)", likePattern(*f.Search))
+	}
+
+	restrictNotes(ctx, &where)
+
+	return where
+}
+
+func (s *noteStore) List(ctx context.Context, filter NoteFilter) ([]models.Note, error) {
+	where := filter.conditions(ctx)
+	return s.query(ctx, `SELECT `+noteColumns+` FROM notes`+where.String()+` ORDER BY created_at DESC`, where.args...)
+}
+
+func (s *noteStore) ListPage(ctx context.Context, filter NoteFilter, page Page) (PageResult[models.Note], error) {
+	keys, err := noteSorts.resolve(page.Sort, "-created_at")
+	if err != nil {
+		return PageResult[models.Note]{}, err
+	}
+	where := filter.conditions(ctx)
+	order, err := keys.paginate(&where, page)
+	if err != nil {
+		return PageResult[models.Note]{}, err
+	}
+
+	notes, err := s.query(ctx, `SELECT `+noteColumns+` FROM notes`+where.String()+order, where.args...)
+	if err != nil {
+		return PageResult[models.Note]{}, err
+	}
+	return keys.finish(notes, page, func(n *models.Note) int { return n.ID }), nil
+}
+
+func (s *noteStore) Count(ctx context.Context, filter NoteFilter) (int, error) {
+	where := filter.conditions(ctx)
+
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notes`+where.String(), where.args...).Scan(&n)
+	return n, err
+}
+
+func (s *noteStore) query(ctx context.Context, query string, args ...any) ([]models.Note, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []models.Note{}
+	for rows.Next() {
+		n, err := scanNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+func (s *noteStore) Get(ctx context.Context, id int) (*models.Note, error) {
+	var where conditions
+	where.add("id = $%d", id)
+	restrictNotes(ctx, &where)
+	n, err := scanNote(s.db.QueryRowContext(ctx, `SELECT `+noteColumns+` FROM notes`+where.String(), where.args...))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &n, nil
+}
+
+func (s *noteStore) Create(ctx context.Context, n *models.Note) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeTarget(ctx, tx, n); err != nil {
+			return err
+		}
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO notes (title, content, entity_id, entity_type)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at, updated_at
+		`, n.Title, n.Content, n.EntityID, n.EntityType).Scan(&n.ID, &n.CreatedAt, &n.UpdatedAt)
+		if err != nil {
+			return dbError(err)
+		}
+		return record(ctx, tx, ActionCreate, nil, n)
+	})
+}
+
+func (s *noteStore) Update(ctx context.Context, n *models.Note) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeEntity(ctx, tx, auth.RoleCommenter, "note", n.ID); err != nil {
+			return err
+		}
+		if err := authorizeTarget(ctx, tx, n); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanNote, `SELECT `+noteColumns+` FROM notes WHERE id = $1 FOR UPDATE`, n.ID)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx, `
+			UPDATE notes
+			SET title = $2, content = $3, entity_id = $4, entity_type = $5
+			WHERE id = $1
+			RETURNING created_at, updated_at
+		`, n.ID, n.Title, n.Content, n.EntityID, n.EntityType).Scan(&n.CreatedAt, &n.UpdatedAt)
+		if err != nil {
+			return notFound(err)
+		}
+		return record(ctx, tx, ActionUpdate, before, n)
+	})
+}
+
+func (s *noteStore) Delete(ctx context.Context, id int) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeEntity(ctx, tx, auth.RoleCommenter, "note", id); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanNote, `SELECT `+noteColumns+` FROM notes WHERE id = $1 FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if err := execDelete(tx.ExecContext(ctx, "DELETE FROM notes WHERE id = $1", id)); err != nil {
+			return err
+		}
+		return record(ctx, tx, ActionDelete, before, nil)
+	})
+}
+
+// authorizeTarget checks that the user of ctx may comment on the entity n
+// is attached to. Notes attached to nothing need no role.
+func authorizeTarget(ctx context.Context, db querier, n *models.Note) error {
+	if n.EntityType == "" || n.EntityID == nil {
+		return nil
+	}
+	workspace, err := entityWorkspace(n.EntityType)
+	if err != nil {
+		return err
+	}
+	return authorize(ctx, db, auth.RoleCommenter, workspace, *n.EntityID)
+}
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSort is returned when a list is asked to sort by a field it does
+// not support.
+var ErrInvalidSort = errors.New("invalid sort field")
+
+// ErrInvalidCursor is returned by ParseCursor for malformed cursors, and by
+// list methods for cursors of another sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a row in a keyset-paginated list by its sort key and ID. Sort
+// is the order the cursor was taken in, e.g. "-priority", and Key holds the
+// textual form of the sort column, e.g. an RFC 3339 timestamp.
+type Cursor struct {
+	Sort string
+	Key  string
+	ID   int
+}
+
+// String encodes the cursor as an opaque token for API clients.
+func (c Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.Sort + "|" + c.Key + "|" + strconv.Itoa(c.ID)))
+}
+
+// ParseCursor decodes a token produced by Cursor.String.
+func ParseCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sort, rest, ok := strings.Cut(string(raw), "|")
+	i := strings.LastIndexByte(rest, '|')
+	if !ok || i < 0 {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(rest[i+1:])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{Sort: sort, Key: rest[:i], ID: id}, nil
+}
+
+// Page selects a window of a list ordered by a keyset. Rows strictly after
+// After and strictly before Before are considered; Limit rows are taken from
+// the start of that range, or from its end when Backward is set.
+//
+// Sort names the field to order by, prefixed with "-" for descending order.
+// An empty Sort uses the store's default order.
+type Page struct {
+	Limit    int
+	After    *Cursor
+	Before   *Cursor
+	Backward bool
+	Sort     string
+}
+
+// PageResult is one page of a list. Cursors[i] is the position of Items[i];
+// More reports whether rows exist beyond the page in the direction of travel.
+type PageResult[T any] struct {
+	Items   []T
+	Cursors []Cursor
+	More    bool
+}
+
+// sortKey describes a field a list can be ordered by. expr is the SQL
+// expression compared against cursors, cast the SQL type the textual cursor
+// key is converted to, and key extracts the same value from a scanned row.
+type sortKey[T any] struct {
+	expr string
+	cast string
+	key  func(*T) string
+}
+
+// sortKeys maps the public sort field names of a list to their keys.
+type sortKeys[T any] map[string]sortKey[T]
+
+// keyset is a resolved sort order: a sort key plus a direction, with id as
+// the tie-breaker in the same direction. sort is the order as named in Page.
+type keyset[T any] struct {
+	sortKey[T]
+	sort string
+	desc bool
+}
+
+// resolve looks up the keyset for sort, falling back to def when sort is
+// empty.
+func (keys sortKeys[T]) resolve(sort, def string) (keyset[T], error) {
+	if sort == "" {
+		sort = def
+	}
+	field, desc := strings.CutPrefix(sort, "-")
+	k, ok := keys[field]
+	if !ok {
+		return keyset[T]{}, fmt.Errorf("%w: %q", ErrInvalidSort, field)
+	}
+	return keyset[T]{sortKey: k, sort: sort, desc: desc}, nil
+}
+
+// paginate adds the cursor bounds of page to where and returns the ORDER BY
+// and LIMIT clause. One extra row is requested so the caller can tell
+// whether more rows exist past the page; see finish. Cursors taken in
+// another order are rejected, as their keys need not fit the sort column.
+func (k keyset[T]) paginate(where *conditions, page Page) (string, error) {
+	for _, c := range []*Cursor{page.After, page.Before} {
+		if c != nil && c.Sort != k.sort {
+			return "", fmt.Errorf("%w: taken in sort %q, not %q", ErrInvalidCursor, c.Sort, k.sort)
+		}
+	}
+
+	after, before := ">", "<"
+	if k.desc {
+		after, before = before, after
+	}
+	if page.After != nil {
+		where.addCursor(k.expr, k.cast, after, page.After)
+	}
+	if page.Before != nil {
+		where.addCursor(k.expr, k.cast, before, page.Before)
+	}
+
+	dir := "ASC"
+	if k.desc != page.Backward {
+		dir = "DESC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %d", k.expr, dir, dir, page.Limit+1), nil
+}
+
+// finish drops the look-ahead row requested by paginate, restores the list
+// order for backward pages and computes the cursor of every row.
+func (k keyset[T]) finish(rows []T, page Page, id func(*T) int) PageResult[T] {
+	result := PageResult[T]{More: len(rows) > page.Limit}
+	if result.More {
+		rows = rows[:page.Limit]
+	}
+	if page.Backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	result.Items = rows
+	result.Cursors = make([]Cursor, len(rows))
+	for i := range rows {
+		result.Cursors[i] = Cursor{Sort: k.sort, Key: k.key(&rows[i]), ID: id(&rows[i])}
+	}
+	return result
+}
+
+// addCursor appends a row comparison of (expr, id) against a cursor.
+func (c *conditions) addCursor(expr, cast, op string, cursor *Cursor) {
+	c.args = append(c.args, cursor.Key, cursor.ID)
+	c.clauses = append(c.clauses, fmt.Sprintf("(%s, id) %s ($%d::%s, $%d)", expr, op, len(c.args)-1, cast, len(c.args)))
+}
+
+// timeKey formats a timestamp sort key. Nil dates become "infinity" to match
+// the COALESCE(column, 'infinity') expression used for nullable date columns,
+// which places undated rows after dated ones in ascending order.
+func timeKey(t *time.Time) string {
+	if t == nil {
+		return "infinity"
+	}
+	return t.Format(time.RFC3339Nano)
+}
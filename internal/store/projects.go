@@ -0,0 +1,227 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ProjectFilter narrows the result of ProjectStore.List. Nil fields are ignored.
+type ProjectFilter struct {
+	WorkspaceID *int
+	FlowID      *int
+	TagID       *int
+	Status      *string
+	TagName     *string
+	Search      *string
+	// IDs and FlowIDs match any of the listed values; loaders use them to
+	// fetch many rows in one query.
+	IDs     []int
+	FlowIDs []int
+}
+
+type ProjectStore interface {
+	List(ctx context.Context, filter ProjectFilter) ([]models.Project, error)
+	// ListPage returns one keyset page of List ordered by page.Sort, which
+	// defaults to "-created_at".
+	ListPage(ctx context.Context, filter ProjectFilter, page Page) (PageResult[models.Project], error)
+	// Count returns the number of rows matching filter.
+	Count(ctx context.Context, filter ProjectFilter) (int, error)
+	// ListRecent returns the most recently updated projects, of the workspace
+	// with workspaceID if it is not nil.
+	ListRecent(ctx context.Context, workspaceID *int, limit int) ([]models.Project, error)
+	Get(ctx context.Context, id int) (*models.Project, error)
+	// Create and Update need a flow, if any, of the project's workspace.
+	Create(ctx context.Context, p *models.Project) error
+	Update(ctx context.Context, p *models.Project) error
+	Delete(ctx context.Context, id int) error
+}
+
+const projectColumns = `id, title, COALESCE(description, ''), COALESCE(status, ''), workspace_id, flow_id, created_at, updated_at`
+
+// projectSorts are the fields ListPage can order by.
+var projectSorts = sortKeys[models.Project]{
+	"created_at": {expr: "created_at", cast: "timestamp", key: func(p *models.Project) string { return timeKey(&p.CreatedAt) }},
+	"updated_at": {expr: "updated_at", cast: "timestamp", key: func(p *models.Project) string { return timeKey(&p.UpdatedAt) }},
+	"title":      {expr: "title", cast: "text", key: func(p *models.Project) string { return p.Title }},
+}
+
+type projectStore struct {
+	db *sql.DB
+}
+
+func scanProject(s scanner) (models.Project, error) {
+	var p models.Project
+	err := s.Scan(&p.ID, &p.Title, &p.Description, &p.Status, &p.WorkspaceID, &p.FlowID, &p.CreatedAt, &p.UpdatedAt)
+	return p, err
+}
+
+// conditions returns the WHERE clauses of f, limited to the workspaces of
+// the user of ctx.
+func (f ProjectFilter) conditions(ctx context.Context) conditions {
+	var where conditions
+	if f.WorkspaceID != nil {
+		where.add("workspace_id = $%d", *f.WorkspaceID)
+	}
+	if f.FlowID != nil {
+		where.add("flow_id = $%d", *f.FlowID)
+	}
+	if f.TagID != nil {
+		where.add("id IN (SELECT project_id FROM project_tags WHERE tag_id = $%d)", *f.TagID)
+	}
+	if f.IDs != nil {
+		where.add("id = ANY($%d)", pq.Array(f.IDs))
+	}
+	if f.FlowIDs != nil {
+		where.add("flow_id = ANY($%d)", pq.Array(f.FlowIDs))
+	}
+	if f.Status != nil {
+		where.add("status = $%d", *f.Status)
+	}
+	if f.TagName != nil {
+		where.add("id IN (SELECT et.project_id FROM project_tags et JOIN tags t ON t.id = et.tag_id WHERE t.name = $%d)", *f.TagName)
+	}
+	if f.Search != nil {
+		where.add("(title ILIKE $%[1]d OR description ILIKE $%[1]d)", likePattern(*f.Search))
+	}
+
+	restrict(ctx, &where, projectWorkspace)
+
+	return where
+}
+
+func (s *projectStore) List(ctx context.Context, filter ProjectFilter) ([]models.Project, error) {
+	where := filter.conditions(ctx)
+	return s.query(ctx, `SELECT `+projectColumns+` FROM projects`+where.String()+` ORDER BY created_at DESC`, where.args...)
+}
+
+func (s *projectStore) ListPage(ctx context.Context, filter ProjectFilter, page Page) (PageResult[models.Project], error) {
+	keys, err := projectSorts.resolve(page.Sort, "-created_at")
+	if err != nil {
+		return PageResult[models.Project]{}, err
+	}
+	where := filter.conditions(ctx)
+	order, err := keys.paginate(&where, page)
+	if err != nil {
+		return PageResult[models.Project]{}, err
+	}
+
+	projects, err := s.query(ctx, `SELECT `+projectColumns+` FROM projects`+where.String()+order, where.args...)
+	if err != nil {
+		return PageResult[models.Project]{}, err
+	}
+	return keys.finish(projects, page, func(p *models.Project) int { return p.ID }), nil
+}
+
+func (s *projectStore) Count(ctx context.Context, filter ProjectFilter) (int, error) {
+	where := filter.conditions(ctx)
+
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM projects`+where.String(), where.args...).Scan(&n)
+	return n, err
+}
+
+func (s *projectStore) ListRecent(ctx context.Context, workspaceID *int, limit int) ([]models.Project, error) {
+	var where conditions
+	if workspaceID != nil {
+		where.add("workspace_id = $%d", *workspaceID)
+	}
+	restrict(ctx, &where, projectWorkspace)
+	return s.query(ctx, `SELECT `+projectColumns+` FROM projects`+where.String()+` ORDER BY updated_at DESC LIMIT `+strconv.Itoa(limit), where.args...)
+}
+
+func (s *projectStore) query(ctx context.Context, query string, args ...any) ([]models.Project, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+func (s *projectStore) Get(ctx context.Context, id int) (*models.Project, error) {
+	where := byID(ctx, id, projectWorkspace)
+	p, err := scanProject(s.db.QueryRowContext(ctx, `SELECT `+projectColumns+` FROM projects`+where.String(), where.args...))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &p, nil
+}
+
+func (s *projectStore) Create(ctx context.Context, p *models.Project) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorize(ctx, tx, auth.RoleEditor, `SELECT $2::integer`, p.WorkspaceID); err != nil {
+			return err
+		}
+		if _, err := placement(ctx, tx, parent{"workspace_id", "workspace", p.WorkspaceID}, parent{"flow_id", "flow", p.FlowID}); err != nil {
+			return err
+		}
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO projects (title, description, status, workspace_id, flow_id)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at, updated_at
+		`, p.Title, p.Description, p.Status, p.WorkspaceID, p.FlowID).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+		if err != nil {
+			return dbError(err)
+		}
+		return record(ctx, tx, ActionCreate, nil, p)
+	})
+}
+
+func (s *projectStore) Update(ctx context.Context, p *models.Project) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT workspace_id FROM projects WHERE id = $2`, p.ID); err != nil {
+			return err
+		}
+		if err := authorize(ctx, tx, auth.RoleEditor, `SELECT $2::integer`, p.WorkspaceID); err != nil {
+			return err
+		}
+		if _, err := placement(ctx, tx, parent{"workspace_id", "workspace", p.WorkspaceID}, parent{"flow_id", "flow", p.FlowID}); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanProject, `SELECT `+projectColumns+` FROM projects WHERE id = $1 FOR UPDATE`, p.ID)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx, `
+			UPDATE projects
+			SET title = $2, description = $3, status = $4, workspace_id = $5, flow_id = $6
+			WHERE id = $1
+			RETURNING created_at, updated_at
+		`, p.ID, p.Title, p.Description, p.Status, p.WorkspaceID, p.FlowID).Scan(&p.CreatedAt, &p.UpdatedAt)
+		if err != nil {
+			return notFound(err)
+		}
+		return record(ctx, tx, ActionUpdate, before, p)
+	})
+}
+
+func (s *projectStore) Delete(ctx context.Context, id int) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT workspace_id FROM projects WHERE id = $2`, id); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanProject, `SELECT `+projectColumns+` FROM projects WHERE id = $1 FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if err := execDelete(tx.ExecContext(ctx, "DELETE FROM projects WHERE id = $1", id)); err != nil {
+			return err
+		}
+		return record(ctx, tx, ActionDelete, before, nil)
+	})
+}
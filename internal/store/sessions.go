@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"go-goal/internal/models"
+)
+
+// SessionStore keeps signed-in browser sessions, keyed by the hash of the
+// token held in the session cookie.
+type SessionStore interface {
+	Create(ctx context.Context, s *models.Session) error
+	// User returns the owner of an unexpired session, or ErrNotFound.
+	User(ctx context.Context, tokenHash []byte) (*models.User, error)
+	Delete(ctx context.Context, tokenHash []byte) error
+	// DeleteExpired removes every expired session and reports how many.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type sessionStore struct {
+	db *sql.DB
+}
+
+func (s *sessionStore) Create(ctx context.Context, sess *models.Session) error {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO sessions (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING created_at
+	`, sess.TokenHash, sess.UserID, sess.ExpiresAt).Scan(&sess.CreatedAt)
+	return dbError(err)
+}
+
+func (s *sessionStore) User(ctx context.Context, tokenHash []byte) (*models.User, error) {
+	u, err := scanUser(s.db.QueryRowContext(ctx, `
+		SELECT u.id, u.email, u.name, u.password_hash, u.is_admin, u.created_at, u.updated_at
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = $1 AND s.expires_at > CURRENT_TIMESTAMP
+	`, tokenHash))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &u, nil
+}
+
+func (s *sessionStore) Delete(ctx context.Context, tokenHash []byte) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE token_hash = $1", tokenHash)
+	return err
+}
+
+func (s *sessionStore) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= CURRENT_TIMESTAMP")
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,202 @@
+// Package store is the data access layer shared by the REST handlers in
+// internal/api and the GraphQL resolvers in internal/graphql. Every SQL
+// statement that touches an entity table lives here, so a schema change only
+// has to be made in one place.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ErrNotFound is returned when the requested row does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrInvalidEntityType is returned when an entity type other than project,
+// goal, task or note is used for tagging.
+var ErrInvalidEntityType = errors.New("invalid entity type")
+
+// ErrConflict is returned when a write would duplicate a unique value.
+var ErrConflict = errors.New("conflict")
+
+// ErrInvalidReference is returned when a write refers to a row that does not
+// exist, or a delete would leave rows referring to a missing one.
+var ErrInvalidReference = errors.New("invalid reference")
+
+// ErrConstraint is returned when a write violates a check or not-null
+// constraint.
+var ErrConstraint = errors.New("constraint violation")
+
+// ConstraintError describes a violated database constraint. It wraps one of
+// ErrConflict, ErrInvalidReference or ErrConstraint, so callers can branch
+// with errors.Is and still report the offending column.
+type ConstraintError struct {
+	Kind       error
+	Constraint string
+	Column     string
+	Detail     string
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Kind, e.Detail)
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Kind
+}
+
+// Store groups the typed stores for every entity.
+type Store struct {
+	Projects   ProjectStore
+	Goals      GoalStore
+	Tasks      TaskStore
+	Tags       TagStore
+	Notes      NoteStore
+	Workspaces WorkspaceStore
+	Members    MemberStore
+	Flows      FlowStore
+	Users      UserStore
+	Sessions   SessionStore
+	Tokens     TokenStore
+	Audit      AuditStore
+}
+
+// New returns a Store backed by the given Postgres connection pool.
+func New(db *sql.DB) *Store {
+	return &Store{
+		Projects:   &projectStore{db: db},
+		Goals:      &goalStore{db: db},
+		Tasks:      &taskStore{db: db},
+		Tags:       &tagStore{db: db},
+		Notes:      &noteStore{db: db},
+		Workspaces: &workspaceStore{db: db},
+		Members:    &memberStore{db: db},
+		Flows:      &flowStore{db: db},
+		Users:      &userStore{db: db},
+		Sessions:   &sessionStore{db: db},
+		Tokens:     &tokenStore{db: db},
+		Audit:      &auditStore{db: db},
+	}
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx, so the access checks
+// can run inside the transaction of a write as well as on their own.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// inTx runs fn in a transaction, committing it when fn succeeds and rolling
+// it back otherwise. Every write runs in one, so its access checks, the
+// rows it locks and its audit event commit or fail together with it.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// conditions accumulates WHERE clauses and their positional arguments.
+type conditions struct {
+	clauses []string
+	args    []any
+}
+
+// add appends a clause; the clause must contain a single %d verb which is
+// replaced with the placeholder index of arg.
+func (c *conditions) add(clause string, arg any) {
+	c.args = append(c.args, arg)
+	c.clauses = append(c.clauses, fmt.Sprintf(clause, len(c.args)))
+}
+
+func (c *conditions) String() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+// likePattern turns free text into an ILIKE pattern matching it anywhere,
+// escaping the wildcard characters it contains.
+func likePattern(text string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+	return "%" + escaped + "%"
+}
+
+// execDelete runs a single-row DELETE and reports ErrNotFound when nothing
+// was removed.
+func execDelete(result sql.Result, err error) error {
+	if err != nil {
+		return dbError(err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// notFound translates sql.ErrNoRows into ErrNotFound and constraint
+// violations as dbError does.
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return dbError(err)
+}
+
+// keyColumn extracts the column list from the detail of unique and foreign
+// key violations, e.g. `Key (project_id)=(42) is not present in table ...`.
+var keyColumn = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// dbError translates Postgres integrity constraint violations into a
+// *ConstraintError and returns any other error unchanged.
+func dbError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	var kind error
+	switch pqErr.Code {
+	case "23505": // unique_violation
+		kind = ErrConflict
+	case "23503": // foreign_key_violation
+		kind = ErrInvalidReference
+	case "23502", "23514": // not_null_violation, check_violation
+		kind = ErrConstraint
+	default:
+		return err
+	}
+
+	column := pqErr.Column
+	if m := keyColumn.FindStringSubmatch(pqErr.Detail); m != nil {
+		column = m[1]
+	}
+	detail := pqErr.Detail
+	if detail == "" {
+		detail = pqErr.Message
+	}
+	return &ConstraintError{Kind: kind, Constraint: pqErr.Constraint, Column: column, Detail: detail}
+}
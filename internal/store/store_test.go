@@ -0,0 +1,1203 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskStoreList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+
+	t.Run("should combine filters into a single WHERE clause", func(t *testing.T) {
+		projectID, status := 2, "pending"
+		rows := sqlmock.NewRows([]string{
+			"id", "title", "description", "goal_id", "project_id", "flow_id",
+			"status", "priority", "due_date", "created_at", "updated_at",
+		}).
+			AddRow(1, "Task", "", nil, 2, nil, "pending", 1, nil, time.Now(), time.Now())
+
+		mock.ExpectQuery(`FROM tasks WHERE project_id = \$1 AND status = \$2 ORDER BY priority DESC, due_date ASC`).
+			WithArgs(projectID, status).
+			WillReturnRows(rows)
+
+		tasks, err := s.Tasks.List(context.Background(), TaskFilter{ProjectID: &projectID, Status: &status})
+
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, 2, *tasks[0].ProjectID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an empty slice rather than nil", func(t *testing.T) {
+		mock.ExpectQuery(`FROM tasks ORDER BY`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		tasks, err := s.Tasks.List(context.Background(), TaskFilter{})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, tasks)
+		assert.Empty(t, tasks)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStoreNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+
+	t.Run("Get maps sql.ErrNoRows to ErrNotFound", func(t *testing.T) {
+		mock.ExpectQuery(`FROM flows WHERE id = \$1`).
+			WithArgs(7).
+			WillReturnError(sql.ErrNoRows)
+
+		flow, err := s.Flows.Get(context.Background(), 7)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, flow)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update maps a missing row to ErrNotFound", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE goals`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}))
+		mock.ExpectRollback()
+
+		err := s.Goals.Update(context.Background(), &models.Goal{ID: 7})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Delete reports ErrNotFound when no row was removed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM projects WHERE id = \$1`).
+			WithArgs(7).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := s.Projects.Delete(context.Background(), 7)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStoreConstraintErrors(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+
+	t.Run("Create maps foreign key violations to ErrInvalidReference", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO tasks`).WillReturnError(&pq.Error{
+			Code:       "23503",
+			Constraint: "tasks_goal_id_fkey",
+			Detail:     `Key (goal_id)=(42) is not present in table "goals".`,
+		})
+		mock.ExpectRollback()
+
+		err := s.Tasks.Create(context.Background(), &models.Task{Title: "Orphan"})
+
+		assert.ErrorIs(t, err, ErrInvalidReference)
+		var ce *ConstraintError
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, "goal_id", ce.Column)
+		assert.Equal(t, "tasks_goal_id_fkey", ce.Constraint)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update maps unique violations to ErrConflict", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE tags`).WillReturnError(&pq.Error{
+			Code:   "23505",
+			Detail: `Key (name)=(urgent) already exists.`,
+		})
+		mock.ExpectRollback()
+
+		err := s.Tags.Update(context.Background(), &models.Tag{ID: 1, Name: "urgent"})
+
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not-null violations map to ErrConstraint", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO workspaces`).WillReturnError(&pq.Error{
+			Code:    "23502",
+			Column:  "name",
+			Message: `null value in column "name" violates not-null constraint`,
+		})
+		mock.ExpectRollback()
+
+		err := s.Workspaces.Create(context.Background(), &models.Workspace{})
+
+		assert.ErrorIs(t, err, ErrConstraint)
+		var ce *ConstraintError
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, "name", ce.Column)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("other Postgres errors pass through", func(t *testing.T) {
+		pqErr := &pq.Error{Code: "40001"}
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO notes`).WillReturnError(pqErr)
+		mock.ExpectRollback()
+
+		err := s.Notes.Create(context.Background(), &models.Note{})
+
+		assert.Equal(t, pqErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTagStoreEntityTypes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+
+	t.Run("should reject unknown entity types without querying", func(t *testing.T) {
+		err := s.Tags.Assign(context.Background(), "workspace", 1, 1)
+
+		assert.ErrorIs(t, err, ErrInvalidEntityType)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should use the join table for the entity type", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO task_tags \(task_id, tag_id\)`).
+			WithArgs(3, 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := s.Tags.Assign(context.Background(), "task", 3, 4)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestListPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+	columns := []string{"id", "title", "description", "status", "workspace_id", "flow_id", "created_at", "updated_at"}
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should seek past the after cursor and fetch one look-ahead row", func(t *testing.T) {
+		workspaceID := 1
+		rows := sqlmock.NewRows(columns).
+			AddRow(9, "Nine", "", "active", 1, nil, created, created).
+			AddRow(8, "Eight", "", "active", 1, nil, created, created).
+			AddRow(7, "Seven", "", "active", 1, nil, created, created)
+
+		mock.ExpectQuery(`FROM projects WHERE workspace_id = \$1 AND \(created_at, id\) < \(\$2::timestamp, \$3\) ORDER BY created_at DESC, id DESC LIMIT 3`).
+			WithArgs(1, "2025-01-02T00:00:00Z", 10).
+			WillReturnRows(rows)
+
+		result, err := s.Projects.ListPage(context.Background(), ProjectFilter{WorkspaceID: &workspaceID}, Page{
+			Limit: 2,
+			After: &Cursor{Sort: "-created_at", Key: "2025-01-02T00:00:00Z", ID: 10},
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, result.More)
+		require.Len(t, result.Items, 2)
+		assert.Equal(t, []int{9, 8}, []int{result.Items[0].ID, result.Items[1].ID})
+		assert.Equal(t, Cursor{Sort: "-created_at", Key: "2025-01-01T12:00:00Z", ID: 8}, result.Cursors[1])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should read backward pages in reverse and restore the list order", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(4, "Four", "", "active", 1, nil, created, created).
+			AddRow(5, "Five", "", "active", 1, nil, created, created)
+
+		mock.ExpectQuery(`FROM projects WHERE \(created_at, id\) > \(\$1::timestamp, \$2\) ORDER BY created_at ASC, id ASC LIMIT 3`).
+			WithArgs("2025-01-01T00:00:00Z", 3).
+			WillReturnRows(rows)
+
+		result, err := s.Projects.ListPage(context.Background(), ProjectFilter{}, Page{
+			Limit:    2,
+			Before:   &Cursor{Sort: "-created_at", Key: "2025-01-01T00:00:00Z", ID: 3},
+			Backward: true,
+		})
+
+		assert.NoError(t, err)
+		assert.False(t, result.More)
+		require.Len(t, result.Items, 2)
+		assert.Equal(t, []int{5, 4}, []int{result.Items[0].ID, result.Items[1].ID})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should order by the requested field with undated rows last", func(t *testing.T) {
+		mock.ExpectQuery(`FROM tasks WHERE \(title ILIKE \$1 OR description ILIKE \$1\) ORDER BY COALESCE\(due_date, 'infinity'\) ASC, id ASC LIMIT 11`).
+			WithArgs(`%50\%%`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		search := "50%"
+		result, err := s.Tasks.ListPage(context.Background(), TaskFilter{Search: &search}, Page{Limit: 10, Sort: "due_date"})
+
+		assert.NoError(t, err)
+		assert.Empty(t, result.Items)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject unknown sort fields without querying", func(t *testing.T) {
+		_, err := s.Goals.ListPage(context.Background(), GoalFilter{}, Page{Limit: 10, Sort: "-color"})
+
+		assert.ErrorIs(t, err, ErrInvalidSort)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject cursors of another sort without querying", func(t *testing.T) {
+		_, err := s.Tasks.ListPage(context.Background(), TaskFilter{}, Page{
+			Limit: 10,
+			Sort:  "due_date",
+			After: &Cursor{Sort: "-priority", Key: "3", ID: 9},
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestParseCursor(t *testing.T) {
+	t.Run("should round-trip keys containing the separator", func(t *testing.T) {
+		cursor := Cursor{Sort: "title", Key: "a|b", ID: 7}
+
+		parsed, err := ParseCursor(cursor.String())
+
+		assert.NoError(t, err)
+		assert.Equal(t, &cursor, parsed)
+	})
+
+	t.Run("should reject malformed tokens", func(t *testing.T) {
+		_, err := ParseCursor("%%%")
+
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func TestWorkspaceAccess(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+	ctx := auth.WithUser(context.Background(), &models.User{ID: 7})
+	projectColumns := []string{"id", "title", "description", "status", "workspace_id", "flow_id", "created_at", "updated_at"}
+	taskColumns := []string{
+		"id", "title", "description", "goal_id", "project_id", "flow_id",
+		"status", "priority", "due_date", "created_at", "updated_at",
+	}
+
+	t.Run("should limit lists to the user's workspaces", func(t *testing.T) {
+		status := "active"
+		mock.ExpectQuery(`FROM projects WHERE status = \$1 AND projects.workspace_id IN \(SELECT workspace_id FROM workspace_members WHERE user_id = \$2\) ORDER BY`).
+			WithArgs(status, 7).
+			WillReturnRows(sqlmock.NewRows(projectColumns))
+
+		_, err := s.Projects.List(ctx, ProjectFilter{Status: &status})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should hide rows of other workspaces", func(t *testing.T) {
+		mock.ExpectQuery(`FROM goals WHERE id = \$1 AND COALESCE`).
+			WithArgs(3, 7).
+			WillReturnRows(sqlmock.NewRows(nil))
+
+		_, err := s.Goals.Get(ctx, 3)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should forbid viewers to create", func(t *testing.T) {
+		workspaceID := 2
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, &workspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "viewer"))
+		mock.ExpectRollback()
+
+		err := s.Projects.Create(ctx, &models.Project{Title: "Launch", WorkspaceID: &workspaceID})
+
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject projects in flows of another workspace", func(t *testing.T) {
+		workspaceID, flowID := 2, 9
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, &workspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`SELECT id FROM workspaces WHERE id = \$2`).
+			WithArgs(7, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`SELECT workspace_id FROM flows WHERE id = \$2`).
+			WithArgs(7, 9).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(3, "editor"))
+		mock.ExpectRollback()
+
+		err := s.Projects.Create(ctx, &models.Project{Title: "Launch", WorkspaceID: &workspaceID, FlowID: &flowID})
+
+		var constraint *ConstraintError
+		require.ErrorAs(t, err, &constraint)
+		assert.ErrorIs(t, err, ErrInvalidReference)
+		assert.Equal(t, "flow_id", constraint.Column)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should report flows the user cannot see as missing on project update", func(t *testing.T) {
+		workspaceID, flowID := 2, 9
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT workspace_id FROM projects WHERE id = \$2`).
+			WithArgs(7, 4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`SELECT \$2::integer`).
+			WithArgs(7, &workspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`SELECT id FROM workspaces WHERE id = \$2`).
+			WithArgs(7, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`SELECT workspace_id FROM flows WHERE id = \$2`).
+			WithArgs(7, 9).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(3, nil))
+		mock.ExpectRollback()
+
+		err := s.Projects.Update(ctx, &models.Project{ID: 4, Title: "Launch", WorkspaceID: &workspaceID, FlowID: &flowID})
+
+		var constraint *ConstraintError
+		require.ErrorAs(t, err, &constraint)
+		assert.Equal(t, "flow not found", constraint.Detail)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject goals whose flow is in another workspace than their project", func(t *testing.T) {
+		projectID, flowID := 4, 9
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT workspace_id FROM projects WHERE id = \$2`).
+			WithArgs(7, 4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`SELECT workspace_id FROM flows WHERE id = \$2`).
+			WithArgs(7, 9).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(3, "editor"))
+		mock.ExpectRollback()
+
+		err := s.Goals.Create(ctx, &models.Goal{Title: "Ship", ProjectID: &projectID, FlowID: &flowID})
+
+		var constraint *ConstraintError
+		require.ErrorAs(t, err, &constraint)
+		assert.Equal(t, "flow_id", constraint.Column)
+		assert.Equal(t, "flow belongs to another workspace", constraint.Detail)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should check every parent of a task", func(t *testing.T) {
+		goalID, projectID := 3, 4
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM goals WHERE id = \$2`).
+			WithArgs(7, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`SELECT workspace_id FROM projects WHERE id = \$2`).
+			WithArgs(7, 4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(3, nil))
+		mock.ExpectRollback()
+
+		err := s.Tasks.Create(ctx, &models.Task{Title: "Deploy", GoalID: &goalID, ProjectID: &projectID})
+
+		var constraint *ConstraintError
+		require.ErrorAs(t, err, &constraint)
+		assert.Equal(t, "project_id", constraint.Column)
+		assert.Equal(t, "project not found", constraint.Detail)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should let editors create tasks whose parents share a workspace", func(t *testing.T) {
+		goalID, flowID := 3, 9
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM goals WHERE id = \$2`).
+			WithArgs(7, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`SELECT workspace_id FROM flows WHERE id = \$2`).
+			WithArgs(7, 9).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`SELECT \$2::integer`).
+			WithArgs(7, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`INSERT INTO tasks`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(5, time.Now(), time.Now()))
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := s.Tasks.Create(ctx, &models.Task{Title: "Deploy", GoalID: &goalID, FlowID: &flowID})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should report rows of other workspaces as missing on write", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(4, nil))
+		mock.ExpectRollback()
+
+		err := s.Tasks.Delete(ctx, 5)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should let editors delete", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(4, "editor"))
+		mock.ExpectQuery(`FROM tasks WHERE id = \$1 FOR UPDATE`).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(5, "Task", "", nil, 2, nil, "pending", 1, nil, time.Now(), time.Now()))
+		mock.ExpectExec(`DELETE FROM tasks WHERE id = \$1`).WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(4, "editor"))
+		mock.ExpectExec(`INSERT INTO audit_events`).
+			WithArgs(7, 4, nil, "task", 5, "delete", sqlmock.AnyArg(), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, s.Tasks.Delete(ctx, 5))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should make the creator owner of a new workspace", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO workspace_members`).
+			WithArgs("Home", "", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(9, time.Now()))
+		mock.ExpectExec(`INSERT INTO audit_events`).
+			WithArgs(7, 9, nil, "workspace", 9, "create", nil, `{"description":"","id":9,"name":"Home"}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ws := &models.Workspace{Name: "Home"}
+
+		assert.NoError(t, s.Workspaces.Create(ctx, ws))
+		assert.Equal(t, 9, ws.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should keep the last owner", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "owner"))
+		mock.ExpectQuery(`FROM workspace_members\s+WHERE workspace_id = \$1 AND role = 'owner'\s+FOR UPDATE`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(7))
+		mock.ExpectRollback()
+
+		err := s.Members.Update(ctx, &models.WorkspaceMember{WorkspaceID: 2, UserID: 7, Role: "editor"})
+
+		assert.ErrorIs(t, err, ErrLastOwner)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should let an owner step down while another owner remains", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "owner"))
+		mock.ExpectQuery(`FROM workspace_members\s+WHERE workspace_id = \$1 AND role = 'owner'\s+FOR UPDATE`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(7).AddRow(8))
+		mock.ExpectQuery(`SELECT role FROM workspace_members WHERE workspace_id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(2, 7).
+			WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(`UPDATE workspace_members`).
+			WithArgs(2, 7, "editor").
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+		mock.ExpectExec(`INSERT INTO audit_events`).
+			WithArgs(7, 2, nil, "member", 7, "update", `{"role":"owner"}`, `{"role":"editor"}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := s.Members.Update(ctx, &models.WorkspaceMember{WorkspaceID: 2, UserID: 7, Role: "editor"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should forbid users who are not admins to change shared tags", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := s.Tags.Update(ctx, &models.Tag{ID: 3, Name: "urgent"})
+
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAudit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+	ctx := auth.WithUser(context.Background(), &models.User{ID: 7, Admin: true})
+	tagColumns := []string{"id", "name", "color", "parent_id", "created_at"}
+
+	t.Run("should record only the changed fields of an update", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM tags WHERE id = \$1 FOR UPDATE`).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(tagColumns).AddRow(3, "urgent", "#ff0000", nil, time.Now()))
+		mock.ExpectQuery(`UPDATE tags`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+		mock.ExpectExec(`INSERT INTO audit_events`).
+			WithArgs(7, nil, nil, "tag", 3, "update", `{"color":"#ff0000"}`, `{"color":"#00ff00"}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := s.Tags.Update(ctx, &models.Tag{ID: 3, Name: "urgent", Color: "#00ff00"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should skip updates that change nothing", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM tags WHERE id = \$1 FOR UPDATE`).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(tagColumns).AddRow(3, "urgent", "#ff0000", nil, time.Now()))
+		mock.ExpectQuery(`UPDATE tags`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+		mock.ExpectCommit()
+
+		err := s.Tags.Update(ctx, &models.Tag{ID: 3, Name: "urgent", Color: "#ff0000"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should record the workspace an update moved the entity out of", func(t *testing.T) {
+		workspaceID := 2
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT workspace_id FROM projects WHERE id = \$2`).
+			WithArgs(7, 4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(1, "editor"))
+		mock.ExpectQuery(`SELECT \$2::integer`).
+			WithArgs(7, &workspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`SELECT id FROM workspaces WHERE id = \$2`).
+			WithArgs(7, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`FROM projects WHERE id = \$1 FOR UPDATE`).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "status", "workspace_id", "flow_id", "created_at", "updated_at"}).
+				AddRow(4, "Launch", "", "active", 1, nil, time.Now(), time.Now()))
+		mock.ExpectQuery(`UPDATE projects`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+		mock.ExpectExec(`INSERT INTO audit_events`).
+			WithArgs(7, &workspaceID, 1, "project", 4, "update", `{"workspace_id":1}`, `{"workspace_id":2}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := s.Projects.Update(ctx, &models.Project{ID: 4, Title: "Launch", Status: "active", WorkspaceID: &workspaceID})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should list events of workspaces an entity was moved out of", func(t *testing.T) {
+		workspaceID := 1
+		mock.ExpectQuery(`FROM audit_events WHERE \(workspace_id = \$1 OR previous_workspace_id = \$1\) AND`).
+			WithArgs(workspaceID, 7).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		n, err := s.Audit.Count(ctx, AuditFilter{WorkspaceID: &workspaceID})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should record tagging against the tagged entity", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectExec(`INSERT INTO project_tags`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectExec(`INSERT INTO audit_events`).
+			WithArgs(7, 2, nil, "project", 4, "tag", nil, `{"tag_id":3}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, s.Tags.Assign(ctx, "project", 4, 3))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not record tags the entity already has", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectExec(`INSERT INTO project_tags`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assert.NoError(t, s.Tags.Assign(ctx, "project", 4, 3))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not record writes without a user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM tags WHERE id = \$1`).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, s.Tags.Delete(context.Background(), 3))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should list events of the user's workspaces newest first", func(t *testing.T) {
+		entityType, entityID := "goal", 5
+		mock.ExpectQuery(`FROM audit_events WHERE entity_type = \$1 AND entity_id = \$2 AND \(audit_events.workspace_id IS NULL OR EXISTS \( SELECT 1 FROM workspace_members WHERE user_id = \$3 AND workspace_id IN \(audit_events.workspace_id, audit_events.previous_workspace_id\)\)\) ORDER BY created_at DESC, id DESC LIMIT 51`).
+			WithArgs(entityType, entityID, 7).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "actor_id", "actor_email", "workspace_id", "previous_workspace_id", "entity_type", "entity_id",
+				"action", "before", "after", "request_id", "created_at",
+			}).AddRow(1, 7, "ada@example.com", 2, 1, "goal", 5, "update", []byte(`{"project_id":1}`), []byte(`{"project_id":2}`), "req-1", time.Now()))
+
+		page, err := s.Audit.ListPage(ctx, AuditFilter{EntityType: &entityType, EntityID: &entityID}, Page{Limit: 50})
+
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, "ada@example.com", page.Items[0].ActorEmail)
+		assert.JSONEq(t, `{"project_id":2}`, string(page.Items[0].After))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFlowRelationships(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+	ctx := auth.WithUser(context.Background(), &models.User{ID: 7})
+	columns := []string{"id", "flow_id", "related_flow_id", "relationship_type", "created_at"}
+
+	t.Run("should list relationships in both directions between visible flows", func(t *testing.T) {
+		mock.ExpectQuery(`FROM flow_relationships WHERE \(flow_id = ANY\(\$1\) OR related_flow_id = ANY\(\$1\)\) AND \(SELECT workspace_id FROM flows WHERE id = flow_relationships.flow_id\) IN .* AND \(SELECT workspace_id FROM flows WHERE id = flow_relationships.related_flow_id\) IN .* ORDER BY created_at, id`).
+			WithArgs(pq.Array([]int{1}), 7, 7).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, 1, 2, "supports", time.Now()).
+				AddRow(2, 3, 1, "precedes", time.Now()))
+
+		relationships, err := s.Flows.ListRelationships(ctx, []int{1})
+
+		assert.NoError(t, err)
+		assert.Len(t, relationships, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject related flows the user cannot see", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 9).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(4, nil))
+		mock.ExpectRollback()
+
+		err := s.Flows.CreateRelationship(ctx, &models.FlowRelationship{FlowID: 1, RelatedFlowID: 9, Type: "supports"})
+
+		var ce *ConstraintError
+		require.ErrorAs(t, err, &ce)
+		assert.ErrorIs(t, err, ErrInvalidReference)
+		assert.Equal(t, "related_flow_id", ce.Column)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject parent flows the user cannot see", func(t *testing.T) {
+		parentID := 9
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 9).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(4, nil))
+		mock.ExpectRollback()
+
+		err := s.Flows.Create(ctx, &models.Flow{Title: "Running", WorkspaceID: 2, ParentID: &parentID})
+
+		var ce *ConstraintError
+		require.ErrorAs(t, err, &ce)
+		assert.ErrorIs(t, err, ErrInvalidReference)
+		assert.Equal(t, "parent_id", ce.Column)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should check precedes relationships for cycles through every flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT id FROM flows WHERE id = ANY\(\$1\) ORDER BY id FOR UPDATE`).
+			WithArgs(pq.Array([]int{1, 2})).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+			WithArgs(flowGraphLockID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`WITH RECURSIVE successors\(id\) AS .* r.relationship_type = 'precedes'`).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		err := s.Flows.CreateRelationship(context.Background(), &models.FlowRelationship{FlowID: 1, RelatedFlowID: 2, Type: "precedes"})
+
+		var ce *ConstraintError
+		require.ErrorAs(t, err, &ce)
+		assert.ErrorIs(t, err, ErrCycle)
+		assert.Equal(t, "related_flow_id", ce.Column)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should only check precedence for cycles", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO flow_relationships`).
+			WithArgs(1, 2, "supports").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, time.Now()))
+		mock.ExpectCommit()
+
+		err := s.Flows.CreateRelationship(context.Background(), &models.FlowRelationship{FlowID: 1, RelatedFlowID: 2, Type: "supports"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject parents that descend from the flow", func(t *testing.T) {
+		parentID := 2
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM flows WHERE id = \$1 FOR UPDATE`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		mock.ExpectExec(`SELECT id FROM flows WHERE id = ANY\(\$1\) ORDER BY id FOR UPDATE`).
+			WithArgs(pq.Array([]int{1, 2})).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+			WithArgs(flowGraphLockID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`WITH RECURSIVE ancestors\(id\) AS .* flows.parent_id IS NOT NULL`).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		err := s.Flows.Update(context.Background(), &models.Flow{ID: 1, Title: "Root", Status: "active", WorkspaceID: 1, ParentID: &parentID})
+
+		var ce *ConstraintError
+		require.ErrorAs(t, err, &ce)
+		assert.ErrorIs(t, err, ErrCycle)
+		assert.Equal(t, "must not be a descendant", ce.Detail)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject flows as their own parent", func(t *testing.T) {
+		parentID := 1
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM flows WHERE id = \$1 FOR UPDATE`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		mock.ExpectRollback()
+
+		err := s.Flows.Update(context.Background(), &models.Flow{ID: 1, Title: "Root", Status: "active", WorkspaceID: 1, ParentID: &parentID})
+
+		assert.ErrorIs(t, err, ErrCycle)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should only delete relationships of the flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(2, "editor"))
+		mock.ExpectQuery(`DELETE FROM flow_relationships\s+WHERE id = \$1 AND \$2 IN \(flow_id, related_flow_id\)`).
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectRollback()
+
+		err := s.Flows.DeleteRelationship(ctx, 1, 5)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFlowConstraints(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+	columns := []string{"flow_id", "quiet_start", "quiet_end", "max_weekly_tasks", "forbidden_tags"}
+
+	t.Run("should return empty constraints for flows without any", func(t *testing.T) {
+		mock.ExpectQuery(`FROM flows\s+LEFT JOIN flow_constraints c ON c.flow_id = flows.id WHERE id = \$1`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, nil, nil, nil, "{}"))
+
+		c, err := s.Flows.GetConstraints(context.Background(), 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, c.FlowID)
+		assert.Nil(t, c.QuietStart)
+		assert.Empty(t, c.ForbiddenTags)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should report missing flows as not found", func(t *testing.T) {
+		mock.ExpectQuery(`LEFT JOIN flow_constraints c`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := s.Flows.GetConstraints(context.Background(), 2)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should upsert the constraints of a flow", func(t *testing.T) {
+		quietStart, quietEnd := "22:00", "07:00"
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO flow_constraints .* ON CONFLICT \(flow_id\) DO UPDATE`).
+			WithArgs(1, &quietStart, &quietEnd, nil, pq.Array([]string{})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := s.Flows.SetConstraints(context.Background(), &models.FlowConstraints{FlowID: 1, QuietStart: &quietStart, QuietEnd: &quietEnd})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFlowTransitions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+	ctx := context.Background()
+	flowRow := func(status string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "title", "description", "color", "status", "start_date", "end_date", "parent_id", "workspace_id", "created_at", "updated_at"}).
+			AddRow(1, "Health", "", "#3B82F6", status, nil, nil, nil, 1, time.Now(), time.Now())
+	}
+
+	t.Run("should record the transition with its reason", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM flows WHERE id = \$1 FOR UPDATE`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		mock.ExpectQuery(`UPDATE flows SET status = \$2 WHERE id = \$1`).
+			WithArgs(1, "paused").
+			WillReturnRows(flowRow("paused"))
+		mock.ExpectExec(`INSERT INTO flow_transitions`).
+			WithArgs(1, "active", "paused", "Recovering", nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		f, err := s.Flows.Transition(ctx, 1, "paused", "Recovering")
+
+		require.NoError(t, err)
+		assert.Equal(t, "paused", f.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should lock the flow and audit the transition in the same transaction", func(t *testing.T) {
+		user := auth.WithUser(ctx, &models.User{ID: 7})
+		mock.ExpectBegin()
+		mock.ExpectQuery(`LEFT JOIN workspace_members m`).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow(1, "editor"))
+		mock.ExpectQuery(`SELECT status FROM flows WHERE id = \$1 FOR UPDATE`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		mock.ExpectQuery(`FROM flows WHERE id = \$1`).
+			WithArgs(1).
+			WillReturnRows(flowRow("active"))
+		mock.ExpectQuery(`UPDATE flows SET status = \$2 WHERE id = \$1`).
+			WithArgs(1, "paused").
+			WillReturnRows(flowRow("paused"))
+		mock.ExpectExec(`INSERT INTO flow_transitions`).
+			WithArgs(1, "active", "paused", "", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO audit_events`).
+			WithArgs(7, 1, nil, "flow", 1, "update", `{"status":"active"}`, `{"status":"paused"}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		_, err := s.Flows.Transition(user, 1, "paused", "")
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject transitions the lifecycle does not allow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM flows WHERE id = \$1 FOR UPDATE`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("paused"))
+		mock.ExpectRollback()
+
+		_, err := s.Flows.Transition(ctx, 1, "paused", "")
+
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should check and record status changes made by updates", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM flows WHERE id = \$1 FOR UPDATE`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("celebrated"))
+		mock.ExpectRollback()
+
+		err := s.Flows.Update(ctx, &models.Flow{ID: 1, Title: "Health", Status: "active", WorkspaceID: 1})
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM flows WHERE id = \$1 FOR UPDATE`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("celebrated"))
+		mock.ExpectQuery(`UPDATE flows`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+		mock.ExpectExec(`INSERT INTO flow_transitions`).
+			WithArgs(1, "celebrated", "archived", "", nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err = s.Flows.Update(ctx, &models.Flow{ID: 1, Title: "Health", Status: "archived", WorkspaceID: 1})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should leave tasks of paused flows out of today's list", func(t *testing.T) {
+		today := func(fragment string) {
+			t.Helper()
+			mock.ExpectQuery(`FROM tasks WHERE .* AND NOT EXISTS \(\s+WITH RECURSIVE lineage\(id\) AS \(.*` + fragment).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "goal_id", "project_id", "flow_id", "status", "priority", "due_date", "created_at", "updated_at"}))
+
+			tasks, err := s.Tasks.ListToday(ctx, nil, 10)
+
+			assert.NoError(t, err)
+			assert.Empty(t, tasks)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+
+		t.Run("should use the flow of the task first", func(t *testing.T) {
+			today(`SELECT COALESCE\(tasks\.flow_id,`)
+		})
+
+		t.Run("should fall back to the flow of the goal or its project", func(t *testing.T) {
+			today(`\(SELECT COALESCE\(goals\.flow_id, \(SELECT flow_id FROM projects WHERE id = goals\.project_id\)\) FROM goals WHERE id = tasks\.goal_id\),`)
+		})
+
+		t.Run("should fall back to the flow of the project", func(t *testing.T) {
+			today(`\(SELECT flow_id FROM projects WHERE id = tasks\.project_id\)\)\s+UNION`)
+		})
+
+		t.Run("should check the ancestors of child flows", func(t *testing.T) {
+			today(`UNION\s+SELECT flows\.parent_id FROM flows JOIN lineage ON flows\.id = lineage\.id\s+\)\s+` +
+				`SELECT 1 FROM flows JOIN lineage ON flows\.id = lineage\.id WHERE flows\.status = 'paused'\)`)
+		})
+	})
+}
+
+func TestFlowTree(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+	ctx := context.Background()
+	flowColumns := []string{"id", "title", "description", "color", "status", "start_date", "end_date", "parent_id", "workspace_id", "created_at", "updated_at"}
+	statsColumns := []string{"root", "projects", "active_projects", "goals", "completed_goals", "tasks", "completed_tasks", "pending_tasks"}
+
+	t.Run("should roll stats up through the hierarchy in one query", func(t *testing.T) {
+		mock.ExpectQuery(`WITH RECURSIVE subtree\(root, id\) AS \(\s+SELECT id, id FROM flows WHERE id = ANY\(\$1\)\s+UNION\s+SELECT subtree.root, flows.id FROM flows JOIN subtree ON flows.parent_id = subtree.id`).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows(statsColumns).AddRow(1, 2, 1, 4, 1, 3, 2, 1))
+
+		stats, err := s.Flows.Stats(ctx, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, stats.TotalTasks)
+		assert.Equal(t, 25.0, stats.GoalCompletionPercent)
+		assert.Equal(t, 66.7, stats.TaskCompletionPercent)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should only count flows and entities of the user's workspaces", func(t *testing.T) {
+		member := `IN \(SELECT workspace_id FROM workspace_members WHERE user_id = \$2\)`
+		mock.ExpectQuery(`SELECT id, id FROM flows WHERE id = ANY\(\$1\) AND flows.workspace_id `+member+`\s+UNION\s+`+
+			`SELECT subtree.root, flows.id FROM flows JOIN subtree ON flows.parent_id = subtree.id AND flows.workspace_id `+member+
+			`.*FROM projects WHERE flow_id = f.id AND projects.workspace_id `+member+
+			`.*FROM goals WHERE flow_id = f.id AND COALESCE.*`+member+
+			`.*FROM tasks WHERE flow_id = f.id AND COALESCE.*`+member).
+			WithArgs(pq.Array([]int{1}), 7).
+			WillReturnRows(sqlmock.NewRows(statsColumns).AddRow(1, 1, 1, 0, 0, 0, 0, 0))
+
+		stats, err := s.Flows.ListStats(auth.WithUser(ctx, &models.User{ID: 7}), []int{1})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats[1].TotalProjects)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return errors instead of empty stats", func(t *testing.T) {
+		mock.ExpectQuery(`WITH RECURSIVE subtree`).WillReturnError(sql.ErrConnDone)
+
+		_, err := s.Flows.Stats(ctx, 1)
+
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should nest flows under their parents", func(t *testing.T) {
+		workspaceID := 1
+		mock.ExpectQuery(`FROM flows WHERE workspace_id = \$1`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(flowColumns).
+				AddRow(3, "Running", "", "", "active", nil, nil, 2, 1, time.Now(), time.Now()).
+				AddRow(2, "Fitness", "", "", "active", nil, nil, 1, 1, time.Now(), time.Now()).
+				AddRow(1, "Health", "", "", "active", nil, nil, nil, 1, time.Now(), time.Now()).
+				AddRow(4, "Career", "", "", "active", nil, nil, 9, 1, time.Now(), time.Now()))
+		mock.ExpectQuery(`WITH RECURSIVE subtree`).
+			WithArgs(pq.Array([]int{3, 2, 1, 4})).
+			WillReturnRows(sqlmock.NewRows(statsColumns).
+				AddRow(1, 0, 0, 0, 0, 4, 1, 3).
+				AddRow(3, 0, 0, 0, 0, 2, 1, 1))
+
+		tree, err := s.Flows.Tree(ctx, &workspaceID)
+
+		require.NoError(t, err)
+		require.Len(t, tree, 2)
+		assert.Equal(t, "Health", tree[0].Title)
+		assert.Equal(t, 4, tree[0].Stats.TotalTasks)
+		require.Len(t, tree[0].Children, 1)
+		require.Len(t, tree[0].Children[0].Children, 1)
+		assert.Equal(t, 50.0, tree[0].Children[0].Children[0].Stats.TaskCompletionPercent)
+		assert.Equal(t, "Career", tree[1].Title, "flows whose parent is not listed are roots")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFlowTimeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db)
+	flowColumns := []string{"id", "title", "description", "color", "status", "start_date", "end_date", "parent_id", "workspace_id", "created_at", "updated_at"}
+	date := func(day int) time.Time { return time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("should lay out overlapping flows in lanes and groups", func(t *testing.T) {
+		from, to := date(4), date(24)
+		mock.ExpectQuery(`FROM flows WHERE start_date < \$1 AND \(end_date IS NULL OR end_date >= \$2\)`).
+			WithArgs(date(25), from).
+			WillReturnRows(sqlmock.NewRows(flowColumns).
+				AddRow(1, "Sleep", "", "", "active", date(1), date(10), nil, 1, time.Now(), time.Now()).
+				AddRow(2, "Launch", "", "", "active", date(8), date(12), nil, 1, time.Now(), time.Now()).
+				AddRow(3, "Reading", "", "", "active", date(11), nil, nil, 1, time.Now(), time.Now()).
+				AddRow(4, "Trip", "", "", "active", date(20), date(22), nil, 1, time.Now(), time.Now()))
+		mock.ExpectQuery(`SELECT flow_id, date_trunc\('week', updated_at\), COUNT\(\*\)\s+FROM tasks`).
+			WithArgs(pq.Array([]int{1, 2, 3, 4}), "completed", from, date(25)).
+			WillReturnRows(sqlmock.NewRows([]string{"flow_id", "week", "count"}).
+				AddRow(2, date(11), 3))
+
+		timeline, err := s.Flows.Timeline(context.Background(), models.TimelineRange{From: &from, To: &to})
+
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{date(4), date(11), date(18)}, timeline.Weeks)
+		require.Len(t, timeline.Flows, 4)
+		lanes := map[string][2]int{}
+		for _, f := range timeline.Flows {
+			lanes[f.Title] = [2]int{f.Lane, f.OverlapGroup}
+		}
+		assert.Equal(t, map[string][2]int{
+			"Sleep":   {0, 0},
+			"Launch":  {1, 0},
+			"Reading": {0, 0},
+			"Trip":    {1, 0},
+		}, lanes, "Reading runs to the end of the range, so Trip overlaps it")
+		assert.Equal(t, []models.WeekCount{{Week: date(4)}, {Week: date(11), Count: 3}, {Week: date(18)}}, timeline.Flows[1].CompletedTasks)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should leave out completed tasks of other workspaces", func(t *testing.T) {
+		from, to := date(4), date(10)
+		ctx := auth.WithUser(context.Background(), &models.User{ID: 7})
+		mock.ExpectQuery(`FROM flows WHERE start_date < \$1`).
+			WillReturnRows(sqlmock.NewRows(flowColumns).
+				AddRow(1, "Sleep", "", "", "active", date(1), nil, nil, 1, time.Now(), time.Now()))
+		mock.ExpectQuery(`FROM tasks WHERE flow_id = ANY\(\$1\) AND status = \$2 AND updated_at >= \$3 AND updated_at < \$4 AND COALESCE\(.*\) IN \(SELECT workspace_id FROM workspace_members WHERE user_id = \$5\)`).
+			WithArgs(pq.Array([]int{1}), "completed", from, date(11), 7).
+			WillReturnRows(sqlmock.NewRows([]string{"flow_id", "week", "count"}).AddRow(1, date(4), 1))
+
+		timeline, err := s.Flows.Timeline(ctx, models.TimelineRange{From: &from, To: &to})
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.WeekCount{{Week: date(4), Count: 1}}, timeline.Flows[0].CompletedTasks)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should start a new group once every flow has ended", func(t *testing.T) {
+		from, to := date(1), date(31)
+		mock.ExpectQuery(`FROM flows WHERE start_date < \$1`).
+			WillReturnRows(sqlmock.NewRows(flowColumns).
+				AddRow(1, "Sleep", "", "", "active", date(1), date(5), nil, 1, time.Now(), time.Now()).
+				AddRow(2, "Launch", "", "", "active", date(6), date(9), nil, 1, time.Now(), time.Now()))
+		mock.ExpectQuery(`FROM tasks`).
+			WillReturnRows(sqlmock.NewRows([]string{"flow_id", "week", "count"}))
+
+		timeline, err := s.Flows.Timeline(context.Background(), models.TimelineRange{From: &from, To: &to})
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, timeline.Flows[0].Lane)
+		assert.Equal(t, 0, timeline.Flows[1].Lane)
+		assert.Equal(t, 0, timeline.Flows[0].OverlapGroup)
+		assert.Equal(t, 1, timeline.Flows[1].OverlapGroup)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
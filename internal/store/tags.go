@@ -0,0 +1,268 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// TagFilter narrows the result of TagStore.List. Nil fields are ignored.
+type TagFilter struct {
+	ParentID *int
+	// IDs matches any of the listed tags; loaders use it to fetch many rows
+	// in one query.
+	IDs []int
+}
+
+type TagStore interface {
+	List(ctx context.Context, filter TagFilter) ([]models.Tag, error)
+	Get(ctx context.Context, id int) (*models.Tag, error)
+	// Create, Update and Delete need an admin, as tags are shared by all
+	// workspaces.
+	Create(ctx context.Context, t *models.Tag) error
+	Update(ctx context.Context, t *models.Tag) error
+	Delete(ctx context.Context, id int) error
+
+	// ListForEntity returns the tags assigned to a project, goal, task or note.
+	ListForEntity(ctx context.Context, entityType string, entityID int) ([]models.Tag, error)
+	// ListForEntities is the batched form of ListForEntity, keyed by entity ID.
+	ListForEntities(ctx context.Context, entityType string, entityIDs []int) (map[int][]models.Tag, error)
+	// Assign tags an entity; assigning an existing tag is a no-op.
+	Assign(ctx context.Context, entityType string, entityID, tagID int) error
+	// Remove untags an entity, returning ErrNotFound if it was not tagged.
+	Remove(ctx context.Context, entityType string, entityID, tagID int) error
+}
+
+const tagColumns = `id, name, COALESCE(color, ''), parent_id, created_at`
+
+type tagStore struct {
+	db *sql.DB
+}
+
+// tagJoinTable returns the join table and foreign key column for an entity type.
+func tagJoinTable(entityType string) (table, column string, err error) {
+	switch entityType {
+	case "project":
+		return "project_tags", "project_id", nil
+	case "goal":
+		return "goal_tags", "goal_id", nil
+	case "task":
+		return "task_tags", "task_id", nil
+	case "note":
+		return "note_tags", "note_id", nil
+	}
+	return "", "", ErrInvalidEntityType
+}
+
+func scanTag(s scanner) (models.Tag, error) {
+	var t models.Tag
+	err := s.Scan(&t.ID, &t.Name, &t.Color, &t.ParentID, &t.CreatedAt)
+	return t, err
+}
+
+func (s *tagStore) List(ctx context.Context, filter TagFilter) ([]models.Tag, error) {
+	var where conditions
+	if filter.ParentID != nil {
+		where.add("parent_id = $%d", *filter.ParentID)
+	}
+	if filter.IDs != nil {
+		where.add("id = ANY($%d)", pq.Array(filter.IDs))
+	}
+
+	return s.query(ctx, `SELECT `+tagColumns+` FROM tags`+where.String()+` ORDER BY name`, where.args...)
+}
+
+func (s *tagStore) ListForEntity(ctx context.Context, entityType string, entityID int) ([]models.Tag, error) {
+	table, column, err := tagJoinTable(entityType)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeEntity(ctx, s.db, auth.RoleViewer, entityType, entityID); err != nil {
+		return nil, err
+	}
+
+	return s.query(ctx, `
+		SELECT t.id, t.name, COALESCE(t.color, ''), t.parent_id, t.created_at
+		FROM tags t
+		JOIN `+table+` et ON t.id = et.tag_id
+		WHERE et.`+column+` = $1
+		ORDER BY t.name
+	`, entityID)
+}
+
+func (s *tagStore) ListForEntities(ctx context.Context, entityType string, entityIDs []int) (map[int][]models.Tag, error) {
+	table, column, err := tagJoinTable(entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT et.`+column+`, t.id, t.name, COALESCE(t.color, ''), t.parent_id, t.created_at
+		FROM tags t
+		JOIN `+table+` et ON t.id = et.tag_id
+		WHERE et.`+column+` = ANY($1)
+		ORDER BY t.name
+	`, pq.Array(entityIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[int][]models.Tag, len(entityIDs))
+	for rows.Next() {
+		var entityID int
+		var t models.Tag
+		if err := rows.Scan(&entityID, &t.ID, &t.Name, &t.Color, &t.ParentID, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags[entityID] = append(tags[entityID], t)
+	}
+	return tags, rows.Err()
+}
+
+func (s *tagStore) query(ctx context.Context, query string, args ...any) ([]models.Tag, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+func (s *tagStore) Get(ctx context.Context, id int) (*models.Tag, error) {
+	t, err := scanTag(s.db.QueryRowContext(ctx, `SELECT `+tagColumns+` FROM tags WHERE id = $1`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &t, nil
+}
+
+func (s *tagStore) Create(ctx context.Context, t *models.Tag) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := requireAdmin(ctx); err != nil {
+			return err
+		}
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO tags (name, color, parent_id)
+			VALUES ($1, $2, $3)
+			RETURNING id, created_at
+		`, t.Name, t.Color, t.ParentID).Scan(&t.ID, &t.CreatedAt)
+		if err != nil {
+			return dbError(err)
+		}
+		return record(ctx, tx, ActionCreate, nil, t)
+	})
+}
+
+func (s *tagStore) Update(ctx context.Context, t *models.Tag) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := requireAdmin(ctx); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanTag, `SELECT `+tagColumns+` FROM tags WHERE id = $1 FOR UPDATE`, t.ID)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx, `
+			UPDATE tags
+			SET name = $2, color = $3, parent_id = $4
+			WHERE id = $1
+			RETURNING created_at
+		`, t.ID, t.Name, t.Color, t.ParentID).Scan(&t.CreatedAt)
+		if err != nil {
+			return notFound(err)
+		}
+		return record(ctx, tx, ActionUpdate, before, t)
+	})
+}
+
+func (s *tagStore) Delete(ctx context.Context, id int) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := requireAdmin(ctx); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanTag, `SELECT `+tagColumns+` FROM tags WHERE id = $1 FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if err := execDelete(tx.ExecContext(ctx, "DELETE FROM tags WHERE id = $1", id)); err != nil {
+			return err
+		}
+		return record(ctx, tx, ActionDelete, before, nil)
+	})
+}
+
+func (s *tagStore) Assign(ctx context.Context, entityType string, entityID, tagID int) error {
+	table, column, err := tagJoinTable(entityType)
+	if err != nil {
+		return err
+	}
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeEntity(ctx, tx, taggingRole(entityType), entityType, entityID); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO `+table+` (`+column+`, tag_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, entityID, tagID)
+		if err != nil {
+			return dbError(err)
+		}
+		// Assigning a tag the entity already has changes nothing to audit.
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		return recordTagging(ctx, tx, ActionTag, entityType, entityID, tagID)
+	})
+}
+
+func (s *tagStore) Remove(ctx context.Context, entityType string, entityID, tagID int) error {
+	table, column, err := tagJoinTable(entityType)
+	if err != nil {
+		return err
+	}
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeEntity(ctx, tx, taggingRole(entityType), entityType, entityID); err != nil {
+			return err
+		}
+
+		// execDelete fails with ErrNotFound when the entity did not have the
+		// tag, so only removals that happened are audited.
+		err := execDelete(tx.ExecContext(ctx, `
+			DELETE FROM `+table+`
+			WHERE `+column+` = $1 AND tag_id = $2
+		`, entityID, tagID))
+		if err != nil {
+			return err
+		}
+		return recordTagging(ctx, tx, ActionUntag, entityType, entityID, tagID)
+	})
+}
+
+// taggingRole is the workspace role needed to tag an entity: notes can be
+// tagged by anyone who may comment, everything else needs an editor.
+func taggingRole(entityType string) string {
+	if entityType == "note" {
+		return auth.RoleCommenter
+	}
+	return auth.RoleEditor
+}
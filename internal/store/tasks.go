@@ -0,0 +1,277 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// TaskFilter narrows the result of TaskStore.List. Nil fields are ignored.
+type TaskFilter struct {
+	ProjectID *int
+	GoalID    *int
+	FlowID    *int
+	Status    *string
+	TagID     *int
+	Priority  *int
+	TagName   *string
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	Search    *string
+	// IDs, ProjectIDs, GoalIDs and FlowIDs match any of the listed values;
+	// loaders use them to fetch many rows in one query.
+	IDs        []int
+	ProjectIDs []int
+	GoalIDs    []int
+	FlowIDs    []int
+}
+
+type TaskStore interface {
+	List(ctx context.Context, filter TaskFilter) ([]models.Task, error)
+	// ListPage returns one keyset page of List ordered by page.Sort, which
+	// defaults to "-priority".
+	ListPage(ctx context.Context, filter TaskFilter, page Page) (PageResult[models.Task], error)
+	// Count returns the number of rows matching filter.
+	Count(ctx context.Context, filter TaskFilter) (int, error)
+	// ListToday returns tasks due today or currently in progress, leaving
+	// out those whose flow, or an ancestor of it, is paused. A task without a
+	// flow of its own takes the flow of its goal, then of its project. A
+	// non-nil workspaceID limits the list to that workspace.
+	ListToday(ctx context.Context, workspaceID *int, limit int) ([]models.Task, error)
+	Get(ctx context.Context, id int) (*models.Task, error)
+	// Create and Update need the goal, project and flow that are set to share
+	// a workspace.
+	Create(ctx context.Context, t *models.Task) error
+	Update(ctx context.Context, t *models.Task) error
+	Delete(ctx context.Context, id int) error
+}
+
+const taskColumns = `id, title, COALESCE(description, ''), goal_id, project_id, flow_id, COALESCE(status, ''), priority, due_date, created_at, updated_at`
+
+// taskSorts are the fields ListPage can order by.
+var taskSorts = sortKeys[models.Task]{
+	"priority":   {expr: "priority", cast: "integer", key: func(t *models.Task) string { return strconv.Itoa(t.Priority) }},
+	"due_date":   {expr: "COALESCE(due_date, 'infinity')", cast: "timestamp", key: func(t *models.Task) string { return timeKey(t.DueDate) }},
+	"created_at": {expr: "created_at", cast: "timestamp", key: func(t *models.Task) string { return timeKey(&t.CreatedAt) }},
+	"updated_at": {expr: "updated_at", cast: "timestamp", key: func(t *models.Task) string { return timeKey(&t.UpdatedAt) }},
+	"title":      {expr: "title", cast: "text", key: func(t *models.Task) string { return t.Title }},
+}
+
+// taskFlow is the flow a task belongs to: its own, else its goal's, which in
+// turn falls back to the goal's project, else its project's.
+const taskFlow = `COALESCE(tasks.flow_id,
+	(SELECT COALESCE(goals.flow_id, (SELECT flow_id FROM projects WHERE id = goals.project_id)) FROM goals WHERE id = tasks.goal_id),
+	(SELECT flow_id FROM projects WHERE id = tasks.project_id))`
+
+// pausedFlow matches tasks whose flow or one of its ancestors is paused.
+const pausedFlow = `EXISTS (
+	WITH RECURSIVE lineage(id) AS (
+		SELECT ` + taskFlow + `
+		UNION
+		SELECT flows.parent_id FROM flows JOIN lineage ON flows.id = lineage.id
+	)
+	SELECT 1 FROM flows JOIN lineage ON flows.id = lineage.id WHERE flows.status = 'paused')`
+
+type taskStore struct {
+	db *sql.DB
+}
+
+func scanTask(s scanner) (models.Task, error) {
+	var t models.Task
+	err := s.Scan(&t.ID, &t.Title, &t.Description, &t.GoalID, &t.ProjectID, &t.FlowID, &t.Status, &t.Priority, &t.DueDate, &t.CreatedAt, &t.UpdatedAt)
+	return t, err
+}
+
+// conditions returns the WHERE clauses of f, limited to the workspaces of
+// the user of ctx.
+func (f TaskFilter) conditions(ctx context.Context) conditions {
+	var where conditions
+	if f.ProjectID != nil {
+		where.add("project_id = $%d", *f.ProjectID)
+	}
+	if f.GoalID != nil {
+		where.add("goal_id = $%d", *f.GoalID)
+	}
+	if f.FlowID != nil {
+		where.add("flow_id = $%d", *f.FlowID)
+	}
+	if f.Status != nil {
+		where.add("status = $%d", *f.Status)
+	}
+	if f.TagID != nil {
+		where.add("id IN (SELECT task_id FROM task_tags WHERE tag_id = $%d)", *f.TagID)
+	}
+	if f.IDs != nil {
+		where.add("id = ANY($%d)", pq.Array(f.IDs))
+	}
+	if f.ProjectIDs != nil {
+		where.add("project_id = ANY($%d)", pq.Array(f.ProjectIDs))
+	}
+	if f.GoalIDs != nil {
+		where.add("goal_id = ANY($%d)", pq.Array(f.GoalIDs))
+	}
+	if f.FlowIDs != nil {
+		where.add("flow_id = ANY($%d)", pq.Array(f.FlowIDs))
+	}
+	if f.Priority != nil {
+		where.add("priority = $%d", *f.Priority)
+	}
+	if f.TagName != nil {
+		where.add("id IN (SELECT et.task_id FROM task_tags et JOIN tags t ON t.id = et.tag_id WHERE t.name = $%d)", *f.TagName)
+	}
+	if f.DueBefore != nil {
+		where.add("due_date < $%d", *f.DueBefore)
+	}
+	if f.DueAfter != nil {
+		where.add("due_date > $%d", *f.DueAfter)
+	}
+	if f.Search != nil {
+		where.add("(title ILIKE $%[1]d OR description ILIKE $%[1]d)", likePattern(*f.Search))
+	}
+
+	restrict(ctx, &where, taskWorkspace)
+
+	return where
+}
+
+func (s *taskStore) List(ctx context.Context, filter TaskFilter) ([]models.Task, error) {
+	where := filter.conditions(ctx)
+	return s.query(ctx, `SELECT `+taskColumns+` FROM tasks`+where.String()+` ORDER BY priority DESC, due_date ASC`, where.args...)
+}
+
+func (s *taskStore) ListPage(ctx context.Context, filter TaskFilter, page Page) (PageResult[models.Task], error) {
+	keys, err := taskSorts.resolve(page.Sort, "-priority")
+	if err != nil {
+		return PageResult[models.Task]{}, err
+	}
+	where := filter.conditions(ctx)
+	order, err := keys.paginate(&where, page)
+	if err != nil {
+		return PageResult[models.Task]{}, err
+	}
+
+	tasks, err := s.query(ctx, `SELECT `+taskColumns+` FROM tasks`+where.String()+order, where.args...)
+	if err != nil {
+		return PageResult[models.Task]{}, err
+	}
+	return keys.finish(tasks, page, func(t *models.Task) int { return t.ID }), nil
+}
+
+func (s *taskStore) Count(ctx context.Context, filter TaskFilter) (int, error) {
+	where := filter.conditions(ctx)
+
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks`+where.String(), where.args...).Scan(&n)
+	return n, err
+}
+
+func (s *taskStore) ListToday(ctx context.Context, workspaceID *int, limit int) ([]models.Task, error) {
+	where := conditions{clauses: []string{
+		"(DATE(due_date) = CURRENT_DATE OR status = 'in_progress')",
+		"NOT " + pausedFlow,
+	}}
+	if workspaceID != nil {
+		where.add(taskWorkspace+" = $%d", *workspaceID)
+	}
+	restrict(ctx, &where, taskWorkspace)
+	return s.query(ctx, `SELECT `+taskColumns+` FROM tasks`+where.String()+` ORDER BY priority DESC LIMIT `+strconv.Itoa(limit), where.args...)
+}
+
+func (s *taskStore) query(ctx context.Context, query string, args ...any) ([]models.Task, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+func (s *taskStore) Get(ctx context.Context, id int) (*models.Task, error) {
+	where := byID(ctx, id, taskWorkspace)
+	t, err := scanTask(s.db.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks`+where.String(), where.args...))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &t, nil
+}
+
+func (s *taskStore) Create(ctx context.Context, t *models.Task) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		workspaceID, err := placement(ctx, tx, parent{"goal_id", "goal", t.GoalID}, parent{"project_id", "project", t.ProjectID}, parent{"flow_id", "flow", t.FlowID})
+		if err != nil {
+			return err
+		}
+		if err := authorize(ctx, tx, auth.RoleEditor, `SELECT $2::integer`, workspaceID); err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO tasks (title, description, goal_id, project_id, flow_id, status, priority, due_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, created_at, updated_at
+		`, t.Title, t.Description, t.GoalID, t.ProjectID, t.FlowID, t.Status, t.Priority, t.DueDate).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+		if err != nil {
+			return dbError(err)
+		}
+		return record(ctx, tx, ActionCreate, nil, t)
+	})
+}
+
+func (s *taskStore) Update(ctx context.Context, t *models.Task) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT `+taskWorkspace+` FROM tasks WHERE id = $2`, t.ID); err != nil {
+			return err
+		}
+		workspaceID, err := placement(ctx, tx, parent{"goal_id", "goal", t.GoalID}, parent{"project_id", "project", t.ProjectID}, parent{"flow_id", "flow", t.FlowID})
+		if err != nil {
+			return err
+		}
+		if err := authorize(ctx, tx, auth.RoleEditor, `SELECT $2::integer`, workspaceID); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanTask, `SELECT `+taskColumns+` FROM tasks WHERE id = $1 FOR UPDATE`, t.ID)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx, `
+			UPDATE tasks
+			SET title = $2, description = $3, goal_id = $4, project_id = $5, flow_id = $6, status = $7, priority = $8, due_date = $9
+			WHERE id = $1
+			RETURNING created_at, updated_at
+		`, t.ID, t.Title, t.Description, t.GoalID, t.ProjectID, t.FlowID, t.Status, t.Priority, t.DueDate).Scan(&t.CreatedAt, &t.UpdatedAt)
+		if err != nil {
+			return notFound(err)
+		}
+		return record(ctx, tx, ActionUpdate, before, t)
+	})
+}
+
+func (s *taskStore) Delete(ctx context.Context, id int) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleEditor, `SELECT `+taskWorkspace+` FROM tasks WHERE id = $2`, id); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanTask, `SELECT `+taskColumns+` FROM tasks WHERE id = $1 FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if err := execDelete(tx.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", id)); err != nil {
+			return err
+		}
+		return record(ctx, tx, ActionDelete, before, nil)
+	})
+}
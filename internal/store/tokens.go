@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"go-goal/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type TokenStore interface {
+	// List returns the tokens of a user, newest first.
+	List(ctx context.Context, userID int) ([]models.APIToken, error)
+	Create(ctx context.Context, t *models.APIToken) error
+	// Delete revokes a token of userID; tokens of other users are not found.
+	Delete(ctx context.Context, userID, id int) error
+	// Use looks up an unexpired token by hash, records that it was used and
+	// returns it with its owner, or ErrNotFound.
+	Use(ctx context.Context, tokenHash []byte) (*models.APIToken, *models.User, error)
+}
+
+const tokenColumns = `id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at`
+
+type tokenStore struct {
+	db *sql.DB
+}
+
+func scanToken(s scanner, dest ...any) (models.APIToken, error) {
+	var t models.APIToken
+	err := s.Scan(append([]any{&t.ID, &t.UserID, &t.Name, &t.Prefix, pq.Array(&t.Scopes), &t.ExpiresAt, &t.LastUsedAt, &t.CreatedAt}, dest...)...)
+	return t, err
+}
+
+func (s *tokenStore) List(ctx context.Context, userID int) ([]models.APIToken, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+tokenColumns+` FROM api_tokens WHERE user_id = $1 ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+func (s *tokenStore) Create(ctx context.Context, t *models.APIToken) error {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO api_tokens (user_id, name, prefix, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, t.UserID, t.Name, t.Prefix, t.TokenHash, pq.Array(t.Scopes), t.ExpiresAt).Scan(&t.ID, &t.CreatedAt)
+	return dbError(err)
+}
+
+func (s *tokenStore) Delete(ctx context.Context, userID, id int) error {
+	return execDelete(s.db.ExecContext(ctx, "DELETE FROM api_tokens WHERE id = $1 AND user_id = $2", id, userID))
+}
+
+func (s *tokenStore) Use(ctx context.Context, tokenHash []byte) (*models.APIToken, *models.User, error) {
+	var u models.User
+	t, err := scanToken(s.db.QueryRowContext(ctx, `
+		WITH t AS (
+			UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP
+			WHERE token_hash = $1 AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+			RETURNING `+tokenColumns+`
+		)
+		SELECT t.id, t.user_id, t.name, t.prefix, t.scopes, t.expires_at, t.last_used_at, t.created_at,
+			u.id, u.email, u.name, u.password_hash, u.is_admin, u.created_at, u.updated_at
+		FROM t
+		JOIN users u ON u.id = t.user_id
+	`, tokenHash), &u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.Admin, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+	return &t, &u, nil
+}
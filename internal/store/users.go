@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"go-goal/internal/models"
+)
+
+type UserStore interface {
+	Get(ctx context.Context, id int) (*models.User, error)
+	// GetByEmail looks a user up by the lower-cased email address.
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// Create makes the first user to register an admin.
+	Create(ctx context.Context, u *models.User) error
+}
+
+const userColumns = `id, email, name, password_hash, is_admin, created_at, updated_at`
+
+type userStore struct {
+	db *sql.DB
+}
+
+func scanUser(s scanner) (models.User, error) {
+	var u models.User
+	err := s.Scan(&u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.Admin, &u.CreatedAt, &u.UpdatedAt)
+	return u, err
+}
+
+func (s *userStore) Get(ctx context.Context, id int) (*models.User, error) {
+	u, err := scanUser(s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &u, nil
+}
+
+func (s *userStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	u, err := scanUser(s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE email = $1`, email))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &u, nil
+}
+
+func (s *userStore) Create(ctx context.Context, u *models.User) error {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO users (email, name, password_hash, is_admin)
+		VALUES ($1, $2, $3, NOT EXISTS (SELECT 1 FROM users))
+		RETURNING id, is_admin, created_at, updated_at
+	`, u.Email, u.Name, u.PasswordHash).Scan(&u.ID, &u.Admin, &u.CreatedAt, &u.UpdatedAt)
+	return dbError(err)
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+)
+
+type WorkspaceStore interface {
+	List(ctx context.Context) ([]models.Workspace, error)
+	Get(ctx context.Context, id int) (*models.Workspace, error)
+	// Create makes the signed-in user, if any, the owner of the workspace.
+	Create(ctx context.Context, ws *models.Workspace) error
+	// Update and Delete need the owner role.
+	Update(ctx context.Context, ws *models.Workspace) error
+	Delete(ctx context.Context, id int) error
+	// Stats returns entity counts used by the dashboard, over the workspace
+	// with workspaceID if it is not nil and over all workspaces otherwise.
+	Stats(ctx context.Context, workspaceID *int) (*models.WorkspaceStats, error)
+}
+
+const workspaceColumns = `id, name, COALESCE(description, ''), created_at`
+
+// workspaceRow selects the workspace with the ID bound to $2, for the
+// access checks.
+const workspaceRow = `SELECT id FROM workspaces WHERE id = $2`
+
+type workspaceStore struct {
+	db *sql.DB
+}
+
+func scanWorkspace(s scanner) (models.Workspace, error) {
+	var ws models.Workspace
+	err := s.Scan(&ws.ID, &ws.Name, &ws.Description, &ws.CreatedAt)
+	return ws, err
+}
+
+func (s *workspaceStore) List(ctx context.Context) ([]models.Workspace, error) {
+	var where conditions
+	restrict(ctx, &where, "workspaces.id")
+	rows, err := s.db.QueryContext(ctx, `SELECT `+workspaceColumns+` FROM workspaces`+where.String()+` ORDER BY created_at DESC`, where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []models.Workspace{}
+	for rows.Next() {
+		ws, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, ws)
+	}
+	return workspaces, rows.Err()
+}
+
+func (s *workspaceStore) Get(ctx context.Context, id int) (*models.Workspace, error) {
+	where := byID(ctx, id, "workspaces.id")
+	ws, err := scanWorkspace(s.db.QueryRowContext(ctx, `SELECT `+workspaceColumns+` FROM workspaces`+where.String(), where.args...))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &ws, nil
+}
+
+func (s *workspaceStore) Create(ctx context.Context, ws *models.Workspace) error {
+	var owner *int
+	if u := auth.UserFrom(ctx); u != nil {
+		owner = &u.ID
+	}
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			WITH ws AS (
+				INSERT INTO workspaces (name, description)
+				VALUES ($1, $2)
+				RETURNING id, created_at
+			), owner AS (
+				INSERT INTO workspace_members (workspace_id, user_id, role)
+				SELECT id, $3, 'owner' FROM ws WHERE $3::integer IS NOT NULL
+			)
+			SELECT id, created_at FROM ws
+		`, ws.Name, ws.Description, owner).Scan(&ws.ID, &ws.CreatedAt)
+		if err != nil {
+			return dbError(err)
+		}
+		return record(ctx, tx, ActionCreate, nil, ws)
+	})
+}
+
+func (s *workspaceStore) Update(ctx context.Context, ws *models.Workspace) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleOwner, workspaceRow, ws.ID); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanWorkspace, `SELECT `+workspaceColumns+` FROM workspaces WHERE id = $1 FOR UPDATE`, ws.ID)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx, `
+			UPDATE workspaces
+			SET name = $2, description = $3
+			WHERE id = $1
+			RETURNING created_at
+		`, ws.ID, ws.Name, ws.Description).Scan(&ws.CreatedAt)
+		if err != nil {
+			return notFound(err)
+		}
+		return record(ctx, tx, ActionUpdate, before, ws)
+	})
+}
+
+func (s *workspaceStore) Delete(ctx context.Context, id int) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := authorizeRow(ctx, tx, auth.RoleOwner, workspaceRow, id); err != nil {
+			return err
+		}
+		before, err := snapshot(ctx, tx, scanWorkspace, `SELECT `+workspaceColumns+` FROM workspaces WHERE id = $1 FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if err := execDelete(tx.ExecContext(ctx, "DELETE FROM workspaces WHERE id = $1", id)); err != nil {
+			return err
+		}
+		return record(ctx, tx, ActionDelete, before, nil)
+	})
+}
+
+func (s *workspaceStore) Stats(ctx context.Context, workspaceID *int) (*models.WorkspaceStats, error) {
+	// Every count is restricted the same way; each builds its own
+	// conditions so the workspace and user IDs are always bound to the same
+	// parameters.
+	var args []any
+	where := func(workspace string, clauses ...string) string {
+		w := conditions{clauses: clauses}
+		if workspaceID != nil {
+			w.add(workspace+" = $%d", *workspaceID)
+		}
+		restrict(ctx, &w, workspace)
+		args = w.args
+		return w.String()
+	}
+
+	var stats models.WorkspaceStats
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM projects`+where(projectWorkspace)+`),
+			(SELECT COUNT(*) FROM goals`+where(goalWorkspace)+`),
+			(SELECT COUNT(*) FROM tasks`+where(taskWorkspace)+`),
+			(SELECT COUNT(*) FROM tasks`+where(taskWorkspace, "status = 'completed'")+`),
+			(SELECT COUNT(*) FROM tasks`+where(taskWorkspace, "status IN ('pending', 'in_progress')")+`)
+	`, args...).Scan(&stats.TotalProjects, &stats.TotalGoals, &stats.TotalTasks, &stats.CompletedTasks, &stats.PendingTasks)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup(t *testing.T) {
+	t.Run("should write spans to stdout once flushed", func(t *testing.T) {
+		var buf bytes.Buffer
+		shutdown, err := Setup(context.Background(), Options{
+			Exporter:    ExporterStdout,
+			SampleRatio: 1,
+			ServiceName: "gogoal",
+			Stdout:      &buf,
+		})
+		require.NoError(t, err)
+
+		_, span := otel.Tracer("test").Start(context.Background(), "GET /api/v1/goals")
+		span.End()
+		require.NoError(t, shutdown(context.Background()))
+
+		assert.Contains(t, buf.String(), `"Name": "GET /api/v1/goals"`)
+		assert.Contains(t, buf.String(), `"Value": "gogoal"`)
+	})
+
+	t.Run("should do nothing without an exporter", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Options{Exporter: ExporterNone})
+
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("should reject unknown exporters", func(t *testing.T) {
+		_, err := Setup(context.Background(), Options{Exporter: "zipkin"})
+
+		assert.EqualError(t, err, `unknown trace exporter "zipkin"`)
+	})
+}
@@ -0,0 +1,217 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-goal/internal/auth"
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+)
+
+// Allowed status values per entity. The first value of each list is the
+// default the database assigns.
+var (
+	ProjectStatuses = []string{"active", "on_hold", "completed", "archived"}
+	GoalStatuses    = []string{"active", "on_hold", "completed", "cancelled"}
+	TaskStatuses    = []string{"pending", "in_progress", "completed", "cancelled"}
+	FlowStatuses    = []string{"active", "dormant", "paused", "conflicting", "completed", "celebrated", "cancelled", "archived"}
+)
+
+// FlowRelationshipTypes are the ways two flows can relate.
+var FlowRelationshipTypes = []string{"supports", "conflicts_with", "precedes", "overlaps"}
+
+// NoteEntityTypes are the entities a note can be attached to.
+var NoteEntityTypes = []string{"project", "goal", "task", "flow", "workspace"}
+
+// Priority bounds for goals and tasks, 1 being the lowest.
+const (
+	MinPriority = 1
+	MaxPriority = 5
+)
+
+// MaxWeeklyTasks bounds the weekly task limit a flow can set.
+const MaxWeeklyTasks = 1000
+
+// MaxTimelineYears bounds the range of a flow timeline.
+const MaxTimelineYears = 3
+
+// MinPasswordLen is the shortest password accepted at registration.
+// maxPasswordBytes is bcrypt's input limit; longer passwords would be
+// silently truncated.
+const (
+	MinPasswordLen   = 8
+	maxPasswordBytes = 72
+)
+
+// Column defaults from the migrations, applied by Defaults.
+const (
+	defaultPriority  = 1
+	defaultTagColor  = "#666666"
+	defaultFlowColor = "#3B82F6"
+)
+
+// Validator holds the rule set of every model. Rules that need to look at
+// other rows, such as parent-cycle checks, read them through the store.
+type Validator struct {
+	Project   Rules[models.Project]
+	Goal      Rules[models.Goal]
+	Task      Rules[models.Task]
+	Tag       Rules[models.Tag]
+	Note      Rules[models.Note]
+	Workspace Rules[models.Workspace]
+	Member    Rules[models.WorkspaceMember]
+	Flow      Rules[models.Flow]
+	// FlowRelationship expects FlowID to be set from the request path.
+	FlowRelationship Rules[models.FlowRelationship]
+	FlowConstraints  Rules[models.FlowConstraints]
+	// FlowTransition checks the reason given for a change of flow status.
+	FlowTransition Rules[models.FlowTransition]
+	TimelineRange  Rules[models.TimelineRange]
+	// Registration checks the credentials of a new account.
+	Registration Rules[models.Credentials]
+	APIToken     Rules[models.APIToken]
+}
+
+// New returns a Validator whose lookups go through s.
+func New(s *store.Store) *Validator {
+	tagParent := func(ctx context.Context, id int) (*int, error) {
+		t, err := s.Tags.Get(ctx, id)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return t.ParentID, nil
+	}
+
+	return &Validator{
+		Project: Rules[models.Project]{
+			Field("title", func(p *models.Project) string { return p.Title }, Required(), MaxLen(255)),
+			Field("status", func(p *models.Project) string { return p.Status }, OneOf(ProjectStatuses...)),
+			Field("workspace_id", func(p *models.Project) *int { return p.WorkspaceID }, Optional(Positive())),
+			Field("flow_id", func(p *models.Project) *int { return p.FlowID }, Optional(Positive())),
+		},
+		Goal: Rules[models.Goal]{
+			Field("title", func(g *models.Goal) string { return g.Title }, Required(), MaxLen(255)),
+			Field("status", func(g *models.Goal) string { return g.Status }, OneOf(GoalStatuses...)),
+			Field("priority", func(g *models.Goal) int { return g.Priority }, Between(MinPriority, MaxPriority)),
+			Field("project_id", func(g *models.Goal) *int { return g.ProjectID }, Optional(Positive())),
+			Field("flow_id", func(g *models.Goal) *int { return g.FlowID }, Optional(Positive())),
+		},
+		Task: Rules[models.Task]{
+			Field("title", func(t *models.Task) string { return t.Title }, Required(), MaxLen(255)),
+			Field("status", func(t *models.Task) string { return t.Status }, OneOf(TaskStatuses...)),
+			Field("priority", func(t *models.Task) int { return t.Priority }, Between(MinPriority, MaxPriority)),
+			Field("goal_id", func(t *models.Task) *int { return t.GoalID }, Optional(Positive())),
+			Field("project_id", func(t *models.Task) *int { return t.ProjectID }, Optional(Positive())),
+			Field("flow_id", func(t *models.Task) *int { return t.FlowID }, Optional(Positive())),
+		},
+		Tag: Rules[models.Tag]{
+			Field("name", func(t *models.Tag) string { return t.Name }, Required(), MaxLen(100)),
+			Field("color", func(t *models.Tag) string { return t.Color }, HexColor()),
+			NoParentCycle("parent_id", func(t *models.Tag) int { return t.ID }, func(t *models.Tag) *int { return t.ParentID }, tagParent),
+		},
+		Note: Rules[models.Note]{
+			Field("title", func(n *models.Note) string { return n.Title }, Required(), MaxLen(255)),
+			Field("entity_type", func(n *models.Note) string { return n.EntityType }, OneOf(append([]string{""}, NoteEntityTypes...)...)),
+			Custom("entity_id", "required", "is required when entity_type is set", func(n *models.Note) bool {
+				return (n.EntityType == "") == (n.EntityID == nil)
+			}),
+		},
+		Workspace: Rules[models.Workspace]{
+			Field("name", func(ws *models.Workspace) string { return ws.Name }, Required(), MaxLen(255)),
+		},
+		Member: Rules[models.WorkspaceMember]{
+			// New members are looked up by email; existing ones by user ID.
+			Custom("email", "required", "is required", func(m *models.WorkspaceMember) bool {
+				return m.UserID != 0 || m.Email != ""
+			}),
+			Field("role", func(m *models.WorkspaceMember) string { return m.Role }, OneOf(auth.Roles...)),
+		},
+		Flow: Rules[models.Flow]{
+			Field("title", func(f *models.Flow) string { return f.Title }, Required(), MaxLen(255)),
+			Field("color", func(f *models.Flow) string { return f.Color }, HexColor()),
+			Field("status", func(f *models.Flow) string { return f.Status }, OneOf(FlowStatuses...)),
+			DateOrder("start_date", "end_date", func(f *models.Flow) *time.Time { return f.StartDate }, func(f *models.Flow) *time.Time { return f.EndDate }),
+		},
+		FlowRelationship: Rules[models.FlowRelationship]{
+			Field("type", func(r *models.FlowRelationship) string { return r.Type }, OneOf(FlowRelationshipTypes...)),
+			Field("related_flow_id", func(r *models.FlowRelationship) int { return r.RelatedFlowID }, Positive()),
+			Custom("related_flow_id", "self_link", "must not be the flow itself", func(r *models.FlowRelationship) bool {
+				return r.FlowID != r.RelatedFlowID
+			}),
+		},
+		FlowConstraints: Rules[models.FlowConstraints]{
+			Field("quiet_start", func(c *models.FlowConstraints) *string { return c.QuietStart }, Optional(ClockTime())),
+			Field("quiet_end", func(c *models.FlowConstraints) *string { return c.QuietEnd }, Optional(ClockTime())),
+			Custom("quiet_end", "required", "must be set together with quiet_start", func(c *models.FlowConstraints) bool {
+				return (c.QuietStart == nil) == (c.QuietEnd == nil)
+			}),
+			Field("max_weekly_tasks", func(c *models.FlowConstraints) *int { return c.MaxWeeklyTasks }, Optional(Between(1, MaxWeeklyTasks))),
+			Field("forbidden_tags", func(c *models.FlowConstraints) []string { return c.ForbiddenTags }, Each(Required(), MaxLen(100))),
+		},
+		FlowTransition: Rules[models.FlowTransition]{
+			Field("to_status", func(t *models.FlowTransition) string { return t.ToStatus }, OneOf(FlowStatuses...)),
+			Field("reason", func(t *models.FlowTransition) string { return t.Reason }, MaxLen(500)),
+		},
+		TimelineRange: Rules[models.TimelineRange]{
+			Custom("from", "required", "is required", func(r *models.TimelineRange) bool { return r.From != nil }),
+			Custom("to", "required", "is required", func(r *models.TimelineRange) bool { return r.To != nil }),
+			DateOrder("from", "to", func(r *models.TimelineRange) *time.Time { return r.From }, func(r *models.TimelineRange) *time.Time { return r.To }),
+			Custom("to", "too_long", fmt.Sprintf("must be at most %d years after from", MaxTimelineYears), func(r *models.TimelineRange) bool {
+				return r.From == nil || r.To == nil || !r.To.After(r.From.AddDate(MaxTimelineYears, 0, 0))
+			}),
+		},
+		Registration: Rules[models.Credentials]{
+			Field("email", func(c *models.Credentials) string { return c.Email }, Required(), MaxLen(254), Email()),
+			Field("name", func(c *models.Credentials) string { return c.Name }, MaxLen(100)),
+			Field("password", func(c *models.Credentials) string { return c.Password }, MinLen(MinPasswordLen), MaxBytes(maxPasswordBytes)),
+		},
+		APIToken: Rules[models.APIToken]{
+			Field("name", func(t *models.APIToken) string { return t.Name }, Required(), MaxLen(100)),
+			Field("scopes", func(t *models.APIToken) []string { return t.Scopes }, NotEmpty[string](), Each(OneOf(auth.Scopes...))),
+			Field("expires_at", func(t *models.APIToken) *time.Time { return t.ExpiresAt }, Optional(Future())),
+		},
+	}
+}
+
+// Defaults fills the fields a create payload may omit with the column
+// defaults from the migrations. It is applied before validating a new
+// entity; updates are validated as sent.
+func Defaults(v any) {
+	switch m := v.(type) {
+	case *models.Project:
+		if m.Status == "" {
+			m.Status = ProjectStatuses[0]
+		}
+	case *models.Goal:
+		if m.Status == "" {
+			m.Status = GoalStatuses[0]
+		}
+		if m.Priority == 0 {
+			m.Priority = defaultPriority
+		}
+	case *models.Task:
+		if m.Status == "" {
+			m.Status = TaskStatuses[0]
+		}
+		if m.Priority == 0 {
+			m.Priority = defaultPriority
+		}
+	case *models.Tag:
+		if m.Color == "" {
+			m.Color = defaultTagColor
+		}
+	case *models.Flow:
+		if m.Color == "" {
+			m.Color = defaultFlowColor
+		}
+		if m.Status == "" {
+			m.Status = FlowStatuses[0]
+		}
+	}
+}
@@ -0,0 +1,345 @@
+// Package validation checks entity payloads before they reach the store. Each
+// model has a declarative rule set (see rules.go) shared by the REST handlers
+// in internal/api and the GraphQL resolvers in internal/graphql, so both APIs
+// accept and reject exactly the same input.
+package validation
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError describes why a single field was rejected. Code is a stable
+// identifier such as "required" or "too_long"; Message is meant for humans.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// Errors is returned by Rules.Validate when one or more rules fail.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Rule checks one aspect of a T and returns nil when it holds.
+type Rule[T any] func(ctx context.Context, v *T) (*FieldError, error)
+
+// Rules is the rule set of a model.
+type Rules[T any] []Rule[T]
+
+// Validate runs every rule against v. It returns Errors listing each failed
+// rule, or the first error a rule could not be evaluated because of, such as
+// a failed database lookup.
+func (rs Rules[T]) Validate(ctx context.Context, v *T) error {
+	var errs Errors
+	for _, rule := range rs {
+		fe, err := rule(ctx, v)
+		if err != nil {
+			return err
+		}
+		if fe != nil {
+			errs = append(errs, *fe)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Check tests a single value. It returns an empty code when the value is
+// valid.
+type Check[V any] func(V) (code, message string)
+
+// Field applies checks in order to the value get extracts from a T and
+// reports the first failure under name.
+func Field[T, V any](name string, get func(*T) V, checks ...Check[V]) Rule[T] {
+	return func(ctx context.Context, v *T) (*FieldError, error) {
+		value := get(v)
+		for _, check := range checks {
+			if code, msg := check(value); code != "" {
+				return &FieldError{Field: name, Code: code, Message: msg}, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+// Required rejects blank strings.
+func Required() Check[string] {
+	return func(s string) (string, string) {
+		if strings.TrimSpace(s) == "" {
+			return "required", "is required"
+		}
+		return "", ""
+	}
+}
+
+// MaxLen rejects strings longer than n characters, matching a VARCHAR(n)
+// column.
+func MaxLen(n int) Check[string] {
+	return func(s string) (string, string) {
+		if utf8.RuneCountInString(s) > n {
+			return "too_long", fmt.Sprintf("must be at most %d characters", n)
+		}
+		return "", ""
+	}
+}
+
+// MinLen rejects strings shorter than n characters.
+func MinLen(n int) Check[string] {
+	return func(s string) (string, string) {
+		if utf8.RuneCountInString(s) < n {
+			return "too_short", fmt.Sprintf("must be at least %d characters", n)
+		}
+		return "", ""
+	}
+}
+
+// MaxBytes rejects strings longer than n bytes, for limits such as
+// bcrypt's that count bytes rather than characters.
+func MaxBytes(n int) Check[string] {
+	return func(s string) (string, string) {
+		if len(s) > n {
+			return "too_long", fmt.Sprintf("must be at most %d bytes", n)
+		}
+		return "", ""
+	}
+}
+
+// OneOf rejects strings other than the given values.
+func OneOf(values ...string) Check[string] {
+	return func(s string) (string, string) {
+		if !slices.Contains(values, s) {
+			return "invalid_choice", "must be one of " + strings.Join(values, ", ")
+		}
+		return "", ""
+	}
+}
+
+var hexColor = regexp.MustCompile(`^#(?:[0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+
+// HexColor rejects strings that are not CSS hex colors such as #3B82F6.
+func HexColor() Check[string] {
+	return func(s string) (string, string) {
+		if !hexColor.MatchString(s) {
+			return "invalid_format", "must be a hex color such as #3B82F6"
+		}
+		return "", ""
+	}
+}
+
+var clockTime = regexp.MustCompile(`^(?:[01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// ClockTime rejects strings that are not 24-hour HH:MM times such as 22:30.
+func ClockTime() Check[string] {
+	return func(s string) (string, string) {
+		if !clockTime.MatchString(s) {
+			return "invalid_format", "must be a time such as 22:30"
+		}
+		return "", ""
+	}
+}
+
+// Email rejects strings that are not a single plain address such as
+// ada@example.com. Display names and comments are not accepted.
+func Email() Check[string] {
+	return func(s string) (string, string) {
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s || addr.Name != "" {
+			return "invalid_format", "must be an email address such as ada@example.com"
+		}
+		return "", ""
+	}
+}
+
+// Between rejects integers outside [lo, hi].
+func Between(lo, hi int) Check[int] {
+	return func(n int) (string, string) {
+		if n < lo || n > hi {
+			return "out_of_range", fmt.Sprintf("must be between %d and %d", lo, hi)
+		}
+		return "", ""
+	}
+}
+
+// Optional applies checks to the pointed-to value and accepts nil.
+func Optional[V any](checks ...Check[V]) Check[*V] {
+	return func(p *V) (string, string) {
+		if p == nil {
+			return "", ""
+		}
+		for _, check := range checks {
+			if code, msg := check(*p); code != "" {
+				return code, msg
+			}
+		}
+		return "", ""
+	}
+}
+
+// Positive rejects IDs below 1.
+func Positive() Check[int] {
+	return func(n int) (string, string) {
+		if n < 1 {
+			return "invalid", "must be a positive ID"
+		}
+		return "", ""
+	}
+}
+
+// NotEmpty rejects empty lists.
+func NotEmpty[V any]() Check[[]V] {
+	return func(list []V) (string, string) {
+		if len(list) == 0 {
+			return "required", "must not be empty"
+		}
+		return "", ""
+	}
+}
+
+// Each applies checks to every element of a list and reports the first
+// failure.
+func Each[V any](checks ...Check[V]) Check[[]V] {
+	return func(list []V) (string, string) {
+		for _, v := range list {
+			for _, check := range checks {
+				if code, msg := check(v); code != "" {
+					return code, msg
+				}
+			}
+		}
+		return "", ""
+	}
+}
+
+// Future rejects times that are not after now.
+func Future() Check[time.Time] {
+	return func(t time.Time) (string, string) {
+		if !t.After(time.Now()) {
+			return "not_future", "must be in the future"
+		}
+		return "", ""
+	}
+}
+
+// DateOrder requires the date end extracts to not precede the one start
+// extracts. The failure is reported under endName; unset dates pass.
+func DateOrder[T any](startName, endName string, start, end func(*T) *time.Time) Rule[T] {
+	return func(ctx context.Context, v *T) (*FieldError, error) {
+		s, e := start(v), end(v)
+		if s != nil && e != nil && e.Before(*s) {
+			return &FieldError{Field: endName, Code: "before_start", Message: "must not be before " + startName}, nil
+		}
+		return nil, nil
+	}
+}
+
+// ParentFunc returns the parent ID of the row with the given ID, or nil for
+// a root row.
+type ParentFunc func(ctx context.Context, id int) (*int, error)
+
+// NoParentCycle rejects a parent that is the row itself or one of its
+// descendants. parentOf is used to walk up from the new parent; a parent
+// that does not exist is left for the foreign key to reject.
+func NoParentCycle[T any](name string, id func(*T) int, parent func(*T) *int, parentOf ParentFunc) Rule[T] {
+	return func(ctx context.Context, v *T) (*FieldError, error) {
+		self, p := id(v), parent(v)
+		if p == nil {
+			return nil, nil
+		}
+		if *p == self {
+			return &FieldError{Field: name, Code: "cycle", Message: "must not refer to itself"}, nil
+		}
+		if self == 0 {
+			return nil, nil
+		}
+
+		seen := map[int]bool{}
+		for cur := p; cur != nil && !seen[*cur]; {
+			if *cur == self {
+				return &FieldError{Field: name, Code: "cycle", Message: "must not be a descendant"}, nil
+			}
+			seen[*cur] = true
+
+			next, err := parentOf(ctx, *cur)
+			if err != nil {
+				return nil, err
+			}
+			cur = next
+		}
+		return nil, nil
+	}
+}
+
+// SuccessorFunc returns the rows the row with the given ID points to along
+// the edges a NoCycle rule follows.
+type SuccessorFunc func(ctx context.Context, id int) ([]int, error)
+
+// NoCycle rejects an edge from one row to another when the second already
+// leads back to the first, so the edges stay acyclic. successors is used to
+// walk forward from the edge's target.
+func NoCycle[T any](name string, from, to func(*T) int, successors SuccessorFunc) Rule[T] {
+	return func(ctx context.Context, v *T) (*FieldError, error) {
+		start, target := from(v), to(v)
+		queue := []int{target}
+		seen := map[int]bool{target: true}
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			if cur == start {
+				return &FieldError{Field: name, Code: "cycle", Message: "must not create a cycle"}, nil
+			}
+
+			next, err := successors(ctx, cur)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range next {
+				if !seen[id] {
+					seen[id] = true
+					queue = append(queue, id)
+				}
+			}
+		}
+		return nil, nil
+	}
+}
+
+// When applies rule only to values for which applies holds.
+func When[T any](applies func(*T) bool, rule Rule[T]) Rule[T] {
+	return func(ctx context.Context, v *T) (*FieldError, error) {
+		if !applies(v) {
+			return nil, nil
+		}
+		return rule(ctx, v)
+	}
+}
+
+// Custom turns a predicate over the whole model into a rule, for checks that
+// involve more than one field.
+func Custom[T any](name, code, message string, ok func(*T) bool) Rule[T] {
+	return func(ctx context.Context, v *T) (*FieldError, error) {
+		if !ok(v) {
+			return &FieldError{Field: name, Code: code, Message: message}, nil
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,221 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-goal/internal/models"
+	"go-goal/internal/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+	ctx := context.Background()
+	rules := Rules[models.Tag]{
+		Field("name", func(t *models.Tag) string { return t.Name }, Required(), MaxLen(5)),
+		Field("color", func(t *models.Tag) string { return t.Color }, HexColor()),
+	}
+
+	t.Run("should accept a valid model", func(t *testing.T) {
+		assert.NoError(t, rules.Validate(ctx, &models.Tag{Name: "work", Color: "#3B82F6"}))
+		assert.NoError(t, rules.Validate(ctx, &models.Tag{Name: "work", Color: "#fff"}))
+	})
+
+	t.Run("should report every failing field", func(t *testing.T) {
+		err := rules.Validate(ctx, &models.Tag{Name: " ", Color: "blue"})
+
+		assert.Equal(t, Errors{
+			{Field: "name", Code: "required", Message: "is required"},
+			{Field: "color", Code: "invalid_format", Message: "must be a hex color such as #3B82F6"},
+		}, err)
+		assert.EqualError(t, err, "validation failed: name is required; color must be a hex color such as #3B82F6")
+	})
+
+	t.Run("should report only the first failing check of a field", func(t *testing.T) {
+		err := rules.Validate(ctx, &models.Tag{Name: "urgently", Color: "#000000"})
+
+		assert.Equal(t, Errors{{Field: "name", Code: "too_long", Message: "must be at most 5 characters"}}, err)
+	})
+
+	t.Run("should count characters rather than bytes", func(t *testing.T) {
+		assert.NoError(t, rules.Validate(ctx, &models.Tag{Name: "čćžšđ", Color: "#000"}))
+	})
+}
+
+func TestEmail(t *testing.T) {
+	check := Email()
+
+	for _, ok := range []string{"ada@example.com", "a.b+c@sub.example.org"} {
+		code, _ := check(ok)
+		assert.Empty(t, code, ok)
+	}
+	for _, bad := range []string{"ada", "Ada <ada@example.com>", "ada@", "@example.com"} {
+		code, _ := check(bad)
+		assert.Equal(t, "invalid_format", code, bad)
+	}
+}
+
+func TestDateOrder(t *testing.T) {
+	rule := DateOrder("start_date", "end_date",
+		func(f *models.Flow) *time.Time { return f.StartDate },
+		func(f *models.Flow) *time.Time { return f.EndDate })
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	before := start.AddDate(0, 0, -1)
+
+	fe, err := rule(context.Background(), &models.Flow{StartDate: &start, EndDate: &before})
+	require.NoError(t, err)
+	assert.Equal(t, &FieldError{Field: "end_date", Code: "before_start", Message: "must not be before start_date"}, fe)
+
+	fe, err = rule(context.Background(), &models.Flow{StartDate: &start, EndDate: &start})
+	require.NoError(t, err)
+	assert.Nil(t, fe)
+
+	fe, err = rule(context.Background(), &models.Flow{EndDate: &before})
+	require.NoError(t, err)
+	assert.Nil(t, fe)
+}
+
+func TestNoParentCycle(t *testing.T) {
+	// 1 <- 2 <- 3, and 4 <- 5 <- 4 is already a loop.
+	parents := map[int]int{2: 1, 3: 2, 4: 5, 5: 4}
+	parentOf := func(ctx context.Context, id int) (*int, error) {
+		if p, ok := parents[id]; ok {
+			return &p, nil
+		}
+		return nil, nil
+	}
+	rule := NoParentCycle("parent_id",
+		func(f *models.Flow) int { return f.ID },
+		func(f *models.Flow) *int { return f.ParentID },
+		parentOf)
+	check := func(id, parent int) *FieldError {
+		fe, err := rule(context.Background(), &models.Flow{ID: id, ParentID: &parent})
+		require.NoError(t, err)
+		return fe
+	}
+
+	t.Run("should reject a row as its own parent", func(t *testing.T) {
+		assert.Equal(t, "must not refer to itself", check(2, 2).Message)
+	})
+
+	t.Run("should reject a descendant as parent", func(t *testing.T) {
+		assert.Equal(t, &FieldError{Field: "parent_id", Code: "cycle", Message: "must not be a descendant"}, check(1, 3))
+	})
+
+	t.Run("should accept an unrelated parent", func(t *testing.T) {
+		assert.Nil(t, check(3, 1))
+		assert.Nil(t, check(1, 99))
+	})
+
+	t.Run("should stop on loops that do not include the row", func(t *testing.T) {
+		assert.Nil(t, check(1, 4))
+	})
+
+	t.Run("should return lookup errors", func(t *testing.T) {
+		boom := errors.New("boom")
+		rule := NoParentCycle("parent_id",
+			func(f *models.Flow) int { return f.ID },
+			func(f *models.Flow) *int { return f.ParentID },
+			func(ctx context.Context, id int) (*int, error) { return nil, boom })
+		parent := 2
+
+		_, err := rule(context.Background(), &models.Flow{ID: 1, ParentID: &parent})
+
+		assert.ErrorIs(t, err, boom)
+	})
+}
+
+func TestNoCycle(t *testing.T) {
+	// 1 -> 2 -> 3, and 4 -> 5 -> 4 is already a loop.
+	edges := map[int][]int{1: {2}, 2: {3}, 4: {5}, 5: {4}}
+	rule := NoCycle("related_flow_id",
+		func(r *models.FlowRelationship) int { return r.FlowID },
+		func(r *models.FlowRelationship) int { return r.RelatedFlowID },
+		func(ctx context.Context, id int) ([]int, error) { return edges[id], nil })
+	check := func(from, to int) *FieldError {
+		fe, err := rule(context.Background(), &models.FlowRelationship{FlowID: from, RelatedFlowID: to})
+		require.NoError(t, err)
+		return fe
+	}
+
+	t.Run("should reject an edge closing a cycle", func(t *testing.T) {
+		assert.Equal(t, &FieldError{Field: "related_flow_id", Code: "cycle", Message: "must not create a cycle"}, check(3, 1))
+	})
+
+	t.Run("should accept edges that keep the graph acyclic", func(t *testing.T) {
+		assert.Nil(t, check(1, 3))
+		assert.Nil(t, check(3, 6))
+	})
+
+	t.Run("should stop on loops that do not include the row", func(t *testing.T) {
+		assert.Nil(t, check(1, 4))
+	})
+}
+
+func TestValidator(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	v := New(store.New(db))
+	ctx := context.Background()
+
+	t.Run("should apply defaults before validating new entities", func(t *testing.T) {
+		task := models.Task{Title: "Write report"}
+		Defaults(&task)
+
+		assert.NoError(t, v.Task.Validate(ctx, &task))
+		assert.Equal(t, "pending", task.Status)
+		assert.Equal(t, 1, task.Priority)
+	})
+
+	t.Run("should reject unknown statuses and out-of-range priorities", func(t *testing.T) {
+		err := v.Goal.Validate(ctx, &models.Goal{Title: "Ship", Status: "someday", Priority: 9})
+
+		var errs Errors
+		require.ErrorAs(t, err, &errs)
+		assert.Len(t, errs, 2)
+		assert.Equal(t, "status", errs[0].Field)
+		assert.Equal(t, "invalid_choice", errs[0].Code)
+		assert.True(t, strings.HasPrefix(errs[0].Message, "must be one of active, "))
+		assert.Equal(t, FieldError{Field: "priority", Code: "out_of_range", Message: "must be between 1 and 5"}, errs[1])
+	})
+
+	t.Run("should require an entity ID for attached notes", func(t *testing.T) {
+		err := v.Note.Validate(ctx, &models.Note{Title: "Idea", EntityType: "goal"})
+
+		assert.Equal(t, Errors{{Field: "entity_id", Code: "required", Message: "is required when entity_type is set"}}, err)
+	})
+
+	t.Run("should walk tag parents through the store", func(t *testing.T) {
+		mock.ExpectQuery(`FROM tags WHERE id = \$1`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "color", "parent_id", "created_at"}).AddRow(2, "child", "#666666", 1, time.Now()))
+		parent := 2
+
+		err := v.Tag.Validate(ctx, &models.Tag{ID: 1, Name: "root", Color: "#666666", ParentID: &parent})
+
+		assert.Equal(t, Errors{{Field: "parent_id", Code: "cycle", Message: "must not be a descendant"}}, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject self-links", func(t *testing.T) {
+		err := v.FlowRelationship.Validate(ctx, &models.FlowRelationship{FlowID: 1, RelatedFlowID: 1, Type: "supports"})
+
+		assert.Equal(t, Errors{{Field: "related_flow_id", Code: "self_link", Message: "must not be the flow itself"}}, err)
+	})
+
+	t.Run("should bound timeline ranges", func(t *testing.T) {
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := from.AddDate(4, 0, 0)
+
+		assert.Equal(t, Errors{{Field: "from", Code: "required", Message: "is required"}}, v.TimelineRange.Validate(ctx, &models.TimelineRange{To: &to}))
+		assert.Equal(t, Errors{{Field: "to", Code: "too_long", Message: "must be at most 3 years after from"}}, v.TimelineRange.Validate(ctx, &models.TimelineRange{From: &from, To: &to}))
+	})
+}
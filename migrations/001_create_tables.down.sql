@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS task_tags;
+DROP TABLE IF EXISTS goal_tags;
+DROP TABLE IF EXISTS project_tags;
+DROP TABLE IF EXISTS notes;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS goals;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS workspaces;
+DROP FUNCTION IF EXISTS update_updated_at_column();
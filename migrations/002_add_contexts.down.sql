@@ -0,0 +1,5 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS context_id;
+ALTER TABLE goals DROP COLUMN IF EXISTS context_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS context_id;
+
+DROP TABLE IF EXISTS contexts;
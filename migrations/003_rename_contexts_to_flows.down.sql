@@ -0,0 +1,31 @@
+-- Restore trigger
+DROP TRIGGER IF EXISTS update_flows_updated_at ON flows;
+CREATE TRIGGER update_contexts_updated_at BEFORE UPDATE ON flows
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Restore foreign key constraints
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_flow_id_fkey;
+ALTER TABLE goals DROP CONSTRAINT IF EXISTS goals_flow_id_fkey;
+ALTER TABLE projects DROP CONSTRAINT IF EXISTS projects_flow_id_fkey;
+ALTER TABLE flows DROP CONSTRAINT IF EXISTS flows_parent_id_fkey;
+
+-- Rename indexes back
+ALTER INDEX idx_flows_workspace_id RENAME TO idx_contexts_workspace_id;
+ALTER INDEX idx_flows_parent_id RENAME TO idx_contexts_parent_id;
+ALTER INDEX idx_flows_status RENAME TO idx_contexts_status;
+ALTER INDEX idx_projects_flow_id RENAME TO idx_projects_context_id;
+ALTER INDEX idx_goals_flow_id RENAME TO idx_goals_context_id;
+ALTER INDEX idx_tasks_flow_id RENAME TO idx_tasks_context_id;
+
+-- Rename flow_id columns back to context_id
+ALTER TABLE projects RENAME COLUMN flow_id TO context_id;
+ALTER TABLE goals RENAME COLUMN flow_id TO context_id;
+ALTER TABLE tasks RENAME COLUMN flow_id TO context_id;
+
+-- Rename flows table back to contexts
+ALTER TABLE flows RENAME TO contexts;
+
+ALTER TABLE contexts ADD CONSTRAINT contexts_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES contexts(id) ON DELETE SET NULL;
+ALTER TABLE projects ADD CONSTRAINT projects_context_id_fkey FOREIGN KEY (context_id) REFERENCES contexts(id) ON DELETE SET NULL;
+ALTER TABLE goals ADD CONSTRAINT goals_context_id_fkey FOREIGN KEY (context_id) REFERENCES contexts(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD CONSTRAINT tasks_context_id_fkey FOREIGN KEY (context_id) REFERENCES contexts(id) ON DELETE SET NULL;
@@ -0,0 +1,8 @@
+-- Allow notes to be tagged like projects, goals and tasks
+CREATE TABLE IF NOT EXISTS note_tags (
+    note_id INTEGER REFERENCES notes(id) ON DELETE CASCADE,
+    tag_id INTEGER REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (note_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_tags_tag_id ON note_tags(tag_id);
@@ -0,0 +1,31 @@
+-- Workspace membership. Every entity belongs to a workspace, directly
+-- (projects, flows) or through its project, goal or flow, and users only see
+-- and change entities of workspaces they are members of
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'editor', 'commenter', 'viewer')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members(user_id);
+
+-- Projects and flows created without a workspace move into a shared one so
+-- they stay reachable
+INSERT INTO workspaces (name, description)
+SELECT 'Shared', 'Projects and flows created before workspaces were enforced'
+WHERE EXISTS (SELECT 1 FROM projects WHERE workspace_id IS NULL)
+   OR EXISTS (SELECT 1 FROM flows WHERE workspace_id IS NULL);
+
+UPDATE projects SET workspace_id = (SELECT MAX(id) FROM workspaces) WHERE workspace_id IS NULL;
+UPDATE flows SET workspace_id = (SELECT MAX(id) FROM workspaces) WHERE workspace_id IS NULL;
+
+-- Until now every user could see and change every workspace; keep them
+-- working on the existing ones as editors. Only the first registered user
+-- becomes their owner, so each workspace keeps someone who can manage its
+-- members without everyone being able to delete it
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT w.id, u.id, CASE WHEN u.id = (SELECT MIN(id) FROM users) THEN 'owner' ELSE 'editor' END
+FROM workspaces w CROSS JOIN users u
+ON CONFLICT DO NOTHING;
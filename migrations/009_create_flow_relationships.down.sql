@@ -0,0 +1,17 @@
+-- The table may predate this migration, renamed from context_relationships
+-- by migration 003, so rolling back keeps it and its rows and only undoes
+-- the changes made here. Rows the up migration merged or removed, including
+-- nested_under ones moved to flows.parent_id, are not brought back
+DROP INDEX IF EXISTS idx_flow_relationships_related_flow_id;
+DROP INDEX IF EXISTS idx_flow_relationships_symmetric;
+DROP INDEX IF EXISTS idx_flow_relationships_pair;
+
+ALTER TABLE flow_relationships
+    DROP CONSTRAINT IF EXISTS flow_relationships_self_check,
+    DROP CONSTRAINT IF EXISTS flow_relationships_type_check,
+    ALTER COLUMN flow_id DROP NOT NULL,
+    ALTER COLUMN related_flow_id DROP NOT NULL;
+
+UPDATE flow_relationships
+SET flow_id = related_flow_id, related_flow_id = flow_id, relationship_type = 'sequential_after'
+WHERE relationship_type = 'precedes';
@@ -0,0 +1,42 @@
+-- Relationships between flows. supports and precedes read from flow_id to
+-- related_flow_id; conflicts_with and overlaps hold both ways, so each pair
+-- is stored once. Databases that still have the table renamed by migration
+-- 003 keep their rows, converted to the new types
+CREATE TABLE IF NOT EXISTS flow_relationships (
+    id SERIAL PRIMARY KEY,
+    flow_id INTEGER REFERENCES flows(id) ON DELETE CASCADE,
+    related_flow_id INTEGER REFERENCES flows(id) ON DELETE CASCADE,
+    relationship_type VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- nested_under is what flows.parent_id records
+UPDATE flows f
+SET parent_id = r.related_flow_id
+FROM flow_relationships r
+WHERE r.relationship_type = 'nested_under' AND r.flow_id = f.id AND f.parent_id IS NULL AND r.related_flow_id <> f.id;
+
+UPDATE flow_relationships
+SET flow_id = related_flow_id, related_flow_id = flow_id, relationship_type = 'precedes'
+WHERE relationship_type = 'sequential_after';
+
+DELETE FROM flow_relationships
+WHERE flow_id IS NULL OR related_flow_id IS NULL OR flow_id = related_flow_id
+   OR relationship_type NOT IN ('supports', 'conflicts_with', 'precedes', 'overlaps');
+
+DELETE FROM flow_relationships r
+USING flow_relationships dup
+WHERE dup.id < r.id AND dup.relationship_type = r.relationship_type
+  AND ((dup.flow_id = r.flow_id AND dup.related_flow_id = r.related_flow_id)
+    OR (r.relationship_type IN ('conflicts_with', 'overlaps') AND dup.flow_id = r.related_flow_id AND dup.related_flow_id = r.flow_id));
+
+ALTER TABLE flow_relationships
+    ALTER COLUMN flow_id SET NOT NULL,
+    ALTER COLUMN related_flow_id SET NOT NULL,
+    ADD CONSTRAINT flow_relationships_type_check CHECK (relationship_type IN ('supports', 'conflicts_with', 'precedes', 'overlaps')),
+    ADD CONSTRAINT flow_relationships_self_check CHECK (flow_id <> related_flow_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_flow_relationships_pair ON flow_relationships(flow_id, related_flow_id, relationship_type);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_flow_relationships_symmetric ON flow_relationships(LEAST(flow_id, related_flow_id), GREATEST(flow_id, related_flow_id), relationship_type)
+    WHERE relationship_type IN ('conflicts_with', 'overlaps');
+CREATE INDEX IF NOT EXISTS idx_flow_relationships_related_flow_id ON flow_relationships(related_flow_id);
@@ -0,0 +1,16 @@
+-- Rules a flow imposes while it is active. Quiet hours apply to the tasks
+-- and goals of flows declared in conflict with it and may wrap past
+-- midnight; the weekly task limit and forbidden tags apply to the flow's
+-- own entities
+CREATE TABLE IF NOT EXISTS flow_constraints (
+    flow_id INTEGER PRIMARY KEY REFERENCES flows(id) ON DELETE CASCADE,
+    quiet_start TIME,
+    quiet_end TIME,
+    max_weekly_tasks INTEGER CHECK (max_weekly_tasks > 0),
+    forbidden_tags TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((quiet_start IS NULL) = (quiet_end IS NULL))
+);
+
+CREATE TRIGGER update_flow_constraints_updated_at BEFORE UPDATE ON flow_constraints
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
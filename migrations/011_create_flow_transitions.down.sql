@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS flow_transitions;
+ALTER TABLE flows DROP CONSTRAINT IF EXISTS flows_status_check;
+ALTER TABLE flows ALTER COLUMN status DROP NOT NULL;
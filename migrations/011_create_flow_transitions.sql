@@ -0,0 +1,22 @@
+-- Flow statuses follow a state machine enforced by the store; each change
+-- of status is recorded here with the optional reason given for it.
+-- Statuses left over from when the column was free text become active
+UPDATE flows SET status = 'active'
+WHERE status IS NULL
+   OR status NOT IN ('active', 'dormant', 'paused', 'conflicting', 'completed', 'celebrated', 'cancelled', 'archived');
+
+ALTER TABLE flows ALTER COLUMN status SET NOT NULL;
+ALTER TABLE flows ADD CONSTRAINT flows_status_check
+    CHECK (status IN ('active', 'dormant', 'paused', 'conflicting', 'completed', 'celebrated', 'cancelled', 'archived'));
+
+CREATE TABLE IF NOT EXISTS flow_transitions (
+    id SERIAL PRIMARY KEY,
+    flow_id INTEGER NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    reason TEXT,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_flow_transitions_flow_id ON flow_transitions(flow_id);
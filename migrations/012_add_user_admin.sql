@@ -0,0 +1,6 @@
+-- Admins manage what all workspaces share, such as tags. The first
+-- registered user becomes one, as they already own the workspaces that
+-- existed before workspaces were enforced
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users SET is_admin = TRUE WHERE id = (SELECT MIN(id) FROM users);
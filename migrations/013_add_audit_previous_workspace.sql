@@ -0,0 +1,5 @@
+-- Updates that move an entity to another workspace also record the one it
+-- left, so members of that workspace keep seeing the move in its history
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS previous_workspace_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_previous_workspace_id ON audit_events(previous_workspace_id);
@@ -0,0 +1,11 @@
+// Package migrations embeds the numbered SQL migration files so the server
+// binary can apply them without reading from the working directory.
+//
+// Files are named NNN_description.sql for the up migration and
+// NNN_description.down.sql for the optional paired down migration.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
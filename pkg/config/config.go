@@ -1,14 +1,53 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
 	Port        string
 	DatabaseURL string
+	AutoMigrate bool
+	
+	// Server Configuration
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownDelay     time.Duration
+	ShutdownTimeout   time.Duration
+	
+	// Database Pool Configuration
+	DBMaxOpenConns     int
+	DBMaxIdleConns     int
+	DBConnMaxLifetime  time.Duration
+	DBConnMaxIdleTime  time.Duration
+	DBStatementTimeout time.Duration
+	
+	// Session Configuration
+	SessionTTL          time.Duration
+	SessionCookieSecure bool
+	
+	// Logging Configuration
+	LogLevel  string
+	LogFormat string
+	
+	// Metrics Configuration
+	MetricsEnabled    bool
+	MetricsOperations []string
+	
+	// Tracing Configuration
+	TracingExporter    string
+	TracingEndpoint    string
+	TracingInsecure    bool
+	TracingSampleRatio float64
 	
 	// App Configuration
 	AppName        string
@@ -37,9 +76,52 @@ func Load() *Config {
 		}
 	}
 	
+	var metricsOperations []string
+	if opsEnv := getEnv("METRICS_GRAPHQL_OPERATIONS", ""); opsEnv != "" {
+		for _, name := range strings.Split(opsEnv, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				metricsOperations = append(metricsOperations, name)
+			}
+		}
+	}
+	
 	return &Config{
 		Port:        getEnv("PORT", "8080"),
 		DatabaseURL: getEnv("DATABASE_URL", "postgres://localhost/go_goal_dev?sslmode=disable"),
+		AutoMigrate: getBoolEnv("AUTO_MIGRATE", true),
+		
+		// Server Configuration
+		ReadTimeout:       getDurationEnv("READ_TIMEOUT", 15*time.Second),
+		ReadHeaderTimeout: getDurationEnv("READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:      getDurationEnv("WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:       getDurationEnv("IDLE_TIMEOUT", 120*time.Second),
+		ShutdownDelay:     getDurationEnv("SHUTDOWN_DELAY", 0),
+		ShutdownTimeout:   getDurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second),
+		
+		// Database Pool Configuration
+		DBMaxOpenConns:     getIntEnv("DB_MAX_CONNECTIONS", 25),
+		DBMaxIdleConns:     getIntEnv("DB_MAX_IDLE_CONNECTIONS", 5),
+		DBConnMaxLifetime:  getDurationEnv("DB_CONNECTION_LIFETIME", 5*time.Minute),
+		DBConnMaxIdleTime:  getDurationEnv("DB_CONNECTION_IDLE_TIME", time.Minute),
+		DBStatementTimeout: getDurationEnv("DB_STATEMENT_TIMEOUT", 30*time.Second),
+		
+		// Session Configuration
+		SessionTTL:          getDurationEnv("SESSION_TTL", 7*24*time.Hour),
+		SessionCookieSecure: getBoolEnv("SESSION_COOKIE_SECURE", true),
+		
+		// Logging Configuration
+		LogLevel:  strings.ToLower(getEnv("LOG_LEVEL", "info")),
+		LogFormat: strings.ToLower(getEnv("LOG_FORMAT", "json")),
+		
+		// Metrics Configuration
+		MetricsEnabled:    getBoolEnv("METRICS_ENABLED", true),
+		MetricsOperations: metricsOperations,
+		
+		// Tracing Configuration
+		TracingExporter:    strings.ToLower(getEnv("TRACING_EXPORTER", "none")),
+		TracingEndpoint:    getEnv("TRACING_OTLP_ENDPOINT", ""),
+		TracingInsecure:    getBoolEnv("TRACING_OTLP_INSECURE", false),
+		TracingSampleRatio: getFloatEnv("TRACING_SAMPLE_RATIO", 1),
 		
 		// App Configuration
 		AppName:        getEnv("APP_NAME", "Go Goal"),
@@ -59,6 +141,85 @@ func Load() *Config {
 	}
 }
 
+// Validate reports every setting that would prevent the server from starting.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be a number between 1 and 65535, got %q", c.Port))
+	}
+
+	if u, err := url.Parse(c.DatabaseURL); err != nil {
+		errs = append(errs, fmt.Errorf("DATABASE_URL is not a valid URL: %w", err))
+	} else if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		errs = append(errs, fmt.Errorf("DATABASE_URL must use the postgres scheme, got %q", u.Scheme))
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"READ_TIMEOUT", c.ReadTimeout},
+		{"READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+		{"WRITE_TIMEOUT", c.WriteTimeout},
+		{"IDLE_TIMEOUT", c.IdleTimeout},
+		{"SHUTDOWN_DELAY", c.ShutdownDelay},
+		{"SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+		{"DB_CONNECTION_LIFETIME", c.DBConnMaxLifetime},
+		{"DB_CONNECTION_IDLE_TIME", c.DBConnMaxIdleTime},
+		{"DB_STATEMENT_TIMEOUT", c.DBStatementTimeout},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %s", d.name, d.value))
+		}
+	}
+
+	if c.SessionTTL <= 0 {
+		errs = append(errs, fmt.Errorf("SESSION_TTL must be positive, got %s", c.SessionTTL))
+	}
+
+	if c.DBMaxOpenConns < 0 {
+		errs = append(errs, fmt.Errorf("DB_MAX_CONNECTIONS must not be negative, got %d", c.DBMaxOpenConns))
+	}
+	if c.DBMaxIdleConns < 0 {
+		errs = append(errs, fmt.Errorf("DB_MAX_IDLE_CONNECTIONS must not be negative, got %d", c.DBMaxIdleConns))
+	} else if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		errs = append(errs, fmt.Errorf("DB_MAX_IDLE_CONNECTIONS (%d) must not exceed DB_MAX_CONNECTIONS (%d)", c.DBMaxIdleConns, c.DBMaxOpenConns))
+	}
+
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, c.LogLevel) {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be one of debug, info, warn or error, got %q", c.LogLevel))
+	}
+	if !slices.Contains([]string{"json", "text"}, c.LogFormat) {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be json or text, got %q", c.LogFormat))
+	}
+
+	if !slices.Contains([]string{"none", "stdout", "otlp"}, c.TracingExporter) {
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER must be none, stdout or otlp, got %q", c.TracingExporter))
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", c.TracingSampleRatio))
+	}
+
+	if len(c.SupportedLanguages) == 0 {
+		errs = append(errs, errors.New("SUPPORTED_LANGUAGES must list at least one language"))
+	} else if !slices.Contains(c.SupportedLanguages, c.DefaultLanguage) {
+		errs = append(errs, fmt.Errorf("DEFAULT_LANGUAGE %q is not in SUPPORTED_LANGUAGES", c.DefaultLanguage))
+	}
+
+	return errors.Join(errs...)
+}
+
+// RedactedDatabaseURL returns DatabaseURL with any password masked, for logging.
+func (c *Config) RedactedDatabaseURL() string {
+	u, err := url.Parse(c.DatabaseURL)
+	if err != nil {
+		return "<invalid>"
+	}
+	return u.Redacted()
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -73,4 +234,33 @@ func getBoolEnv(key string, defaultValue bool) bool {
 		}
 	}
 	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getDurationEnv parses values such as "30s" or "2m"; zero disables the
+// corresponding timeout.
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
 }
\ No newline at end of file